@@ -2,44 +2,582 @@ package cloud
 
 import (
 	"errors"
-	"lucky-go/config"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"lucky-go/config"
+	"lucky-go/output"
 )
 
-// rebootCmd 表示重启命令
-var rebootCmd = &cobra.Command{
-	Use:   "reboot [destination]",
-	Short: "重启目标机器",
-	Long:  `重启由目标名称指定的云实例。`,
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
-			return errors.New("必须提供目标")
-		}
+// selectorHelp 是接受选择器的子命令共用的说明
+const selectorHelp = `选择器支持名称通配符（web-*）、分组（@web）以及条件过滤（tag=prod,region=ap-hongkong），
+匹配多个目标时按 --parallel 限制并发，并在结束后输出每个目标的结果表格。`
+
+// NewCommand 为云模块创建并返回 cloud 命令及其子命令。
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cloud",
+		Short: "管理云服务器实例",
+		Long: `管理配置中目标对应的云服务器实例。
 
-		destination := args[0]
+目标的 provider 字段决定使用的云平台：lighthouse（腾讯云轻量应用服务器，默认）、
+cvm（腾讯云云服务器）或 fake（本地模拟，状态保存在 ~/.lucky-go/fake-cloud.json，
+可通过 LUCKY_GO_FAKE_CLOUD 指定文件，设为 memory 时只保存在内存中）。
 
-		if destination == "" {
-			return errors.New("必须提供目标")
+示例:
+  lucky-go cloud status
+  lucky-go cloud reboot @web --parallel 2
+  lucky-go cloud reboot web-1 --snapshot --wait
+  lucky-go cloud stop dev
+  lucky-go cloud list --region ap-hongkong
+  lucky-go cloud describe web-1
+  lucky-go cloud create --name test-1 --region ap-hongkong --blueprint lhbp-xxx --bundle xxx --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud destroy test-1
+  lucky-go cloud snapshot list web-1
+  lucky-go cloud firewall apply @web
+  lucky-go cloud keypair bind web-1 --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud traffic --watch
+  lucky-go cloud expiry --notify-within 14d
+  lucky-go cloud run web-1 -- systemctl restart sshd
+  lucky-go cloud metrics web-1 --metric cpu,wan-out --since 6h --chart
+  lucky-go cloud scheduler run
+  lucky-go cloud sync --regions ap-hongkong
+  lucky-go cloud status -o json
+
+修改实例的命令都支持 --dry-run，只显示将要发送的 API 调用和目标实例，不发送任何请求。
+重启、关机、销毁、回滚或删除快照、删除防火墙规则、绑定或解绑密钥对前需要输入目标名称确认（多个目标时输入目标数量），
+使用 --yes 跳过确认。配置了 protected: true 的目标需要同时使用 --force 才会执行这些操作。
+
+使用 -o json 或 -o yaml 时标准输出只包含结果，进度和确认提示输出到标准错误。
+退出码:
+  0  成功
+  1  其他错误，如参数错误或取消操作
+  3  目标或分组不在配置中，或选择器没有匹配任何目标
+  4  目标缺少 region、instance-id，或 provider 不支持该操作
+  5  云平台 API 返回错误
+  6  等待实例或快照超时
+  7  目标受保护，没有使用 --force
+  8  cloud run 在实例上执行的命令失败
+批量操作中失败目标的原因相同时使用对应的退出码，否则为 1。
+
+腾讯云凭证依次从以下来源查找:
+  1. --secret-id 和 --secret-key
+  2. --account 或目标的 account 字段指定的账号（配置中的 accounts）
+  3. 密钥库的 tencent-cloud-secret-id、tencent-cloud-secret-key，或环境变量
+     TENCENT_CLOUD_SECRET_ID、TENCENT_CLOUD_SECRET_KEY
+  4. tccli 的凭证文件 ~/.tccli/default.credential
+账号的密钥保存在密钥库的 tencent-cloud-secret-id@<账号> 和 tencent-cloud-secret-key@<账号>，
+或通过 tccli-profile 读取 tccli 的凭证文件；配置了 role-arn 时通过 STS AssumeRole
+获取临时凭证，过期前自动刷新。`,
+	}
+
+	cmd.PersistentFlags().StringP("output", "o", output.Table, "输出格式：table、json 或 yaml")
+	cmd.PersistentFlags().Bool("dry-run", false, "只显示将要发送的 API 调用，不修改任何内容")
+	cmd.PersistentFlags().BoolP("yes", "y", false, "跳过确认")
+	cmd.PersistentFlags().Bool("force", false, "允许对受保护（protected: true）的目标执行破坏性操作")
+	cmd.PersistentFlags().String("account", "", "使用配置中 accounts 的账号，覆盖目标的 account 字段")
+	cmd.PersistentFlags().String("secret-id", "", "腾讯云 SecretId，优先于其他凭证来源")
+	cmd.PersistentFlags().String("secret-key", "", "腾讯云 SecretKey，需要和 --secret-id 一起使用")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		flags, err := loadCredentialFlags(cmd)
+		if err != nil {
+			return err
 		}
+		credentialFlags = flags
 
-		destinationInstance, err := config.LoadDestinationInstance(destination)
+		endpoint, err := loadTencentEndpoint()
 		if err != nil {
 			return err
 		}
+		tencentEndpoint = endpoint
+		return nil
+	}
+
+	cmd.AddCommand(newPowerCommand(powerAction{use: "reboot", short: "重启目标机器", action: "重启", api: "RebootInstances", pending: "REBOOTING", run: RebootInstance, waitable: true, snapshotable: true, destructive: true}))
+	cmd.AddCommand(newPowerCommand(powerAction{use: "start", short: "开机目标机器", action: "开机", api: "StartInstances", pending: "STARTING", run: StartInstance, waitable: true}))
+	cmd.AddCommand(newPowerCommand(powerAction{use: "stop", short: "关机目标机器", action: "关机", api: "StopInstances", pending: "STOPPING", run: StopInstance, destructive: true}))
+	cmd.AddCommand(newStatusCommand())
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newDescribeCommand())
+	cmd.AddCommand(newCreateCommand())
+	cmd.AddCommand(newDestroyCommand())
+	cmd.AddCommand(newSnapshotCommand())
+	cmd.AddCommand(newFirewallCommand())
+	cmd.AddCommand(newKeyPairCommand())
+	cmd.AddCommand(newTrafficCommand())
+	cmd.AddCommand(newExpiryCommand())
+	cmd.AddCommand(newRunCommand())
+	cmd.AddCommand(newMetricsCommand())
+	cmd.AddCommand(newSchedulerCommand())
+	cmd.AddCommand(newSyncCommand())
+
+	withExitCodes(cmd)
+
+	return cmd
+}
+
+// powerAction 描述一个开关机类操作
+type powerAction struct {
+	use, short, action string
+	// api 是 --dry-run 时显示的 API 名称
+	api string
+	// pending 是操作提交后实例进入的中间状态
+	pending string
+	run     func(dest *config.DestinationInstance) (string, error)
+	// waitable 表示操作完成后实例应回到 RUNNING，可以使用 --wait 等待
+	waitable bool
+	// snapshotable 表示可以使用 --snapshot 在操作前创建快照
+	snapshotable bool
+	// destructive 表示操作会中断服务，需要输入目标名称确认，受保护的目标需要 --force
+	destructive bool
+}
+
+// newPowerCommand 创建对选中目标执行开关机类操作的子命令
+func newPowerCommand(power powerAction) *cobra.Command {
+	var parallel int
+	var wait, snapshot bool
+	var opts waitOptions
+	var snapshotTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   power.use + " [destination|selector...]",
+		Short: power.short,
+		Long:  fmt.Sprintf("%v由目标名称或选择器指定的云实例。\n\n%v", power.action, selectorHelp),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if (wait && opts.Timeout <= 0) || ((wait || snapshot) && opts.Interval <= 0) {
+				return fmt.Errorf("--timeout 和 --interval 必须大于 0")
+			}
+			if snapshot && snapshotTimeout <= 0 {
+				return fmt.Errorf("--snapshot-timeout 必须大于 0")
+			}
+
+			dests, err := config.LoadDestinations(args...)
+			if err != nil {
+				return err
+			}
+
+			flags := loadMutationFlags(cmd)
+			if power.destructive {
+				if err := guardDestructive(cmd, progressWriter(cmd, format), dests, power.action, flags); err != nil {
+					return err
+				}
+			}
+			if flags.DryRun {
+				return dryRunPower(cmd, format, dests, power, snapshot)
+			}
 
-		err = RebootInstance(destinationInstance)
+			index := make(map[string]int, len(dests))
+			for i, dest := range dests {
+				index[dest.Name] = i
+			}
 
+			details := make([]ActionResult, len(dests))
+			opts.Out = &syncWriter{w: progressWriter(cmd, format)}
+			results := runFleet(dests, parallel, func(dest *config.Destination) error {
+				detail := &details[index[dest.Name]]
+				if err := requireInstance(dest); err != nil {
+					return err
+				}
+				if snapshot {
+					// 大磁盘的快照可能比开机慢得多，使用单独的 --snapshot-timeout
+					snapshotOpts := opts
+					snapshotOpts.Timeout = snapshotTimeout
+					snapshotId, err := createSnapshotAndWait(dest, autoSnapshotName(dest.Name), snapshotOpts)
+					detail.SnapshotId = snapshotId
+					if err != nil {
+						return fmt.Errorf("%v前创建快照失败: %w", power.action, err)
+					}
+				}
+				requestId, err := power.run(&dest.DestinationInstance)
+				detail.RequestId = requestId
+				if err != nil {
+					return err
+				}
+				detail.State = power.pending
+				fmt.Fprintf(opts.Out, "%v: 已提交%v请求\n", dest.Name, power.action)
+
+				// 清理旧快照只是附带的维护，失败时不影响已提交的操作
+				if snapshot {
+					if err := pruneSnapshots(dest, opts.Out); err != nil {
+						fmt.Fprintf(opts.Out, "警告: %v: 按保留策略清理快照失败: %v\n", dest.Name, err)
+					}
+				}
+
+				if !wait {
+					return nil
+				}
+				if err := waitForInstance(dest, opts); err != nil {
+					return err
+				}
+				detail.State = "RUNNING"
+				return nil
+			})
+
+			if format != output.Table {
+				if err := output.Write(cmd.OutOrStdout(), format, newActionResults(power.use, results, details)); err != nil {
+					return err
+				}
+			} else if len(results) > 1 {
+				renderFleetTable(cmd.OutOrStdout(), results)
+			}
+
+			return fleetError(power.action, results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量操作时的最大并发数")
+	if power.waitable {
+		cmd.Flags().BoolVar(&wait, "wait", false, "等待实例回到 RUNNING 且 SSH 端口可以连接")
+		cmd.Flags().DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "--wait 的最长等待时间")
+		cmd.Flags().DurationVar(&opts.Interval, "interval", 5*time.Second, "--wait 和 --snapshot 的轮询间隔")
+	}
+	if power.snapshotable {
+		cmd.Flags().BoolVar(&snapshot, "snapshot", false, power.action+"前先创建快照并等待完成，随后按保留策略清理旧快照")
+		cmd.Flags().DurationVar(&snapshotTimeout, "snapshot-timeout", 10*time.Minute, "--snapshot 等待快照完成的最长时间")
+	}
+
+	return cmd
+}
+
+// dryRunPower 输出开关机类操作将要发送的 API 调用，缺少实例配置的目标视为失败
+func dryRunPower(cmd *cobra.Command, format string, dests []config.Destination, power powerAction, snapshot bool) error {
+	var calls []apiCall
+	var results []fleetResult
+	for i := range dests {
+		dest := &dests[i]
+		err := requireInstance(dest)
+		results = append(results, fleetResult{Name: dest.Name, InstanceId: dest.InstanceId, Err: err})
 		if err != nil {
+			fmt.Fprintf(progressWriter(cmd, format), "%v: %v\n", dest.Name, err)
+			continue
+		}
+
+		if snapshot {
+			calls = append(calls, createSnapshotCall(dest, autoSnapshotName(dest.Name)))
+		}
+		calls = append(calls, powerCall(dest, power.api))
+	}
+
+	if err := writeDryRun(cmd.OutOrStdout(), format, calls); err != nil {
+		return err
+	}
+	return fleetError(power.action, results)
+}
+
+// newStatusCommand 创建 cloud status 子命令，显示目标的状态、地址、套餐、到期时间和流量
+func newStatusCommand() *cobra.Command {
+	var parallel int
+
+	cmd := &cobra.Command{
+		Use:   "status [selector...]",
+		Short: "显示目标实例的状态",
+		Long:  "显示目标实例的状态、公网 IP、套餐、到期时间和流量使用情况，默认显示所有配置了 instance-id 的目标。\n\n" + selectorHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dests, err := loadCloudDestinations(args)
+			if err != nil {
+				return err
+			}
+
+			index := make(map[string]int, len(dests))
+			for i, dest := range dests {
+				index[dest.Name] = i
+			}
+
+			instances := make([]*Instance, len(dests))
+			results := runFleet(dests, parallel, func(dest *config.Destination) error {
+				if err := requireInstance(dest); err != nil {
+					return err
+				}
+				instance, err := DescribeInstance(&dest.DestinationInstance)
+				instances[index[dest.Name]] = instance
+				return err
+			})
+
+			if format != output.Table {
+				statuses := make([]StatusResult, len(results))
+				for i, result := range results {
+					statuses[i] = StatusResult{
+						Destination: result.Name,
+						InstanceId:  result.InstanceId,
+						Instance:    instances[i],
+						Error:       newResultError(result.Err),
+					}
+				}
+				if err := output.Write(cmd.OutOrStdout(), format, statuses); err != nil {
+					return err
+				}
+			} else {
+				renderStatusTable(cmd.OutOrStdout(), results, instances)
+			}
+
+			return fleetError("查询", results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量查询时的最大并发数")
+
+	return cmd
+}
+
+// newListCommand 创建 cloud list 子命令，列出地域内的全部实例
+func newListCommand() *cobra.Command {
+	var provider string
+	var regions []string
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出云平台上的实例",
+		Long: `列出指定地域内的全部实例，包括未写入配置的实例。
+
+未指定 --region 时查询配置中目标用到的所有云平台和地域，
+指定 --region 时查询 --provider 对应的云平台（默认为轻量应用服务器）。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			configured := map[string]string{}
+			var targets []listTarget
+			// 配置中还没有目标时只能使用 --region；配置无法读取时报错，而不是显示没有目标名称的实例
+			dests, err := config.LoadDestinations("*")
+			if err != nil && !errors.Is(err, config.ErrDestinationNotFound) {
+				return err
+			}
+			for _, dest := range dests {
+				if dest.InstanceId != "" {
+					configured[dest.InstanceId] = dest.Name
+				}
+			}
+			targets = destinationListTargets(dests)
+
+			if len(regions) > 0 {
+				targets = nil
+				for _, region := range regions {
+					targets = append(targets, listTarget{provider: provider, region: region})
+				}
+			}
+
+			if len(targets) == 0 {
+				return fmt.Errorf("配置中没有目标设置了 region，请使用 --region 指定地域")
+			}
+
+			var instances []Instance
+			var queried []string
+			for _, target := range targets {
+				found, err := ListInstances(target.provider, target.region, target.account)
+				if err != nil {
+					return fmt.Errorf("查询地域 %v 失败: %w", target.region, err)
+				}
+				instances = append(instances, found...)
+				queried = append(queried, target.region)
+			}
+
+			if format != output.Table {
+				entries := make([]InstanceEntry, len(instances))
+				for i, instance := range instances {
+					entries[i] = InstanceEntry{Destination: configured[instance.InstanceId], Instance: instance}
+				}
+				return output.Write(cmd.OutOrStdout(), format, entries)
+			}
+
+			if len(instances) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "地域 %v 中没有任何实例\n", strings.Join(queried, ", "))
+				return nil
+			}
+
+			renderInstanceTable(cmd.OutOrStdout(), instances, configured)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", config.ProviderLighthouse, "与 --region 一起使用时查询的云平台")
+	cmd.Flags().StringSliceVar(&regions, "region", nil, "要查询的地域，可重复指定或用逗号分隔")
+
+	return cmd
+}
+
+// newDescribeCommand 创建 cloud describe 子命令，输出单个实例的详细信息，默认为以目标名称为键的 YAML
+func newDescribeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe [destination]",
+		Short: "显示目标实例的详细信息",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := config.LoadDestination(args[0])
+			if err != nil {
+				return err
+			}
+			if err := requireInstance(dest); err != nil {
+				return err
+			}
+
+			instance, err := DescribeInstance(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, InstanceEntry{Destination: dest.Name, Instance: *instance})
+			}
+
+			bytes, err := yaml.Marshal(map[string]*Instance{dest.Name: instance})
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(bytes)
 			return err
+		},
+	}
+}
+
+// loadCloudDestinations 解析选择器，未提供时返回所有配置了 instance-id 的目标
+func loadCloudDestinations(selectors []string) ([]config.Destination, error) {
+	if len(selectors) > 0 {
+		return config.LoadDestinations(selectors...)
+	}
+
+	dests, err := config.LoadDestinations("instance-id=?*")
+	if err != nil {
+		return nil, fmt.Errorf("配置中没有目标设置了 instance-id")
+	}
+	return dests, nil
+}
+
+// loadInstanceDestination 解析单个目标并要求其配置了实例
+func loadInstanceDestination(selector string) (*config.Destination, error) {
+	dest, err := config.LoadDestination(selector)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireInstance(dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// listTarget 是 cloud list 要查询的云平台、地域和账号
+type listTarget struct {
+	provider string
+	region   string
+	account  string
+}
+
+// destinationListTargets 返回目标用到的云平台、地域和账号组合，排序并去重
+func destinationListTargets(dests []config.Destination) []listTarget {
+	seen := map[listTarget]bool{}
+	var targets []listTarget
+	for _, dest := range dests {
+		if dest.Region == "" {
+			continue
+		}
+		target := listTarget{provider: dest.Provider, region: dest.Region, account: dest.Account}
+		if target.provider == "" {
+			target.provider = config.ProviderLighthouse
+		}
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
 		}
+	}
 
-		return nil
-	},
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].provider != targets[j].provider {
+			return targets[i].provider < targets[j].provider
+		}
+		if targets[i].region != targets[j].region {
+			return targets[i].region < targets[j].region
+		}
+		return targets[i].account < targets[j].account
+	})
+	return targets
 }
 
-// NewCommand 为云模块创建并返回重启命令。
-func NewCommand() *cobra.Command {
-	return rebootCmd
+// colorState 按实例状态着色：运行中为绿色，已关机为红色，其他中间状态为黄色
+func colorState(state string) string {
+	switch state {
+	case "RUNNING":
+		return color.New(color.FgGreen, color.Bold).Sprint(state)
+	case "STOPPED", "SHUTDOWN", "TERMINATING":
+		return color.New(color.FgRed, color.Bold).Sprint(state)
+	default:
+		return color.New(color.FgYellow, color.Bold).Sprint(state)
+	}
+}
+
+// renderStatusTable 渲染目标状态表格，查询失败的目标在状态列显示错误
+func renderStatusTable(w io.Writer, results []fleetResult, instances []*Instance) {
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+
+	table := output.NewTable(w)
+	table.Header([]string{"目标", "实例 ID", "状态", "公网 IP", "套餐", "到期时间", "流量"})
+	for i, result := range results {
+		instance := instances[i]
+		if result.Err != nil || instance == nil {
+			message := "未知"
+			if result.Err != nil {
+				message = result.Err.Error()
+			}
+			_ = table.Append([]string{result.Name, result.InstanceId, red(message), "", "", "", ""})
+			continue
+		}
+
+		_ = table.Append([]string{
+			result.Name,
+			instance.InstanceId,
+			colorState(instance.State),
+			strings.Join(instance.PublicIPs, ", "),
+			instance.BundleId,
+			formatExpiry(instance.ExpiredTime),
+			formatTraffic(instance.Traffic),
+		})
+	}
+
+	_ = table.Render()
+}
+
+// renderInstanceTable 渲染地域内的实例列表，configured 把实例 ID 映射到配置中的目标名称
+func renderInstanceTable(w io.Writer, instances []Instance, configured map[string]string) {
+	table := output.NewTable(w)
+	table.Header([]string{"云平台", "地域", "实例 ID", "实例名称", "目标", "状态", "公网 IP", "套餐", "到期时间"})
+	for _, instance := range instances {
+		_ = table.Append([]string{
+			instance.Provider,
+			instance.Region,
+			instance.InstanceId,
+			instance.Name,
+			configured[instance.InstanceId],
+			colorState(instance.State),
+			strings.Join(instance.PublicIPs, ", "),
+			instance.BundleId,
+			formatExpiry(instance.ExpiredTime),
+		})
+	}
+
+	_ = table.Render()
 }
@@ -0,0 +1,89 @@
+package cloud
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newExpiryCommand 创建 cloud expiry 子命令，显示实例到期时间并推送续费提醒
+func newExpiryCommand() *cobra.Command {
+	var parallel int
+	var notifyWithin string
+
+	cmd := &cobra.Command{
+		Use:   "expiry [selector...]",
+		Short: "显示实例到期时间并推送续费提醒",
+		Long: `显示目标实例的到期时间和剩余天数，按紧急程度排序。
+默认显示所有配置了 instance-id 的目标。
+
+使用 --notify-within 时把该时长内到期的实例汇总为一条 Telegram 消息推送，适合放在定时任务中。
+进入提醒窗口时提醒一次，之后剩余 7、3、1 天和到期当天各再提醒一次，
+已提醒的节点记录在 ~/.lucky-go/expiry-state.json（可通过 LUCKY_GO_EXPIRY_STATE 指定），
+不会每次运行都重复推送；续费后到期时间变化，重新开始提醒。
+--dry-run 时只显示将要推送的消息。
+
+` + selectorHelp,
+		Example: `  lucky-go cloud expiry
+  lucky-go cloud expiry --notify-within 14d`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			within := 0
+			if notifyWithin != "" {
+				window, err := config.ParseAge(notifyWithin)
+				if err != nil || window < 24*time.Hour {
+					return fmt.Errorf("--notify-within %q 格式不正确，应为至少 1 天的时长，如 14d", notifyWithin)
+				}
+				within = int(window / (24 * time.Hour))
+			}
+
+			dests, err := loadCloudDestinations(args)
+			if err != nil {
+				return err
+			}
+
+			index := make(map[string]int, len(dests))
+			for i, dest := range dests {
+				index[dest.Name] = i
+			}
+			instances := make([]*Instance, len(dests))
+			results := runFleet(dests, parallel, func(dest *config.Destination) error {
+				if err := requireInstance(dest); err != nil {
+					return err
+				}
+				instance, err := DescribeInstance(&dest.DestinationInstance)
+				instances[index[dest.Name]] = instance
+				return err
+			})
+
+			expiries := newExpiryResults(results, instances)
+			if format != output.Table {
+				if err := output.Write(cmd.OutOrStdout(), format, expiries); err != nil {
+					return err
+				}
+			} else {
+				renderExpiryTable(cmd.OutOrStdout(), expiries)
+			}
+
+			if within > 0 {
+				if err := notifyExpiry(progressWriter(cmd, format), expiries, within, loadMutationFlags(cmd).DryRun); err != nil {
+					return err
+				}
+			}
+			return fleetError("查询", results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量查询时的最大并发数")
+	cmd.Flags().StringVar(&notifyWithin, "notify-within", "", "推送该时长内到期的实例，如 14d")
+
+	return cmd
+}
@@ -0,0 +1,367 @@
+package cloud
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newFirewallCommand 创建 cloud firewall 命令及其子命令
+func newFirewallCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "firewall",
+		Short: "管理目标实例的防火墙规则",
+		Long: `查看和修改目标实例的防火墙规则，目前仅支持轻量应用服务器和 fake provider。
+
+apply 按目标配置中的 firewall 字段同步规则，先显示添加（+）和删除（-）的规则，确认后再执行：
+
+  dest:
+    web:
+      firewall:
+        - {protocol: TCP, port: "22", cidr: 10.0.0.0/8, description: SSH}
+        - {protocol: TCP, port: "80,443"}
+        - {protocol: ICMP}
+
+cidr 默认为 0.0.0.0/0，action 默认为 ACCEPT，ICMP 和 ALL 协议的 port 默认为 ALL。
+规则按协议、端口、来源和策略比较，仅描述不同的规则视为相同。`,
+	}
+
+	cmd.AddCommand(newFirewallListCommand())
+	cmd.AddCommand(newFirewallRuleCommand("add", "添加一条防火墙规则", true))
+	cmd.AddCommand(newFirewallRuleCommand("remove", "删除一条防火墙规则", false))
+	cmd.AddCommand(newFirewallApplyCommand())
+
+	return cmd
+}
+
+// newFirewallListCommand 创建 cloud firewall list 子命令
+func newFirewallListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list [destination]",
+		Aliases: []string{"ls"},
+		Short:   "列出目标实例的防火墙规则",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+
+			firewall, err := destinationFirewall(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			rules, err := firewall.ListFirewallRules(dest.InstanceId)
+			if err != nil {
+				return err
+			}
+
+			if format != output.Table {
+				if rules == nil {
+					rules = []config.FirewallRule{}
+				}
+				return output.Write(cmd.OutOrStdout(), format, rules)
+			}
+
+			if len(rules) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%v 没有任何防火墙规则\n", dest.Name)
+				return nil
+			}
+
+			renderFirewallTable(cmd.OutOrStdout(), rules)
+			return nil
+		},
+	}
+}
+
+// newFirewallRuleCommand 创建添加或删除单条规则的子命令
+func newFirewallRuleCommand(use, short string, add bool) *cobra.Command {
+	var rule config.FirewallRule
+
+	cmd := &cobra.Command{
+		Use:   use + " [destination]",
+		Short: short,
+		Example: fmt.Sprintf(`  lucky-go cloud firewall %v web --protocol TCP --port 8080 --cidr 10.0.0.0/8
+  lucky-go cloud firewall %v web --protocol ICMP`, use, use),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+			rule = rule.Normalize()
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			firewall, err := destinationFirewall(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+
+			current, err := firewall.ListFirewallRules(dest.InstanceId)
+			if err != nil {
+				return err
+			}
+			existing := findFirewallRule(current, rule)
+
+			var plan firewallPlan
+			if add {
+				if existing != nil {
+					return fmt.Errorf("%v 已存在防火墙规则 %v", dest.Name, *existing)
+				}
+				plan.Add = []config.FirewallRule{rule}
+			} else {
+				if existing == nil {
+					return fmt.Errorf("%v 不存在防火墙规则 %v", dest.Name, rule)
+				}
+				// 删除时使用云平台上的原始规则，包括描述
+				rule = *existing
+				plan.Remove = []config.FirewallRule{rule}
+
+				// 删除规则可能断开 SSH 等连接
+				if err := guardDestructive(cmd, progressWriter(cmd, format), []config.Destination{*dest}, "删除防火墙规则", loadMutationFlags(cmd)); err != nil {
+					return err
+				}
+			}
+
+			if loadMutationFlags(cmd).DryRun {
+				return writeDryRun(cmd.OutOrStdout(), format, firewallCalls(dest, plan))
+			}
+			if err := applyFirewallPlan(firewall, dest.InstanceId, plan); err != nil {
+				return err
+			}
+
+			action := "删除"
+			if add {
+				action = "添加"
+			}
+			out := progressWriter(cmd, format)
+			fmt.Fprintf(out, "%v: 已%v防火墙规则 %v\n", dest.Name, action, rule)
+			if len(dest.Firewall) > 0 {
+				fmt.Fprintf(out, "提示: %v 在配置中声明了 firewall，下次 apply 时会按配置覆盖此修改\n", dest.Name)
+			}
+
+			if format != output.Table {
+				result := FirewallResult{Destination: dest.Name, InstanceId: dest.InstanceId, Applied: true}
+				if add {
+					result.Add = []config.FirewallRule{rule}
+				} else {
+					result.Remove = []config.FirewallRule{rule}
+				}
+				return output.Write(cmd.OutOrStdout(), format, result)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rule.Protocol, "protocol", "TCP", "协议：TCP、UDP、ICMP 或 ALL")
+	cmd.Flags().StringVar(&rule.Port, "port", "", "端口，如 22、80,443、8000-9000 或 ALL")
+	cmd.Flags().StringVar(&rule.Cidr, "cidr", "0.0.0.0/0", "来源 IP 或网段")
+	cmd.Flags().StringVar(&rule.Action, "action", "ACCEPT", "策略：ACCEPT 或 DROP")
+	if add {
+		cmd.Flags().StringVar(&rule.Description, "description", "", "规则描述")
+	}
+
+	return cmd
+}
+
+// newFirewallApplyCommand 创建 cloud firewall apply 子命令，把配置中的规则同步到云平台
+func newFirewallApplyCommand() *cobra.Command {
+	var parallel int
+
+	cmd := &cobra.Command{
+		Use:   "apply [selector...]",
+		Short: "按配置同步防火墙规则",
+		Long:  "按目标配置中的 firewall 字段同步防火墙规则，默认处理所有声明了 firewall 的目标。\n云平台按顺序匹配规则，顺序与配置不同时按配置顺序替换全部规则。\n执行前先显示变更计划，未指定 --yes 时需要确认：只添加规则时输入 y，\n需要删除或替换规则时输入目标名称（多个目标时输入目标数量）；有变更的目标受保护时需要 --force。\n\n" + selectorHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dests, err := loadFirewallDestinations(args)
+			if err != nil {
+				return err
+			}
+
+			index := make(map[string]int, len(dests))
+			for i, dest := range dests {
+				index[dest.Name] = i
+			}
+
+			out := progressWriter(cmd, format)
+			plans := make([]firewallPlan, len(dests))
+			results := runFleet(dests, parallel, func(dest *config.Destination) error {
+				plan, err := planDestinationFirewall(dest)
+				plans[index[dest.Name]] = plan
+				return err
+			})
+
+			summary := make([]FirewallResult, len(results))
+			changed := 0
+			for i, result := range results {
+				summary[i] = FirewallResult{
+					Destination: result.Name,
+					InstanceId:  result.InstanceId,
+					Add:         plans[i].Add,
+					Remove:      plans[i].Remove,
+					Replace:     plans[i].Replace,
+					Error:       newResultError(result.Err),
+				}
+				if result.Err != nil {
+					fmt.Fprintf(out, "%v: %v\n", result.Name, result.Err)
+					continue
+				}
+				renderFirewallPlan(out, result.Name, plans[i])
+				if !plans[i].Empty() {
+					changed++
+				}
+			}
+
+			writeSummary := func() error {
+				if format == output.Table {
+					return nil
+				}
+				return output.Write(cmd.OutOrStdout(), format, summary)
+			}
+
+			if err := fleetError("查询防火墙", results); err != nil {
+				if writeErr := writeSummary(); writeErr != nil {
+					return writeErr
+				}
+				return err
+			}
+
+			if changed == 0 {
+				return writeSummary()
+			}
+
+			var pending []config.Destination
+			for i, dest := range dests {
+				if !plans[i].Empty() {
+					pending = append(pending, dest)
+				}
+			}
+
+			flags := loadMutationFlags(cmd)
+			if err := requireUnprotected(pending, "修改防火墙", flags); err != nil {
+				return err
+			}
+			if flags.DryRun {
+				var calls []apiCall
+				for i := range pending {
+					calls = append(calls, firewallCalls(&pending[i], plans[index[pending[i].Name]])...)
+				}
+				return writeDryRun(cmd.OutOrStdout(), format, calls)
+			}
+
+			// 删除或替换规则可能断开 SSH 等连接，与其他破坏性操作一样要求输入目标名称或数量确认
+			destructive := false
+			for i := range pending {
+				plan := plans[index[pending[i].Name]]
+				if len(plan.Remove) > 0 || plan.Replace != nil {
+					destructive = true
+				}
+			}
+			if !flags.Yes {
+				if destructive {
+					if err := confirmDestinations(cmd.InOrStdin(), out, pending, "删除或替换防火墙规则"); err != nil {
+						return err
+					}
+				} else {
+					fmt.Fprintf(out, "确认对 %d 个目标应用以上防火墙变更？[y/N]: ", changed)
+					if !confirm(cmd.InOrStdin()) {
+						return fmt.Errorf("已取消同步")
+					}
+				}
+			}
+
+			results = runFleet(pending, parallel, func(dest *config.Destination) error {
+				firewall, err := destinationFirewall(&dest.DestinationInstance)
+				if err != nil {
+					return err
+				}
+				return applyFirewallPlan(firewall, dest.InstanceId, plans[index[dest.Name]])
+			})
+
+			for _, result := range results {
+				i := index[result.Name]
+				summary[i].Applied = result.Err == nil
+				summary[i].Error = newResultError(result.Err)
+			}
+			if format == output.Table {
+				renderFleetTable(out, results)
+			} else if err := writeSummary(); err != nil {
+				return err
+			}
+			return fleetError("同步防火墙", results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量操作时的最大并发数")
+
+	return cmd
+}
+
+// loadFirewallDestinations 解析选择器并只保留声明了 firewall 的目标
+func loadFirewallDestinations(selectors []string) ([]config.Destination, error) {
+	dests, err := loadCloudDestinations(selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	var declared []config.Destination
+	for _, dest := range dests {
+		if len(dest.Firewall) > 0 {
+			declared = append(declared, dest)
+		}
+	}
+	if len(declared) == 0 {
+		return nil, fmt.Errorf("选中的目标都没有在配置中声明 firewall 规则")
+	}
+	return declared, nil
+}
+
+// planDestinationFirewall 校验目标声明的规则并计算同步所需的变更
+func planDestinationFirewall(dest *config.Destination) (firewallPlan, error) {
+	if err := requireInstance(dest); err != nil {
+		return firewallPlan{}, err
+	}
+	if err := dest.Validate(); err != nil {
+		return firewallPlan{}, err
+	}
+
+	firewall, err := destinationFirewall(&dest.DestinationInstance)
+	if err != nil {
+		return firewallPlan{}, err
+	}
+	current, err := firewall.ListFirewallRules(dest.InstanceId)
+	if err != nil {
+		return firewallPlan{}, err
+	}
+
+	return planFirewall(current, dest.Firewall), nil
+}
+
+// findFirewallRule 返回 rules 中与 rule 相同的规则，不存在时返回 nil
+func findFirewallRule(rules []config.FirewallRule, rule config.FirewallRule) *config.FirewallRule {
+	for i := range rules {
+		if rules[i].Key() == rule.Key() {
+			return &rules[i]
+		}
+	}
+	return nil
+}
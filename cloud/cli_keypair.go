@@ -0,0 +1,499 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	lighthouse "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/lighthouse/v20200324"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// pendingKeyId 是 --dry-run 时代替尚未导入的密钥对 ID 的占位符
+const pendingKeyId = "<ImportKeyPair 返回的 ID>"
+
+// newKeyPairCommand 创建 cloud keypair 命令及其子命令
+func newKeyPairCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "keypair",
+		Aliases: []string{"key"},
+		Short:   "管理 SSH 密钥对及其与实例的绑定",
+		Long: `管理地域内的 SSH 密钥对，并把密钥对绑定到目标实例，目前仅支持轻量应用服务器和 fake provider。
+
+bind --from 读取本地公钥，地域内已有相同公钥的密钥对时直接使用，否则先导入。
+绑定成功后把目标的 identity-file 更新为对应的私钥（--identity-file，或去掉 .pub 后缀的同名文件）。
+绑定和解绑期间运行中的实例会被关机，需要输入目标名称确认，受保护的目标需要 --force。`,
+		Example: `  lucky-go cloud keypair list
+  lucky-go cloud keypair create deploy --region ap-hongkong
+  lucky-go cloud keypair import alice --region ap-hongkong --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud keypair bind web-1 --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud keypair unbind web-1 lhkp-xxxxxxxx`,
+	}
+
+	cmd.AddCommand(newKeyPairListCommand())
+	cmd.AddCommand(newKeyPairCreateCommand())
+	cmd.AddCommand(newKeyPairImportCommand())
+	cmd.AddCommand(newKeyPairBindCommand())
+	cmd.AddCommand(newKeyPairUnbindCommand())
+
+	return cmd
+}
+
+// newKeyPairListCommand 创建 cloud keypair list 子命令
+func newKeyPairListCommand() *cobra.Command {
+	var provider string
+	var regions []string
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出地域内的密钥对及绑定的目标",
+		Long: `列出地域内的全部密钥对，以及绑定了密钥对的目标（未写入配置的实例显示实例 ID）。
+
+未指定 --region 时查询配置中目标用到的地域（跳过不支持密钥对的云平台），
+指定 --region 时查询 --provider 对应的云平台（默认为轻量应用服务器）。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			configured := map[string]string{}
+			var targets []listTarget
+			if dests, err := config.LoadDestinations("*"); err == nil {
+				for _, dest := range dests {
+					if dest.InstanceId != "" {
+						configured[dest.InstanceId] = dest.Name
+					}
+				}
+				targets = destinationListTargets(dests)
+			}
+
+			explicit := len(regions) > 0
+			if explicit {
+				targets = nil
+				for _, region := range regions {
+					targets = append(targets, listTarget{provider: provider, region: region})
+				}
+			}
+
+			entries := []KeyPairEntry{}
+			queried := 0
+			for _, target := range targets {
+				provider, err := NewProvider(target.provider, target.region, target.account)
+				if err != nil {
+					return err
+				}
+				keyPairs, ok := provider.(KeyPairProvider)
+				if !ok {
+					// 配置中的目标可能使用不支持密钥对的云平台，只在明确指定时报错
+					if !explicit {
+						continue
+					}
+					return configErrorf("provider %v 不支持密钥对管理", target.provider)
+				}
+
+				found, err := keyPairs.ListKeyPairs()
+				if err != nil {
+					return fmt.Errorf("查询地域 %v 的密钥对失败: %w", target.region, err)
+				}
+				queried++
+				for _, keyPair := range found {
+					entry := KeyPairEntry{Provider: target.provider, Region: target.region, KeyPair: keyPair}
+					for _, instanceId := range keyPair.AssociatedInstanceIds {
+						if name, ok := configured[instanceId]; ok {
+							entry.Destinations = append(entry.Destinations, name)
+						}
+					}
+					entries = append(entries, entry)
+				}
+			}
+
+			if queried == 0 {
+				return fmt.Errorf("配置中没有支持密钥对的目标设置了 region，请使用 --region 指定地域")
+			}
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, entries)
+			}
+
+			if len(entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "没有任何密钥对")
+				return nil
+			}
+
+			renderKeyPairTable(cmd.OutOrStdout(), entries, configured)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&provider, "provider", config.ProviderLighthouse, "与 --region 一起使用时查询的云平台")
+	cmd.Flags().StringSliceVar(&regions, "region", nil, "要查询的地域，可重复指定或用逗号分隔")
+
+	return cmd
+}
+
+// renderKeyPairTable 渲染密钥对表格，绑定的实例显示为目标名称，未写入配置的显示实例 ID
+func renderKeyPairTable(w io.Writer, entries []KeyPairEntry, configured map[string]string) {
+	table := output.NewTable(w)
+	table.Header([]string{"地域", "密钥对 ID", "名称", "绑定", "创建时间"})
+	for _, entry := range entries {
+		bound := make([]string, 0, len(entry.AssociatedInstanceIds))
+		for _, instanceId := range entry.AssociatedInstanceIds {
+			if name, ok := configured[instanceId]; ok {
+				bound = append(bound, name)
+			} else {
+				bound = append(bound, instanceId)
+			}
+		}
+		_ = table.Append([]string{entry.Region, entry.KeyId, entry.KeyName, strings.Join(bound, ", "), entry.CreatedTime})
+	}
+
+	_ = table.Render()
+}
+
+// regionFlags 是 create 和 import 指定密钥对所在云平台和地域的标志
+type regionFlags struct {
+	provider string
+	region   string
+}
+
+// add 为命令添加 --provider 和 --region 标志
+func (flags *regionFlags) add(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&flags.provider, "provider", config.ProviderLighthouse, "密钥对所在的云平台")
+	cmd.Flags().StringVar(&flags.region, "region", "", "密钥对所在的地域")
+	_ = cmd.MarkFlagRequired("region")
+}
+
+// keyPairs 返回对应云平台和地域的密钥对管理能力，使用 --account 或默认凭证
+func (flags *regionFlags) keyPairs() (KeyPairProvider, error) {
+	return keyPairProvider(flags.provider, flags.region, "")
+}
+
+// call 返回地域级别的 API 调用，用于 --dry-run
+func (flags *regionFlags) call(action string, params any) apiCall {
+	return newAPICall(&config.Destination{
+		Name:                flags.region,
+		DestinationInstance: config.DestinationInstance{Provider: flags.provider, Region: flags.region},
+	}, action, params)
+}
+
+// newKeyPairCreateCommand 创建 cloud keypair create 子命令
+func newKeyPairCreateCommand() *cobra.Command {
+	var flags regionFlags
+	var privateKeyFile string
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "创建密钥对并保存私钥",
+		Long: `在地域内创建密钥对，私钥只在创建时返回一次，保存到 --private-key-file
+（默认为 ~/.ssh/lucky-go-<name>，权限 0600），文件已存在时不会覆盖。
+名称只能包含字母、数字和下划线，不超过 25 个字符。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			name := args[0]
+			if err := validateKeyPairName(name); err != nil {
+				return err
+			}
+
+			if privateKeyFile == "" {
+				privateKeyFile = "~/.ssh/lucky-go-" + name
+			}
+			path, err := expandHome(privateKeyFile)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("私钥文件 %v 已存在，请使用 --private-key-file 指定其他路径", privateKeyFile)
+			}
+
+			if loadMutationFlags(cmd).DryRun {
+				call := flags.call("CreateKeyPair", &lighthouse.CreateKeyPairRequest{KeyName: &name})
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{call})
+			}
+
+			keyPairs, err := flags.keyPairs()
+			if err != nil {
+				return err
+			}
+			keyPair, privateKey, err := keyPairs.CreateKeyPair(name)
+			if err != nil {
+				return err
+			}
+
+			// 密钥对已经创建，保存失败时输出私钥，避免私钥丢失
+			if err := writePrivateKey(path, privateKey); err != nil {
+				fmt.Fprint(cmd.ErrOrStderr(), privateKey)
+				return fmt.Errorf("已创建密钥对 %v，但保存私钥失败（私钥已输出到标准错误）: %w", keyPair.KeyId, err)
+			}
+
+			fmt.Fprintf(progressWriter(cmd, format), "已创建密钥对 %v（%v），私钥保存在 %v\n", keyPair.KeyId, name, privateKeyFile)
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, KeyPairEntry{Provider: flags.provider, Region: flags.region, KeyPair: *keyPair, PrivateKeyFile: privateKeyFile})
+			}
+			return nil
+		},
+	}
+
+	flags.add(cmd)
+	cmd.Flags().StringVar(&privateKeyFile, "private-key-file", "", "保存私钥的路径，默认为 ~/.ssh/lucky-go-<name>")
+
+	return cmd
+}
+
+// writePrivateKey 以 0600 权限写入私钥，文件已存在时返回错误
+func writePrivateKey(path, privateKey string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(privateKey); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// newKeyPairImportCommand 创建 cloud keypair import 子命令
+func newKeyPairImportCommand() *cobra.Command {
+	var flags regionFlags
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "import [name]",
+		Short: "导入本地公钥为密钥对",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			name := args[0]
+			if err := validateKeyPairName(name); err != nil {
+				return err
+			}
+
+			publicKey, _, err := readPublicKey(from)
+			if err != nil {
+				return err
+			}
+
+			if loadMutationFlags(cmd).DryRun {
+				call := flags.call("ImportKeyPair", &lighthouse.ImportKeyPairRequest{KeyName: &name, PublicKey: &publicKey})
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{call})
+			}
+
+			keyPairs, err := flags.keyPairs()
+			if err != nil {
+				return err
+			}
+			keyId, err := keyPairs.ImportKeyPair(name, publicKey)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(progressWriter(cmd, format), "已导入密钥对 %v（%v）\n", keyId, name)
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, KeyPairEntry{Provider: flags.provider, Region: flags.region, KeyPair: KeyPair{KeyId: keyId, KeyName: name, PublicKey: publicKey}})
+			}
+			return nil
+		},
+	}
+
+	flags.add(cmd)
+	cmd.Flags().StringVar(&from, "from", "", "公钥文件，如 ~/.ssh/id_ed25519.pub")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+// newKeyPairBindCommand 创建 cloud keypair bind 子命令
+func newKeyPairBindCommand() *cobra.Command {
+	var from, identityFile string
+
+	cmd := &cobra.Command{
+		Use:   "bind [destination] [key-id...]",
+		Short: "把密钥对绑定到目标实例",
+		Long: `把密钥对绑定到目标实例，密钥对由 ID 或 --from 的公钥文件指定。
+
+使用 --from 时地域内已有相同公钥的密钥对直接使用，否则以公钥注释和指纹命名导入。
+绑定成功后把目标的 identity-file 更新为 --identity-file，未指定时使用 --from 去掉 .pub 后缀的私钥文件（存在时）。
+实例已绑定全部密钥对时只更新配置，不会关机。`,
+		Example: `  lucky-go cloud keypair bind web-1 --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud keypair bind web-1 lhkp-xxxxxxxx --identity-file ~/.ssh/lucky-go-deploy`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if from == "" && len(args) < 2 {
+				return fmt.Errorf("请指定密钥对 ID 或使用 --from 指定公钥文件")
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			keyPairs, err := destinationKeyPairs(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			existing, err := keyPairs.ListKeyPairs()
+			if err != nil {
+				return err
+			}
+
+			result := KeyPairResult{Destination: dest.Name, InstanceId: dest.InstanceId, KeyIds: args[1:]}
+			var importName, publicKey string
+			if from != "" {
+				key, comment, err := readPublicKey(from)
+				if err != nil {
+					return err
+				}
+				if found := findKeyPair(existing, key); found != nil {
+					result.KeyIds = append(result.KeyIds, found.KeyId)
+				} else {
+					publicKey, importName = key, importKeyPairName(key, comment)
+					result.Imported = true
+				}
+				if identityFile == "" {
+					identityFile = identityFileFor(from)
+				}
+			}
+			result.IdentityFile = identityFile
+
+			// 跳过实例已绑定的密钥对，全部已绑定时无需关机
+			var pending []string
+			for _, keyId := range result.KeyIds {
+				keyPair := findKeyPairById(existing, keyId)
+				if keyPair == nil {
+					return fmt.Errorf("地域 %v 不存在密钥对 %v", dest.Region, keyId)
+				}
+				if !contains(keyPair.AssociatedInstanceIds, dest.InstanceId) {
+					pending = append(pending, keyId)
+				}
+			}
+
+			out := progressWriter(cmd, format)
+			flags := loadMutationFlags(cmd)
+			if len(pending) > 0 || result.Imported {
+				if err := guardDestructive(cmd, out, []config.Destination{*dest}, "绑定密钥对（运行中的实例会被关机）", flags); err != nil {
+					return err
+				}
+			}
+
+			if flags.DryRun {
+				var calls []apiCall
+				if result.Imported {
+					calls = append(calls, newAPICall(dest, "ImportKeyPair", &lighthouse.ImportKeyPairRequest{KeyName: &importName, PublicKey: &publicKey}))
+					pending = append(pending, pendingKeyId)
+				}
+				if len(pending) > 0 {
+					calls = append(calls, keyPairCall(dest, "AssociateInstancesKeyPairs", pending))
+				}
+				return writeDryRun(cmd.OutOrStdout(), format, calls)
+			}
+
+			if result.Imported {
+				keyId, err := keyPairs.ImportKeyPair(importName, publicKey)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "已导入密钥对 %v（%v）\n", keyId, importName)
+				result.KeyIds = append(result.KeyIds, keyId)
+				pending = append(pending, keyId)
+			}
+
+			if len(pending) > 0 {
+				if err := keyPairs.AssociateKeyPairs(dest.InstanceId, pending); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%v: 已绑定密钥对 %v\n", dest.Name, strings.Join(pending, ", "))
+			} else {
+				fmt.Fprintf(out, "%v: 已绑定密钥对 %v，无需重复绑定\n", dest.Name, strings.Join(result.KeyIds, ", "))
+			}
+
+			if identityFile != "" && identityFile != dest.IdentityFile {
+				if err := setIdentityFile(dest.Name, identityFile); err != nil {
+					return fmt.Errorf("已绑定密钥对，但更新配置失败: %w", err)
+				}
+				fmt.Fprintf(out, "%v: 已把 identity-file 更新为 %v\n", dest.Name, identityFile)
+			}
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, result)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "公钥文件，如 ~/.ssh/id_ed25519.pub")
+	cmd.Flags().StringVar(&identityFile, "identity-file", "", "绑定成功后写入配置的 SSH 私钥路径")
+
+	return cmd
+}
+
+// newKeyPairUnbindCommand 创建 cloud keypair unbind 子命令
+func newKeyPairUnbindCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unbind [destination] [key-id...]",
+		Short: "解除密钥对与目标实例的绑定",
+		Long:  "解除密钥对与目标实例的绑定，解绑后无法再使用对应的私钥登录，不会修改配置中的 identity-file。",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			keyIds := args[1:]
+
+			out := progressWriter(cmd, format)
+			flags := loadMutationFlags(cmd)
+			if err := guardDestructive(cmd, out, []config.Destination{*dest}, "解绑密钥对（运行中的实例会被关机）", flags); err != nil {
+				return err
+			}
+			if flags.DryRun {
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{keyPairCall(dest, "DisassociateInstancesKeyPairs", keyIds)})
+			}
+
+			keyPairs, err := destinationKeyPairs(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			if err := keyPairs.DisassociateKeyPairs(dest.InstanceId, keyIds); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "%v: 已解绑密钥对 %v\n", dest.Name, strings.Join(keyIds, ", "))
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, KeyPairResult{Destination: dest.Name, InstanceId: dest.InstanceId, KeyIds: keyIds})
+			}
+			return nil
+		},
+	}
+}
+
+// keyPairCall 返回绑定或解绑密钥对的 API 调用，两者的参数相同
+func keyPairCall(dest *config.Destination, action string, keyIds []string) apiCall {
+	return newAPICall(dest, action, map[string]any{"KeyIds": keyIds, "InstanceIds": []string{dest.InstanceId}})
+}
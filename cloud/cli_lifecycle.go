@@ -0,0 +1,263 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	lighthouse "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/lighthouse/v20200324"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newCreateCommand 创建 cloud create 子命令，创建实例并写入配置
+func newCreateCommand() *cobra.Command {
+	var spec InstanceSpec
+	var provider, region, user, from, identityFile string
+	var keyIds, tags []string
+	var opts waitOptions
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "创建实例并写入配置",
+		Long: `按镜像（--blueprint）和套餐（--bundle）创建一台预付费的实例，目前仅支持轻量应用服务器和 fake provider。
+
+实例创建时绑定密钥对（--key-id，或 --from 的公钥，地域内不存在时先导入），等待实例变为 RUNNING 后
+以 --name 为名称写入配置，ssh 为 --user@公网 IP，identity-file 为 --identity-file 或 --from 去掉 .pub 后缀的私钥文件。
+等待超时时实例仍会继续创建，之后可以使用 cloud sync --add 写入配置。`,
+		Example: `  lucky-go cloud create --name test-1 --region ap-hongkong --blueprint lhbp-xxxxxxxx --bundle bundle_starter_mc_promo_med2_02 --from ~/.ssh/id_ed25519.pub
+  lucky-go cloud create --name test-2 --region ap-hongkong --blueprint lhbp-xxxxxxxx --bundle bundle_starter_mc_promo_med2_02 --key-id lhkp-xxxxxxxx --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateDestinationName(spec.Name); err != nil {
+				return err
+			}
+			if from == "" && len(keyIds) == 0 {
+				return fmt.Errorf("请使用 --key-id 或 --from 指定登录实例的密钥对")
+			}
+			if spec.Period <= 0 {
+				return fmt.Errorf("--period 必须大于 0")
+			}
+			if opts.Timeout <= 0 || opts.Interval <= 0 {
+				return fmt.Errorf("--timeout 和 --interval 必须大于 0")
+			}
+			// 配置无法读取时不能创建实例，否则付费实例创建后才会在写入配置时失败
+			if _, err := config.LoadDestination(spec.Name); err == nil {
+				return fmt.Errorf("目标 %v 已存在", spec.Name)
+			} else if !errors.Is(err, config.ErrDestinationNotFound) {
+				return err
+			}
+
+			account := credentialFlags.Account
+			dest := &config.Destination{
+				Name:                spec.Name,
+				DestinationInstance: config.DestinationInstance{Provider: provider, Region: region, Account: account, Tags: tags},
+			}
+			if dest.Provider == config.ProviderLighthouse {
+				dest.Provider = ""
+			}
+
+			lifecycle, err := lifecycleProvider(provider, region, account)
+			if err != nil {
+				return err
+			}
+			keyPairs, err := keyPairProvider(provider, region, account)
+			if err != nil {
+				return err
+			}
+
+			out := progressWriter(cmd, format)
+			flags := loadMutationFlags(cmd)
+			spec.KeyIds = keyIds
+			var calls []apiCall
+			if from != "" {
+				publicKey, comment, err := readPublicKey(from)
+				if err != nil {
+					return err
+				}
+				existing, err := keyPairs.ListKeyPairs()
+				if err != nil {
+					return err
+				}
+
+				if found := findKeyPair(existing, publicKey); found != nil {
+					spec.KeyIds = append(spec.KeyIds, found.KeyId)
+				} else {
+					name := importKeyPairName(publicKey, comment)
+					if flags.DryRun {
+						calls = append(calls, newAPICall(dest, "ImportKeyPair", &lighthouse.ImportKeyPairRequest{KeyName: &name, PublicKey: &publicKey}))
+						spec.KeyIds = append(spec.KeyIds, pendingKeyId)
+					} else {
+						keyId, err := keyPairs.ImportKeyPair(name, publicKey)
+						if err != nil {
+							return err
+						}
+						fmt.Fprintf(out, "已导入密钥对 %v（%v）\n", keyId, name)
+						spec.KeyIds = append(spec.KeyIds, keyId)
+					}
+				}
+				if identityFile == "" {
+					identityFile = identityFileFor(from)
+				}
+			}
+
+			if flags.DryRun {
+				calls = append(calls, newAPICall(dest, "CreateInstances", lighthouseCreateRequest(spec)))
+				return writeDryRun(cmd.OutOrStdout(), format, calls)
+			}
+
+			instanceId, err := lifecycle.CreateInstance(spec)
+			if err != nil {
+				return err
+			}
+			dest.InstanceId = instanceId
+			fmt.Fprintf(out, "%v: 已提交创建，实例 ID: %v\n", spec.Name, instanceId)
+
+			opts.Out = out
+			instance, err := waitForState(dest, "RUNNING", opts)
+			if err != nil {
+				return fmt.Errorf("%w，实例 %v 仍在创建中，稍后可使用 cloud sync --add 写入配置", err, instanceId)
+			}
+			if len(instance.PublicIPs) == 0 {
+				return fmt.Errorf("实例 %v 没有公网 IP，未写入配置", instanceId)
+			}
+
+			dest.Ssh = joinSsh(user, instance.PublicIPs[0])
+			dest.IdentityFile = identityFile
+			if err := addDestination(spec.Name, dest.DestinationInstance); err != nil {
+				return fmt.Errorf("实例 %v 已创建，但写入配置失败: %w", instanceId, err)
+			}
+			fmt.Fprintf(out, "%v: 已写入配置，ssh: %v\n", spec.Name, dest.Ssh)
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, CreateResult{
+					Destination:  spec.Name,
+					InstanceId:   instanceId,
+					Region:       region,
+					State:        instance.State,
+					Ssh:          dest.Ssh,
+					KeyIds:       spec.KeyIds,
+					IdentityFile: identityFile,
+				})
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&spec.Name, "name", "", "实例名称，同时作为配置中的目标名称")
+	cmd.Flags().StringVar(&spec.BlueprintId, "blueprint", "", "镜像 ID，如 lhbp-xxxxxxxx")
+	cmd.Flags().StringVar(&spec.BundleId, "bundle", "", "套餐 ID")
+	cmd.Flags().StringVar(&region, "region", "", "地域，如 ap-hongkong")
+	cmd.Flags().StringVar(&spec.Zone, "zone", "", "可用区，默认由云平台选择")
+	cmd.Flags().Int64Var(&spec.Period, "period", 1, "购买时长（月）")
+	cmd.Flags().BoolVar(&spec.AutoRenew, "auto-renew", false, "到期后自动续费")
+	cmd.Flags().StringVar(&provider, "provider", config.ProviderLighthouse, "云平台")
+	cmd.Flags().StringSliceVar(&keyIds, "key-id", nil, "创建时绑定的密钥对 ID，可重复指定")
+	cmd.Flags().StringVar(&from, "from", "", "绑定该公钥文件对应的密钥对，如 ~/.ssh/id_ed25519.pub")
+	cmd.Flags().StringVar(&identityFile, "identity-file", "", "写入配置的 SSH 私钥路径")
+	cmd.Flags().StringVar(&user, "user", "root", "写入配置的 SSH 用户")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "写入配置的标签，可重复指定或用逗号分隔")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 10*time.Minute, "等待实例变为 RUNNING 的最长时间")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 5*time.Second, "查询实例状态的间隔")
+	for _, name := range []string{"name", "blueprint", "bundle", "region"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+
+	return cmd
+}
+
+// newDestroyCommand 创建 cloud destroy 子命令，销毁实例并从配置中删除目标
+func newDestroyCommand() *cobra.Command {
+	var keepConfig bool
+	var opts waitOptions
+
+	cmd := &cobra.Command{
+		Use:   "destroy [destination]",
+		Short: "销毁目标实例并从配置中删除",
+		Long: `退还目标实例并等待其进入 SHUTDOWN 状态，然后彻底销毁，最后从配置中删除目标（--keep-config 时保留）。
+实例销毁后数据无法恢复，执行前需要输入目标名称确认，受保护的目标需要 --force。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if opts.Timeout <= 0 || opts.Interval <= 0 {
+				return fmt.Errorf("--timeout 和 --interval 必须大于 0")
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			lifecycle, err := lifecycleProvider(dest.Provider, dest.Region, dest.Account)
+			if err != nil {
+				return err
+			}
+
+			out := progressWriter(cmd, format)
+			flags := loadMutationFlags(cmd)
+			if err := guardDestructive(cmd, out, []config.Destination{*dest}, "销毁", flags); err != nil {
+				return err
+			}
+
+			if flags.DryRun {
+				instanceIds := map[string]any{"InstanceIds": []string{dest.InstanceId}}
+				if !keepConfig {
+					fmt.Fprintf(out, "[dry-run] 销毁后将从配置中删除目标 %v\n", dest.Name)
+				}
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{
+					newAPICall(dest, "IsolateInstances", instanceIds),
+					newAPICall(dest, "TerminateInstances", instanceIds),
+				})
+			}
+
+			instance, err := DescribeInstance(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			if instance.State != "SHUTDOWN" {
+				if err := lifecycle.IsolateInstance(dest.InstanceId); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%v: 已退还实例 %v，等待进入 SHUTDOWN\n", dest.Name, dest.InstanceId)
+
+				opts.Out = out
+				if _, err := waitForState(dest, "SHUTDOWN", opts); err != nil {
+					return err
+				}
+			}
+
+			if err := lifecycle.TerminateInstance(dest.InstanceId); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%v: 已销毁实例 %v\n", dest.Name, dest.InstanceId)
+
+			result := DestroyResult{Destination: dest.Name, InstanceId: dest.InstanceId}
+			if !keepConfig {
+				if err := removeDestination(dest.Name); err != nil {
+					return fmt.Errorf("实例已销毁，但从配置中删除目标失败: %w", err)
+				}
+				result.ConfigRemoved = true
+				fmt.Fprintf(out, "已从配置中删除目标 %v\n", dest.Name)
+			}
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, result)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&keepConfig, "keep-config", false, "销毁实例后保留配置中的目标")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "等待实例进入 SHUTDOWN 的最长时间")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 5*time.Second, "查询实例状态的间隔")
+
+	return cmd
+}
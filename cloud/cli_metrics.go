@@ -0,0 +1,151 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// outputCSV 是 cloud metrics 额外支持的 CSV 输出格式
+const outputCSV = "csv"
+
+// newMetricsCommand 创建 cloud metrics 子命令，查询实例的云监控指标并在终端中绘图
+func newMetricsCommand() *cobra.Command {
+	var metrics []string
+	var since, endpoint string
+	var period int64
+	var chart bool
+	var width, height int
+
+	cmd := &cobra.Command{
+		Use:   "metrics [destination]",
+		Short: "查询实例的监控指标（CPU、内存、带宽）",
+		Long: `从腾讯云云监控查询目标实例最近一段时间的监控数据，默认以表格显示每个指标的最小、平均、最大、
+最新值和迷你图，--chart 时再为每个指标绘制折线图。-o csv 输出每个时间点一行的 CSV，-o json/yaml 输出全部数据点。
+
+可用的指标（--metric，逗号分隔）:
+  cpu      CPU 使用率（%）
+  mem      内存使用率（%）
+  lan-in   内网入带宽（Mbps）
+  lan-out  内网出带宽（Mbps）
+  wan-in   外网入带宽（Mbps）
+  wan-out  外网出带宽（Mbps）
+
+统计周期默认按 --since 自动选择（60s、300s、3600s 或 86400s），使每个指标不超过 1440 个数据点，
+也可以使用 --period 指定。目前支持轻量应用服务器和云服务器。`,
+		Example: `  lucky-go cloud metrics web-1
+  lucky-go cloud metrics web-1 --metric cpu,wan-out --since 6h --chart
+  lucky-go cloud metrics web-1 --since 7d -o csv > web-1.csv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := metricsFormat(cmd)
+			if err != nil {
+				return err
+			}
+			specs, err := parseMetrics(metrics)
+			if err != nil {
+				return err
+			}
+			window, err := config.ParseAge(since)
+			if err != nil {
+				return err
+			}
+			if window < time.Minute {
+				return fmt.Errorf("--since 不能小于 1m")
+			}
+			if period == 0 {
+				period = metricsPeriod(window)
+			} else if !validMetricsPeriod(period) {
+				return fmt.Errorf("--period 只能为 60、300、3600 或 86400")
+			}
+			if width <= 0 || height <= 0 {
+				return fmt.Errorf("--width 和 --height 必须大于 0")
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newMonitorClient(&dest.DestinationInstance, endpoint)
+			if err != nil {
+				return err
+			}
+
+			end := timeNow()
+			result := MetricsResult{Destination: dest.Name, InstanceId: dest.InstanceId, Start: end.Add(-window), End: end, Period: period}
+			for _, spec := range specs {
+				series, err := client.Series(dest.InstanceId, spec, period, result.Start, result.End)
+				if err != nil {
+					return fmt.Errorf("查询 %v 的 %v 失败: %w", dest.Name, spec.Name, err)
+				}
+				result.Series = append(result.Series, series)
+			}
+
+			switch format {
+			case outputCSV:
+				return writeMetricsCSV(cmd.OutOrStdout(), result)
+			case output.Table:
+				renderMetricsTable(cmd.OutOrStdout(), result, width)
+				if chart {
+					for _, series := range result.Series {
+						fmt.Fprintln(cmd.OutOrStdout())
+						renderChart(cmd.OutOrStdout(), series, width, height)
+					}
+				}
+				return nil
+			default:
+				return output.Write(cmd.OutOrStdout(), format, result)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&metrics, "metric", []string{"cpu", "mem", "lan-out", "wan-out"}, "查询的指标，逗号分隔")
+	cmd.Flags().StringVar(&since, "since", "24h", "查询最近多长时间的数据，如 6h、7d")
+	cmd.Flags().Int64Var(&period, "period", 0, "统计周期（秒），默认按 --since 自动选择")
+	cmd.Flags().BoolVar(&chart, "chart", false, "为每个指标绘制折线图")
+	cmd.Flags().IntVar(&width, "width", 60, "迷你图和折线图的宽度（字符）")
+	cmd.Flags().IntVar(&height, "height", 8, "折线图的高度（行）")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "云监控 API 地址，默认使用 endpoints.tencent 或 monitor.tencentcloudapi.com")
+
+	return cmd
+}
+
+// metricsFormat 返回 cloud metrics 的输出格式，在 table、json、yaml 之外支持 csv
+func metricsFormat(cmd *cobra.Command) (string, error) {
+	if flag := cmd.Flag("output"); flag != nil && flag.Value.String() == outputCSV {
+		return outputCSV, nil
+	}
+	return outputFormat(cmd)
+}
+
+// renderMetricsTable 渲染每个指标的统计值和迷你图
+func renderMetricsTable(w io.Writer, result MetricsResult, width int) {
+	fmt.Fprintf(w, "%v (%v) %v ~ %v，统计周期 %ds\n", result.Destination, result.InstanceId,
+		result.Start.Local().Format("2006-01-02 15:04"), result.End.Local().Format("2006-01-02 15:04"), result.Period)
+
+	table := output.NewTable(w)
+	table.Header([]string{"指标", "单位", "最小", "平均", "最大", "最新", "趋势"})
+	for _, series := range result.Series {
+		low, avg, high, last, ok := series.stats()
+		if !ok {
+			_ = table.Append([]string{series.Metric, series.Unit, "-", "-", "-", "-", "无数据"})
+			continue
+		}
+		_ = table.Append([]string{
+			series.Metric,
+			series.Unit,
+			formatMetricValue(low),
+			formatMetricValue(avg),
+			formatMetricValue(high),
+			formatMetricValue(last),
+			sparkline(downsample(series.Points, width)),
+		})
+	}
+
+	_ = table.Render()
+}
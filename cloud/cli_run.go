@@ -0,0 +1,159 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/output"
+)
+
+const (
+	// maxRunTimeout 是自动化助手允许的命令最长执行时间
+	maxRunTimeout = 24 * time.Hour
+	// runPollSlack 是命令超时后继续等待任务结束的时间，任务状态的更新可能有延迟
+	runPollSlack = 30 * time.Second
+)
+
+// newRunCommand 创建 cloud run 子命令，通过自动化助手在实例上执行命令
+func newRunCommand() *cobra.Command {
+	var timeout, interval time.Duration
+	var endpoint string
+
+	cmd := &cobra.Command{
+		Use:   "run [destination] -- [command...]",
+		Short: "通过自动化助手（TAT）在实例上执行命令",
+		Long: `通过腾讯云自动化助手（TAT）以 root 身份在实例上执行 shell 命令，不依赖 SSH，
+适合在 sshd 无法连接时重启 sshd 或排查磁盘已满等问题。实例上需要运行自动化助手客户端。
+
+命令提交后轮询执行结果，输出到标准输出。命令失败时 lucky-go 以 8 退出，
+命令的退出码输出到标准错误，并包含在 -o json/yaml 结果的 exit-code 中。
+命令没有运行（如自动化助手不在线）时使用 cloud 命令的其他退出码。
+--endpoint 指定 API 地址，可以是域名或 http(s)://host:port，便于连接本地的替身服务测试。`,
+		Example: `  lucky-go cloud run web-1 -- systemctl restart sshd
+  lucky-go cloud run web-1 --timeout 5m -- 'du -xh / | sort -rh | head -20'`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if timeout < time.Second || timeout > maxRunTimeout {
+				return fmt.Errorf("--timeout 必须在 1s 到 24h 之间")
+			}
+			if interval <= 0 {
+				return fmt.Errorf("--interval 必须大于 0")
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			command := strings.Join(args[1:], " ")
+			timeoutSeconds := int64(timeout / time.Second)
+
+			if loadMutationFlags(cmd).DryRun {
+				call := newAPICall(dest, "RunCommand", runCommandParams(dest.InstanceId, command, timeoutSeconds))
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{call})
+			}
+
+			client, err := newTATClient(&dest.DestinationInstance, endpoint)
+			if err != nil {
+				return err
+			}
+			invocationId, err := client.Run(dest.InstanceId, command, timeoutSeconds)
+			if err != nil {
+				return err
+			}
+
+			// 标准输出只包含命令的输出，便于在管道中使用
+			progress := cmd.ErrOrStderr()
+			fmt.Fprintf(progress, "%v: 已提交命令，执行活动 %v\n", dest.Name, invocationId)
+
+			// 表格输出时边轮询边输出，JSON/YAML 时只在结果中包含输出
+			var stream io.Writer = io.Discard
+			if format == output.Table {
+				stream = cmd.OutOrStdout()
+			}
+			task, taskOutput, err := pollTask(client, invocationId, timeout+runPollSlack, interval, stream)
+
+			result := RunResult{Destination: dest.Name, InstanceId: dest.InstanceId, InvocationId: invocationId, Output: taskOutput}
+			if err == nil {
+				result.Status = task.TaskStatus
+				if task.TaskStatus == tatTaskSuccess || task.TaskStatus == tatTaskFailed {
+					exitCode := task.TaskResult.ExitCode
+					result.ExitCode = &exitCode
+					fmt.Fprintf(progress, "%v: 命令结束，退出码 %d\n", dest.Name, exitCode)
+				}
+				err = taskError(task)
+			}
+			if format != output.Table {
+				result.Error = newResultError(err)
+				if writeErr := output.Write(cmd.OutOrStdout(), format, result); writeErr != nil {
+					return writeErr
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", time.Minute, "命令在实例上的最长执行时间")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "查询执行结果的间隔")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "自动化助手 API 地址，默认使用 endpoints.tencent 或 tat.tencentcloudapi.com")
+
+	return cmd
+}
+
+// pollTask 轮询执行任务直到结束，把新增的输出写入 stream，返回最终的任务和完整输出
+func pollTask(client *tatClient, invocationId string, timeout, interval time.Duration, stream io.Writer) (*tatTask, string, error) {
+	deadline := timeNow().Add(timeout)
+	var printed string
+	for {
+		task, err := client.Task(invocationId)
+		if err != nil {
+			return nil, printed, err
+		}
+
+		text, err := task.output()
+		if err != nil {
+			return task, printed, err
+		}
+		if strings.HasPrefix(text, printed) {
+			fmt.Fprint(stream, text[len(printed):])
+		} else {
+			fmt.Fprint(stream, text)
+		}
+		printed = text
+
+		if !tatPendingStates[task.TaskStatus] {
+			return task, printed, nil
+		}
+		if !timeNow().Before(deadline) {
+			return task, printed, timeoutErrorf("执行活动 %v 在 %v 内没有结束（当前状态 %v）", invocationId, timeout, task.TaskStatus)
+		}
+		sleepFunc(interval)
+	}
+}
+
+// taskError 把结束的执行任务转换为错误：成功时为 nil，命令失败时为带退出码的 remoteExitError
+func taskError(task *tatTask) error {
+	switch task.TaskStatus {
+	case tatTaskSuccess:
+		if task.TaskResult.ExitCode != 0 {
+			return &remoteExitError{code: task.TaskResult.ExitCode}
+		}
+		return nil
+	case tatTaskFailed:
+		return &remoteExitError{code: task.TaskResult.ExitCode}
+	case tatTaskTimeout, tatTaskTaskTimeout:
+		return timeoutErrorf("命令执行超时（%v）", task.TaskStatus)
+	default:
+		if task.ErrorInfo != "" {
+			return fmt.Errorf("命令没有运行（%v）: %v", task.TaskStatus, task.ErrorInfo)
+		}
+		return fmt.Errorf("命令没有运行（%v）", task.TaskStatus)
+	}
+}
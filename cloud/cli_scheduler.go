@@ -0,0 +1,240 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newSchedulerCommand 创建 cloud scheduler 命令及其子命令
+func newSchedulerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "按计划定时开关机实例",
+		Long: `按目标配置中的 schedule 定时开机和关机，适合夜间闲置的开发机：
+
+  dest:
+    dev:
+      schedule:
+        start: "0 9 * * 1-5"    # 工作日 9 点开机
+        stop: "0 21 * * *"      # 每天 21 点关机
+        timezone: Asia/Shanghai # 可选，默认为本地时区
+
+start 和 stop 为 5 字段的 cron 表达式（分钟 小时 日 月 星期），至少提供一个，
+支持 *、列表、范围、步长、英文缩写（mon、jan）和 @daily 等简写。`,
+	}
+
+	cmd.AddCommand(newSchedulerRunCommand())
+	cmd.AddCommand(newSchedulerListCommand())
+
+	return cmd
+}
+
+// newSchedulerRunCommand 创建 cloud scheduler run 子命令，作为守护进程按计划开关机
+func newSchedulerRunCommand() *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:   "run [selector...]",
+		Short: "运行定时开关机守护进程",
+		Long: `每隔 --interval 检查一次配置了 schedule 的目标，执行到期的开机或关机，每次操作都推送 Telegram 通知。
+每次检查都重新读取配置，修改 schedule 后无需重启。
+
+已处理的计划时间记录在 ~/.lucky-go/scheduler-state.json（可通过 LUCKY_GO_SCHEDULER_STATE 指定）。
+守护进程停止期间错过的计划在启动后补执行，只执行最近的一次操作（最多回溯 7 天）；
+每个计划只执行一次，之后手动开关机不会被覆盖。实例已处于目标状态时不做任何操作。
+受保护的目标需要 --force 才会定时关机。--once 只检查一次后退出，适合放在系统的定时任务中。
+
+` + selectorHelp,
+		Example: `  lucky-go cloud scheduler run
+  lucky-go cloud scheduler run @dev --once --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if interval < time.Minute {
+				return fmt.Errorf("--interval 不能小于 1m")
+			}
+			if format != output.Table && !once {
+				return fmt.Errorf("-o %v 只能与 --once 一起使用", format)
+			}
+
+			out := progressWriter(cmd, format)
+			s, err := newScheduler(out, loadMutationFlags(cmd), interval)
+			if err != nil {
+				return err
+			}
+
+			if !once {
+				fmt.Fprintf(out, "定时开关机已启动，每 %v 检查一次\n", interval)
+			}
+			for {
+				results, err := runSchedulerTick(s, args)
+				if once {
+					if format != output.Table {
+						if results == nil {
+							results = []ScheduleResult{}
+						}
+						if writeErr := output.Write(cmd.OutOrStdout(), format, results); writeErr != nil {
+							return writeErr
+						}
+					}
+					if err != nil {
+						return err
+					}
+					return scheduleError(results)
+				}
+
+				// 守护进程遇到错误时继续运行，下次检查时重试
+				if err != nil {
+					fmt.Fprintf(out, "%v\n", err)
+				}
+				sleepFunc(interval)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", time.Minute, "检查计划的间隔")
+	cmd.Flags().BoolVar(&once, "once", false, "只检查一次后退出")
+
+	return cmd
+}
+
+// runSchedulerTick 读取配置了 schedule 的目标并执行一次检查
+func runSchedulerTick(s *scheduler, selectors []string) ([]ScheduleResult, error) {
+	dests, err := loadScheduledDestinations(selectors)
+	if err != nil {
+		return nil, err
+	}
+	return s.tick(dests)
+}
+
+// loadScheduledDestinations 解析选择器并返回其中配置了 schedule 的目标，未提供选择器时返回全部
+func loadScheduledDestinations(selectors []string) ([]config.Destination, error) {
+	if len(selectors) == 0 {
+		selectors = []string{"*"}
+	}
+	dests, err := config.LoadDestinations(selectors...)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduled []config.Destination
+	for _, dest := range dests {
+		if dest.Schedule != nil {
+			scheduled = append(scheduled, dest)
+		}
+	}
+	if len(scheduled) == 0 {
+		return nil, fmt.Errorf("没有目标配置了 schedule")
+	}
+	return scheduled, nil
+}
+
+// scheduleError 汇总执行失败的计划，全部成功时返回 nil
+func scheduleError(results []ScheduleResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Status == "failed" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个目标按计划开关机失败", failed)
+	}
+	return nil
+}
+
+// ScheduleEntry 是 cloud scheduler list 输出的目标计划
+type ScheduleEntry struct {
+	Destination string          `json:"destination" yaml:"destination"`
+	Schedule    config.Schedule `json:"schedule" yaml:"schedule"`
+	// NextAction 和 NextAt 是下一次计划操作，LastApplied 是最近一次已处理的计划时间
+	NextAction  string     `json:"next-action,omitempty" yaml:"next-action,omitempty"`
+	NextAt      *time.Time `json:"next-at,omitempty" yaml:"next-at,omitempty"`
+	LastApplied *time.Time `json:"last-applied,omitempty" yaml:"last-applied,omitempty"`
+}
+
+// newSchedulerListCommand 创建 cloud scheduler list 子命令，显示目标的计划和下一次操作
+func newSchedulerListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list [selector...]",
+		Aliases: []string{"ls"},
+		Short:   "显示目标的开关机计划和下一次操作",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dests, err := loadScheduledDestinations(args)
+			if err != nil {
+				return err
+			}
+			path, err := stateFilePath(SCHEDULER_STATE_ENV, SCHEDULER_STATE_FILE)
+			if err != nil {
+				return err
+			}
+			state, err := loadSchedulerState(path)
+			if err != nil {
+				return err
+			}
+
+			now := timeNow()
+			entries := make([]ScheduleEntry, len(dests))
+			for i, dest := range dests {
+				entries[i] = ScheduleEntry{Destination: dest.Name, Schedule: *dest.Schedule}
+				if next, ok := nextScheduleEvent(dest.Schedule, now); ok {
+					entries[i].NextAction, entries[i].NextAt = next.Action, &next.At
+				}
+				if last, ok := state.Applied[dest.Name]; ok {
+					entries[i].LastApplied = &last
+				}
+			}
+
+			if format != output.Table {
+				return output.Write(cmd.OutOrStdout(), format, entries)
+			}
+			renderScheduleTable(cmd.OutOrStdout(), entries)
+			return nil
+		},
+	}
+}
+
+// renderScheduleTable 渲染计划表格
+func renderScheduleTable(w io.Writer, entries []ScheduleEntry) {
+	table := output.NewTable(w)
+	table.Header([]string{"目标", "开机", "关机", "时区", "下一次", "上次处理"})
+	for _, entry := range entries {
+		timezone := entry.Schedule.Timezone
+		if timezone == "" {
+			timezone = "本地"
+		}
+		next, last := "-", "-"
+		if entry.NextAt != nil {
+			verb := scheduleEvent{Action: entry.NextAction}.verb()
+			next = fmt.Sprintf("%v %v", entry.NextAt.Format("2006-01-02 15:04"), verb)
+		}
+		if entry.LastApplied != nil {
+			last = entry.LastApplied.Local().Format("2006-01-02 15:04")
+		}
+		_ = table.Append([]string{entry.Destination, orDash(entry.Schedule.Start), orDash(entry.Schedule.Stop), timezone, next, last})
+	}
+
+	_ = table.Render()
+}
+
+// orDash 在字符串为空时返回 -
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,425 @@
+package cloud
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newSnapshotCommand 创建 cloud snapshot 命令及其子命令
+func newSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "管理目标实例的快照",
+		Long: `创建、列出、删除和回滚目标实例的快照，目前仅支持轻量应用服务器和 fake provider。
+
+未指定 --name 时创建的快照以 lucky-go- 开头，配置中的 snapshots 保留策略只会清理这类自动快照：
+
+  snapshots:
+    keep: 3        # 最多保留 3 个自动快照
+    max-age: 30d   # 删除超过 30 天的自动快照
+
+目标也可以通过自身的 snapshots 字段覆盖全局策略。最新的一个自动快照始终保留。`,
+	}
+
+	cmd.AddCommand(newSnapshotCreateCommand())
+	cmd.AddCommand(newSnapshotListCommand())
+	cmd.AddCommand(newSnapshotDeleteCommand())
+	cmd.AddCommand(newSnapshotRestoreCommand())
+	cmd.AddCommand(newSnapshotPruneCommand())
+
+	return cmd
+}
+
+// newSnapshotCreateCommand 创建 cloud snapshot create 子命令
+func newSnapshotCreateCommand() *cobra.Command {
+	var name string
+	var wait bool
+	var opts waitOptions
+
+	cmd := &cobra.Command{
+		Use:   "create [destination]",
+		Short: "为目标实例创建快照",
+		Long:  "为目标实例创建快照。未指定 --name 时自动命名，并在快照完成后按保留策略清理旧的自动快照。",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+
+			auto := name == ""
+			if auto {
+				name = autoSnapshotName(dest.Name)
+			}
+
+			if loadMutationFlags(cmd).DryRun {
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{createSnapshotCall(dest, name)})
+			}
+
+			start := timeNow()
+			opts.Out = progressWriter(cmd, format)
+			snapshotId, state, err := createSnapshot(dest, name, wait || auto, opts)
+			// 自动快照完成后按保留策略清理旧快照
+			if err == nil && auto {
+				err = pruneSnapshots(dest, opts.Out)
+			}
+
+			if format != output.Table {
+				result := newActionResult(dest.Name, dest.InstanceId, "snapshot-create", start, err)
+				result.SnapshotId = snapshotId
+				if err == nil {
+					result.State = state
+				}
+				if writeErr := output.Write(cmd.OutOrStdout(), format, result); writeErr != nil {
+					return writeErr
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "快照名称，默认自动生成")
+	cmd.Flags().BoolVar(&wait, "wait", false, "等待快照创建完成")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 10*time.Minute, "等待快照完成的最长时间")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 5*time.Second, "等待快照时的轮询间隔")
+
+	return cmd
+}
+
+// createSnapshot 为目标创建快照，wait 时等待快照完成，返回快照 ID 和快照状态
+func createSnapshot(dest *config.Destination, name string, wait bool, opts waitOptions) (string, string, error) {
+	// 自动快照需要等待完成后才能清理旧快照
+	if wait {
+		snapshotId, err := createSnapshotAndWait(dest, name, opts)
+		return snapshotId, "NORMAL", err
+	}
+
+	snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+	if err != nil {
+		return "", "", err
+	}
+	snapshotId, err := snapshots.CreateSnapshot(dest.InstanceId, name)
+	if err != nil {
+		return "", "", err
+	}
+	fmt.Fprintf(opts.Out, "%v: 已提交快照 %v（%v）\n", dest.Name, name, snapshotId)
+	return snapshotId, "CREATING", nil
+}
+
+// newSnapshotListCommand 创建 cloud snapshot list 子命令
+func newSnapshotListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list [destination]",
+		Aliases: []string{"ls"},
+		Short:   "列出目标实例的快照",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			list, err := snapshots.ListSnapshots(dest.InstanceId)
+			if err != nil {
+				return err
+			}
+
+			if format != output.Table {
+				if list == nil {
+					list = []Snapshot{}
+				}
+				return output.Write(cmd.OutOrStdout(), format, list)
+			}
+
+			if len(list) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%v 没有任何快照\n", dest.Name)
+				return nil
+			}
+
+			renderSnapshotTable(cmd.OutOrStdout(), list)
+			return nil
+		},
+	}
+}
+
+// newSnapshotDeleteCommand 创建 cloud snapshot delete 子命令
+func newSnapshotDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete [destination] [snapshot-id...]",
+		Aliases: []string{"rm"},
+		Short:   "删除目标实例的快照",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			if err := requireSnapshots(snapshots, dest, args[1:]); err != nil {
+				return err
+			}
+
+			flags := loadMutationFlags(cmd)
+			if err := guardDestructive(cmd, progressWriter(cmd, format), []config.Destination{*dest}, "删除快照", flags); err != nil {
+				return err
+			}
+			if flags.DryRun {
+				var calls []apiCall
+				for _, snapshotId := range args[1:] {
+					calls = append(calls, deleteSnapshotCall(dest, snapshotId))
+				}
+				return writeDryRun(cmd.OutOrStdout(), format, calls)
+			}
+
+			var results []ActionResult
+			for _, snapshotId := range args[1:] {
+				start := timeNow()
+				err = snapshots.DeleteSnapshot(snapshotId)
+				if err != nil {
+					err = fmt.Errorf("删除快照 %v 失败: %w", snapshotId, err)
+				}
+
+				result := newActionResult(dest.Name, dest.InstanceId, "snapshot-delete", start, err)
+				result.SnapshotId = snapshotId
+				results = append(results, result)
+				if err != nil {
+					break
+				}
+				fmt.Fprintf(progressWriter(cmd, format), "%v: 已删除快照 %v\n", dest.Name, snapshotId)
+			}
+
+			if format != output.Table {
+				if writeErr := output.Write(cmd.OutOrStdout(), format, results); writeErr != nil {
+					return writeErr
+				}
+			}
+			return err
+		},
+	}
+}
+
+// newSnapshotRestoreCommand 创建 cloud snapshot restore 子命令
+func newSnapshotRestoreCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore [destination] [snapshot-id]",
+		Short: "把目标实例回滚到快照",
+		Long:  "把目标实例回滚到快照，快照之后写入的数据将会丢失。未指定 --yes 时需要输入目标名称确认。",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dest, err := loadInstanceDestination(args[0])
+			if err != nil {
+				return err
+			}
+			snapshotId := args[1]
+			out := progressWriter(cmd, format)
+
+			snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+			if err != nil {
+				return err
+			}
+			if err := requireSnapshots(snapshots, dest, []string{snapshotId}); err != nil {
+				return err
+			}
+
+			flags := loadMutationFlags(cmd)
+			if !flags.Yes && !flags.DryRun {
+				fmt.Fprintf(out, "回滚后快照 %v 之后写入 %v 的数据将会丢失。\n", snapshotId, dest.Name)
+			}
+			if err := guardDestructive(cmd, out, []config.Destination{*dest}, "回滚", flags); err != nil {
+				return err
+			}
+			if flags.DryRun {
+				return writeDryRun(cmd.OutOrStdout(), format, []apiCall{restoreSnapshotCall(dest, snapshotId)})
+			}
+
+			start := timeNow()
+			err = snapshots.RestoreSnapshot(dest.InstanceId, snapshotId)
+			if err == nil {
+				fmt.Fprintf(out, "%v: 已提交回滚到快照 %v 的请求\n", dest.Name, snapshotId)
+			}
+
+			if format != output.Table {
+				result := newActionResult(dest.Name, dest.InstanceId, "snapshot-restore", start, err)
+				result.SnapshotId = snapshotId
+				if writeErr := output.Write(cmd.OutOrStdout(), format, result); writeErr != nil {
+					return writeErr
+				}
+			}
+			return err
+		},
+	}
+}
+
+// newSnapshotPruneCommand 创建 cloud snapshot prune 子命令，按保留策略清理自动快照
+func newSnapshotPruneCommand() *cobra.Command {
+	var parallel int
+
+	cmd := &cobra.Command{
+		Use:   "prune [selector...]",
+		Short: "按保留策略清理自动快照",
+		Long:  "按配置中的 snapshots 保留策略删除过期的自动快照，默认处理所有配置了 instance-id 的目标。\n\n" + selectorHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			dests, err := loadCloudDestinations(args)
+			if err != nil {
+				return err
+			}
+
+			if loadMutationFlags(cmd).DryRun {
+				return dryRunPrune(cmd, format, dests, parallel)
+			}
+
+			out := &syncWriter{w: progressWriter(cmd, format)}
+			results := runFleet(dests, parallel, func(dest *config.Destination) error {
+				if err := requireInstance(dest); err != nil {
+					return err
+				}
+				return pruneSnapshots(dest, out)
+			})
+
+			if format != output.Table {
+				if err := output.Write(cmd.OutOrStdout(), format, newActionResults("snapshot-prune", results, nil)); err != nil {
+					return err
+				}
+			} else if len(results) > 1 {
+				renderFleetTable(cmd.OutOrStdout(), results)
+			}
+
+			return fleetError("清理快照", results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量操作时的最大并发数")
+
+	return cmd
+}
+
+// dryRunPrune 输出按保留策略清理快照将要发送的 API 调用
+func dryRunPrune(cmd *cobra.Command, format string, dests []config.Destination, parallel int) error {
+	index := make(map[string]int, len(dests))
+	for i, dest := range dests {
+		index[dest.Name] = i
+	}
+
+	expired := make([][]Snapshot, len(dests))
+	results := runFleet(dests, parallel, func(dest *config.Destination) error {
+		if err := requireInstance(dest); err != nil {
+			return err
+		}
+		_, snapshots, err := destinationExpiredSnapshots(dest)
+		expired[index[dest.Name]] = snapshots
+		return err
+	})
+
+	var calls []apiCall
+	for i, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(progressWriter(cmd, format), "%v: %v\n", result.Name, result.Err)
+			continue
+		}
+		for _, snapshot := range expired[i] {
+			calls = append(calls, deleteSnapshotCall(&dests[i], snapshot.SnapshotId))
+		}
+	}
+
+	if err := writeDryRun(cmd.OutOrStdout(), format, calls); err != nil {
+		return err
+	}
+	return fleetError("清理快照", results)
+}
+
+// requireSnapshots 确认快照都属于目标实例，避免误删其他实例的快照
+func requireSnapshots(snapshots SnapshotProvider, dest *config.Destination, snapshotIds []string) error {
+	list, err := snapshots.ListSnapshots(dest.InstanceId)
+	if err != nil {
+		return err
+	}
+
+	owned := make(map[string]bool, len(list))
+	for _, snapshot := range list {
+		owned[snapshot.SnapshotId] = true
+	}
+	for _, snapshotId := range snapshotIds {
+		if !owned[snapshotId] {
+			return fmt.Errorf("%v（%v）不存在快照 %v", dest.Name, dest.InstanceId, snapshotId)
+		}
+	}
+	return nil
+}
+
+// confirm 读取一行输入，只有 y 或 yes 视为确认
+func confirm(in io.Reader) bool {
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// renderSnapshotTable 渲染快照列表
+func renderSnapshotTable(w io.Writer, snapshots []Snapshot) {
+	table := output.NewTable(w)
+	table.Header([]string{"快照 ID", "名称", "状态", "进度", "创建时间"})
+	for _, snapshot := range snapshots {
+		_ = table.Append([]string{
+			snapshot.SnapshotId,
+			snapshot.Name,
+			colorSnapshotState(snapshot.State),
+			fmt.Sprintf("%d%%", snapshot.Percent),
+			snapshot.CreatedTime,
+		})
+	}
+
+	_ = table.Render()
+}
+
+// colorSnapshotState 按快照状态着色：可用为绿色，失败为红色，其他中间状态为黄色
+func colorSnapshotState(state string) string {
+	switch state {
+	case "NORMAL":
+		return color.New(color.FgGreen, color.Bold).Sprint(state)
+	case "FAILED":
+		return color.New(color.FgRed, color.Bold).Sprint(state)
+	default:
+		return color.New(color.FgYellow, color.Bold).Sprint(state)
+	}
+}
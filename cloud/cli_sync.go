@@ -0,0 +1,181 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newSyncCommand 创建 cloud sync 子命令，把云平台上的实例同步到配置
+func newSyncCommand() *cobra.Command {
+	var opts syncOptions
+	var add bool
+	var user string
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "把云平台上的实例同步到配置",
+		Long: `列出指定地域内的全部实例并与配置中的目标比较，显示新增、变化和消失的实例，
+确认后更新目标的 instance-id、region 和 ssh 主机。
+
+目标依次按 instance-id、标签（默认标签键为 lucky-go，值为目标名称）和实例名称匹配实例。
+ssh 的主机部分为 IP 地址且不是实例的公网 IP 时才会更新，主机名和别名保持不变。
+新实例默认只显示，使用 --add 添加为目标；消失的目标只显示，不会从配置中删除。
+
+未指定 --regions 时使用配置中目标用到的地域。`,
+		Example: `  lucky-go cloud sync --regions ap-hongkong,ap-tokyo
+  lucky-go cloud sync --add --user ubuntu --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			// 配置中还没有目标时，所有实例都视为新增；配置无法读取时不能继续，否则会重复添加已有的实例
+			dests, err := config.LoadDestinations("*")
+			if err != nil && !errors.Is(err, config.ErrDestinationNotFound) {
+				return err
+			}
+
+			// 指定 --regions 时使用默认凭证（或 --account），否则按目标的账号分别查询
+			var targets []listTarget
+			if len(opts.Regions) == 0 {
+				for _, target := range destinationListTargets(dests) {
+					if target.provider != opts.Provider {
+						continue
+					}
+					if n := len(opts.Regions); n == 0 || opts.Regions[n-1] != target.region {
+						opts.Regions = append(opts.Regions, target.region)
+					}
+					targets = append(targets, target)
+				}
+			} else {
+				for _, region := range opts.Regions {
+					targets = append(targets, listTarget{provider: opts.Provider, region: region})
+				}
+			}
+			if len(opts.Regions) == 0 {
+				return fmt.Errorf("配置中没有 %v 目标设置了 region，请使用 --regions 指定地域", opts.Provider)
+			}
+
+			var instances []Instance
+			for _, target := range targets {
+				found, err := ListInstances(target.provider, target.region, target.account)
+				if err != nil {
+					return fmt.Errorf("查询地域 %v 失败: %w", target.region, err)
+				}
+				instances = append(instances, found...)
+			}
+
+			out := progressWriter(cmd, format)
+			changes, warnings := planSync(dests, instances, opts)
+			for _, warning := range warnings {
+				fmt.Fprintf(out, "警告: %v\n", warning)
+			}
+
+			result := SyncResult{Regions: opts.Regions, Instances: len(instances), Changes: changes, Warnings: warnings}
+			if result.Changes == nil {
+				result.Changes = []syncChange{}
+			}
+			writeSummary := func() error {
+				if format == output.Table {
+					return nil
+				}
+				return output.Write(cmd.OutOrStdout(), format, result)
+			}
+
+			fmt.Fprintf(out, "地域 %v 中共有 %d 个实例\n", strings.Join(opts.Regions, ", "), len(instances))
+			if len(changes) == 0 {
+				fmt.Fprintln(out, "配置已与云平台一致")
+				return writeSummary()
+			}
+			renderSyncTable(out, changes)
+
+			pending := 0
+			for _, change := range changes {
+				if change.Kind == syncChanged || (change.Kind == syncNew && add) {
+					pending++
+				}
+			}
+			flags := loadMutationFlags(cmd)
+			if pending == 0 || flags.DryRun {
+				return writeSummary()
+			}
+
+			if !flags.Yes {
+				fmt.Fprintf(out, "确认把以上 %d 处变更写入配置？[y/N]: ", pending)
+				if !confirm(cmd.InOrStdin()) {
+					return fmt.Errorf("已取消同步")
+				}
+			}
+
+			var skipped []string
+			err = config.Update(func(cfg *config.Config) error {
+				var applyErr error
+				skipped, applyErr = applySync(cfg, changes, add, user, opts.Provider)
+				return applyErr
+			})
+			if err != nil {
+				return err
+			}
+			for _, message := range skipped {
+				fmt.Fprintf(out, "警告: %v\n", message)
+			}
+
+			result.Applied = pending - len(skipped)
+			result.Warnings = append(result.Warnings, skipped...)
+			fmt.Fprintf(out, "已更新配置: %d 处变更\n", result.Applied)
+			return writeSummary()
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Provider, "provider", config.ProviderLighthouse, "要同步的云平台")
+	cmd.Flags().StringSliceVar(&opts.Regions, "regions", nil, "要同步的地域，可重复指定或用逗号分隔")
+	cmd.Flags().StringVar(&opts.TagKey, "tag-key", DEFAULT_SYNC_TAG_KEY, "按标签匹配时使用的标签键，为空时不按标签匹配")
+	cmd.Flags().BoolVar(&add, "add", false, "把未匹配的新实例添加为目标")
+	cmd.Flags().StringVar(&user, "user", "root", "--add 添加目标时使用的 SSH 用户")
+
+	return cmd
+}
+
+// renderSyncTable 渲染同步差异：新增为绿色，变化为黄色，消失为红色
+func renderSyncTable(w io.Writer, changes []syncChange) {
+	kinds := map[string]string{
+		syncNew:     color.New(color.FgGreen, color.Bold).Sprint("新增"),
+		syncChanged: color.New(color.FgYellow, color.Bold).Sprint("变化"),
+		syncGone:    color.New(color.FgRed, color.Bold).Sprint("消失"),
+	}
+
+	table := output.NewTable(w)
+	table.Header([]string{"类型", "目标", "实例 ID", "地域", "匹配方式", "详情"})
+	for _, change := range changes {
+		var instanceId, region, details string
+		switch change.Kind {
+		case syncNew:
+			instanceId, region = change.Instance.InstanceId, change.Instance.Region
+			details = fmt.Sprintf("%v %v", change.Instance.Name, strings.Join(change.Instance.PublicIPs, ", "))
+		case syncChanged:
+			instanceId, region = change.Instance.InstanceId, change.Instance.Region
+			var lines []string
+			for _, field := range change.Fields {
+				lines = append(lines, fmt.Sprintf("%v: %q → %q", field.Field, field.Old, field.New))
+			}
+			details = strings.Join(lines, "\n")
+		case syncGone:
+			instanceId = change.Fields[0].Old
+			details = "实例已不存在"
+		}
+
+		_ = table.Append([]string{kinds[change.Kind], change.Name, instanceId, region, change.MatchedBy, details})
+	}
+
+	_ = table.Render()
+}
@@ -8,9 +8,21 @@ import (
 
 	"lucky-go/config"
 
+	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
 
+// findSubcommand 返回 cloud 命令下指定名称的子命令
+func findSubcommand(t *testing.T, name string) *cobra.Command {
+	t.Helper()
+
+	cmd, _, err := NewCommand().Find([]string{name})
+	if err != nil || cmd.Name() != name {
+		t.Fatalf("expected cloud %v subcommand, got: %v", name, err)
+	}
+	return cmd
+}
+
 func TestRebootCommand(t *testing.T) {
 	// 创建临时测试目录
 	tempDir := t.TempDir()
@@ -52,20 +64,25 @@ func TestRebootCommand(t *testing.T) {
 	}
 
 	t.Run("ValidDestination", func(t *testing.T) {
-		cmd := NewCommand()
+		cmd := findSubcommand(t, "reboot")
 
 		// 为测试目的替换内部函数
 		originalFunc := rebootInstanceFunc
-		rebootInstanceFunc = func(dest *config.DestinationInstance) error {
+		rebootInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
 			if dest.Region != "ap-beijing" || dest.InstanceId != "ins-test123" {
-				return errors.New("unexpected destination instance values")
+				return "", errors.New("unexpected destination instance values")
 			}
-			return nil
+			return "", nil
 		}
 		defer func() {
 			rebootInstanceFunc = originalFunc
 		}()
 
+		// 重启需要确认，测试中跳过
+		if err := cmd.Flag("yes").Value.Set("true"); err != nil {
+			t.Fatal(err)
+		}
+
 		err := cmd.RunE(cmd, []string{"test-dest"})
 		if err != nil {
 			t.Errorf("expected no error, got: %v", err)
@@ -75,7 +92,7 @@ func TestRebootCommand(t *testing.T) {
 	t.Run("NoDestinationProvided", func(t *testing.T) {
 		// 为避免运行命令时出现错误，我们不直接调用RunE
 		// 而是测试命令配置
-		cmd := NewCommand()
+		cmd := findSubcommand(t, "reboot")
 
 		// 验证命令配置
 		if cmd.Args == nil {
@@ -84,7 +101,7 @@ func TestRebootCommand(t *testing.T) {
 	})
 
 	t.Run("NonExistentDestination", func(t *testing.T) {
-		cmd := NewCommand()
+		cmd := findSubcommand(t, "reboot")
 
 		// 为测试目的替换内部函数
 		originalFunc := rebootInstanceFunc
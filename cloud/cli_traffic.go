@@ -0,0 +1,168 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// newTrafficCommand 创建 cloud traffic 子命令，显示流量包使用情况并可持续监控
+func newTrafficCommand() *cobra.Command {
+	var parallel, count int
+	var watch bool
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "traffic [selector...]",
+		Short: "显示和监控流量包使用情况",
+		Long: `显示目标实例流量包的已用、总量、剩余和使用率，并按本周期的平均用量估算用完的日期。
+默认显示所有配置了 instance-id 的目标。
+
+使用 --watch 持续监控：使用率每达到一个新的告警阈值就通过 Telegram 推送一次告警，
+超过 stop-at 时自动关机（受保护的目标需要 --force，--dry-run 时只显示关机请求）。
+策略在配置的 traffic 字段中设置，目标可以单独覆盖：
+
+  traffic:
+    alerts: [80, 90, 100]   # 默认值
+    stop-at: 120            # 超过流量包 120% 时关机，默认不关机
+
+` + selectorHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			if watch && format != output.Table {
+				return fmt.Errorf("--watch 只支持表格输出")
+			}
+
+			dests, err := loadCloudDestinations(args)
+			if err != nil {
+				return err
+			}
+
+			if !watch {
+				return showTraffic(cmd.OutOrStdout(), format, dests, parallel)
+			}
+
+			if interval <= 0 {
+				return fmt.Errorf("--interval 必须大于 0")
+			}
+
+			out := &syncWriter{w: cmd.OutOrStdout()}
+			watcher := newTrafficWatcher(out)
+			flags := loadMutationFlags(cmd)
+			watcher.dryRun, watcher.force = flags.DryRun, flags.Force
+			for i := 0; count == 0 || i < count; i++ {
+				if i > 0 {
+					sleepFunc(interval)
+				}
+
+				results := runFleet(dests, parallel, func(dest *config.Destination) error {
+					if err := requireInstance(dest); err != nil {
+						return err
+					}
+					return watcher.check(dest)
+				})
+				for _, result := range results {
+					if result.Err != nil {
+						fmt.Fprintf(out, "%v: %v\n", result.Name, result.Err)
+					}
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "批量查询时的最大并发数")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "持续监控并按策略告警和关机")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Minute, "--watch 的检查间隔")
+	cmd.Flags().IntVar(&count, "count", 0, "--watch 的检查次数，0 表示一直运行")
+
+	return cmd
+}
+
+// showTraffic 查询目标的流量包并按 format 输出
+func showTraffic(w io.Writer, format string, dests []config.Destination, parallel int) error {
+	index := make(map[string]int, len(dests))
+	for i, dest := range dests {
+		index[dest.Name] = i
+	}
+
+	instances := make([]*Instance, len(dests))
+	results := runFleet(dests, parallel, func(dest *config.Destination) error {
+		if err := requireInstance(dest); err != nil {
+			return err
+		}
+		instance, err := DescribeInstance(&dest.DestinationInstance)
+		instances[index[dest.Name]] = instance
+		return err
+	})
+
+	if format != output.Table {
+		if err := output.Write(w, format, newTrafficResults(results, instances)); err != nil {
+			return err
+		}
+	} else {
+		renderTrafficTable(w, results, instances)
+	}
+
+	return fleetError("查询", results)
+}
+
+// renderTrafficTable 渲染流量表格，使用率按 80% 和 100% 分别以黄色和红色标出
+func renderTrafficTable(w io.Writer, results []fleetResult, instances []*Instance) {
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+
+	table := output.NewTable(w)
+	table.Header([]string{"目标", "实例 ID", "已用", "总量", "剩余", "使用率", "周期结束", "预计用完"})
+	for i, result := range results {
+		instance := instances[i]
+		if result.Err != nil || instance == nil {
+			message := "未知"
+			if result.Err != nil {
+				message = result.Err.Error()
+			}
+			_ = table.Append([]string{result.Name, result.InstanceId, red(message), "", "", "", "", ""})
+			continue
+		}
+
+		traffic := instance.Traffic
+		if traffic == nil || traffic.Total == 0 {
+			_ = table.Append([]string{result.Name, instance.InstanceId, "-", "-", "-", "无流量包", "-", "-"})
+			continue
+		}
+
+		_ = table.Append([]string{
+			result.Name,
+			instance.InstanceId,
+			formatBytes(traffic.Used),
+			formatBytes(traffic.Total),
+			formatBytes(traffic.Remaining),
+			colorPercent(trafficPercent(traffic)),
+			formatPeriodEnd(traffic),
+			formatExhaustion(traffic),
+		})
+	}
+
+	_ = table.Render()
+}
+
+// colorPercent 按使用率着色：100% 及以上为红色，80% 及以上为黄色，其余为绿色
+func colorPercent(percent float64) string {
+	text := fmt.Sprintf("%.1f%%", percent)
+	switch {
+	case percent >= 100:
+		return color.New(color.FgRed, color.Bold).Sprint(text)
+	case percent >= 80:
+		return color.New(color.FgYellow, color.Bold).Sprint(text)
+	default:
+		return color.New(color.FgGreen).Sprint(text)
+	}
+}
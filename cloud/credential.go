@@ -0,0 +1,316 @@
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+
+	"lucky-go/config"
+)
+
+const (
+	// stsService、stsVersion 和 stsRegion 是 STS API 的服务名、版本和地域
+	stsService = "sts"
+	stsVersion = "2018-08-13"
+	stsRegion  = "ap-guangzhou"
+
+	// defaultTccliProfile 是没有配置账号时读取的 tccli 配置名称
+	defaultTccliProfile = "default"
+
+	// roleRefreshMargin 是临时凭证过期前提前刷新的时间
+	roleRefreshMargin = 5 * time.Minute
+)
+
+// credentialOptions 是 cloud 命令的 --account、--secret-id 和 --secret-key 全局标志
+type credentialOptions struct {
+	// Account 覆盖所有目标的 account 字段
+	Account string
+	// SecretId 和 SecretKey 是显式指定的密钥，优先于其他所有凭证来源
+	SecretId  string
+	SecretKey string
+}
+
+// credentialFlags 是当前命令的凭证标志，由 cloud 命令的 PersistentPreRunE 设置
+var credentialFlags credentialOptions
+
+// loadCredentialFlags 读取命令的凭证标志，--secret-id 和 --secret-key 必须同时提供
+func loadCredentialFlags(cmd *cobra.Command) (credentialOptions, error) {
+	value := func(name string) string {
+		if flag := cmd.Flag(name); flag != nil {
+			return flag.Value.String()
+		}
+		return ""
+	}
+
+	options := credentialOptions{Account: value("account"), SecretId: value("secret-id"), SecretKey: value("secret-key")}
+	if (options.SecretId == "") != (options.SecretKey == "") {
+		return credentialOptions{}, configErrorf("--secret-id 和 --secret-key 必须同时提供")
+	}
+	return options, nil
+}
+
+// tencentCredential 返回访问账号 account（为空表示目标没有配置账号）所用的腾讯云凭证，依次使用:
+//  1. --secret-id 和 --secret-key 标志
+//  2. --account 标志或目标 account 字段指定的账号，见 accountCredential
+//  3. 密钥库或环境变量 TENCENT_CLOUD_SECRET_ID、TENCENT_CLOUD_SECRET_KEY
+//  4. tccli 的凭证文件 ~/.tccli/default.credential
+func tencentCredential(account string) (common.CredentialIface, error) {
+	flags := credentialFlags
+	if flags.SecretId != "" {
+		return common.NewCredential(flags.SecretId, flags.SecretKey), nil
+	}
+	if flags.Account != "" {
+		account = flags.Account
+	}
+	if account != "" {
+		return accountCredential(account)
+	}
+	return defaultCredential()
+}
+
+// defaultCredential 从密钥库、环境变量或 tccli 的 default 配置读取凭证
+func defaultCredential() (common.CredentialIface, error) {
+	secretId, err := config.LookupSecret(config.SecretTencentCloudSecretId, "TENCENT_CLOUD_SECRET_ID")
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := config.LookupSecret(config.SecretTencentCloudSecretKey, "TENCENT_CLOUD_SECRET_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if secretId != "" && secretKey != "" {
+		return common.NewCredential(secretId, secretKey), nil
+	}
+
+	credential, err := tccliCredential(defaultTccliProfile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, configErrorf("没有找到腾讯云凭证，请设置 TENCENT_CLOUD_SECRET_ID 和 TENCENT_CLOUD_SECRET_KEY、使用 config secrets set 保存密钥，或为目标配置 account")
+	}
+	return credential, err
+}
+
+// accountCredential 返回配置中账号的凭证。账号的密钥来自 tccli-profile 指定的 tccli 配置，
+// 或密钥库中的 tencent-cloud-secret-id@<账号> 和 tencent-cloud-secret-key@<账号>；
+// 配置了 role-arn 时用这些密钥（没有时用默认凭证）扮演角色，返回会自动刷新的临时凭证。
+func accountCredential(name string) (common.CredentialIface, error) {
+	account, err := config.LoadAccount(name)
+	if err != nil {
+		return nil, &configError{err}
+	}
+
+	var base common.CredentialIface
+	if account.TccliProfile != "" {
+		if base, err = tccliCredential(account.TccliProfile); err != nil {
+			return nil, configErrorf("账号 %v: %w", name, err)
+		}
+	} else if base, err = accountSecrets(name); err != nil {
+		return nil, err
+	}
+
+	if account.RoleArn == "" {
+		if base == nil {
+			return nil, configErrorf("账号 %v 没有凭证，请使用 config secrets set %v 和 %v 保存密钥，或配置 tccli-profile", name,
+				config.AccountSecretName(config.SecretTencentCloudSecretId, name), config.AccountSecretName(config.SecretTencentCloudSecretKey, name))
+		}
+		return base, nil
+	}
+
+	if base == nil {
+		if base, err = defaultCredential(); err != nil {
+			return nil, err
+		}
+	}
+	return assumedRoleCredential(name, base, account)
+}
+
+// accountSecrets 从密钥库读取账号的密钥，两个条目都不存在时返回 nil
+func accountSecrets(name string) (common.CredentialIface, error) {
+	secretId, err := config.LookupSecret(config.AccountSecretName(config.SecretTencentCloudSecretId, name), "")
+	if err != nil {
+		return nil, err
+	}
+	secretKey, err := config.LookupSecret(config.AccountSecretName(config.SecretTencentCloudSecretKey, name), "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case secretId == "" && secretKey == "":
+		return nil, nil
+	case secretId == "":
+		return nil, configErrorf("账号 %v 缺少密钥库条目 %v", name, config.AccountSecretName(config.SecretTencentCloudSecretId, name))
+	case secretKey == "":
+		return nil, configErrorf("账号 %v 缺少密钥库条目 %v", name, config.AccountSecretName(config.SecretTencentCloudSecretKey, name))
+	}
+	return common.NewCredential(secretId, secretKey), nil
+}
+
+// tccliCredentialFile 是 tccli 凭证文件的内容
+type tccliCredentialFile struct {
+	SecretId  string `json:"secretId"`
+	SecretKey string `json:"secretKey"`
+	Token     string `json:"token"`
+}
+
+// tccliCredential 读取 tccli 的凭证文件 ~/.tccli/<profile>.credential
+func tccliCredential(profile string) (common.CredentialIface, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(home, ".tccli", profile+".credential")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file tccliCredentialFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析 %v 失败: %w", path, err)
+	}
+	if file.SecretId == "" || file.SecretKey == "" {
+		return nil, fmt.Errorf("%v 中没有 secretId 和 secretKey", path)
+	}
+	return common.NewTokenCredential(file.SecretId, file.SecretKey, file.Token), nil
+}
+
+// roleToken 是 AssumeRole 返回的临时凭证
+type roleToken struct {
+	TmpSecretId  string `json:"TmpSecretId"`
+	TmpSecretKey string `json:"TmpSecretKey"`
+	Token        string `json:"Token"`
+	// Expiration 是临时凭证的过期时间
+	Expiration time.Time `json:"-"`
+}
+
+// assumeRoleResponse 是 AssumeRole 的响应
+type assumeRoleResponse struct {
+	Response struct {
+		Credentials roleToken `json:"Credentials"`
+		ExpiredTime int64     `json:"ExpiredTime"`
+		RequestId   string    `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// assumeRoleFunc 用 base 凭证扮演账号配置的角色。测试中可替换。
+var assumeRoleFunc = defaultAssumeRole
+
+// defaultAssumeRole 调用 STS AssumeRole 获取临时凭证
+func defaultAssumeRole(base common.CredentialIface, account config.Account) (*roleToken, error) {
+	duration, err := account.RoleDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	cpf, err := newClientProfile(stsService, "")
+	if err != nil {
+		return nil, err
+	}
+	client := common.NewCommonClient(base, stsRegion, cpf)
+	request := tchttp.NewCommonRequest(stsService, stsVersion, "AssumeRole")
+	err = request.SetActionParameters(map[string]any{
+		"RoleArn":         account.RoleArn,
+		"RoleSessionName": account.SessionName(),
+		"DurationSeconds": int64(duration / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := tchttp.NewCommonResponse()
+	if err := client.Send(request, response); err != nil {
+		return nil, err
+	}
+
+	var parsed assumeRoleResponse
+	if err := json.Unmarshal(response.GetBody(), &parsed); err != nil {
+		return nil, err
+	}
+	token := parsed.Response.Credentials
+	token.Expiration = time.Unix(parsed.Response.ExpiredTime, 0)
+	return &token, nil
+}
+
+// roleCredential 是扮演角色得到的临时凭证，过期前 roleRefreshMargin 自动重新调用 AssumeRole。
+// 实现 common.CredentialIface，SDK 每次签名请求时读取。
+type roleCredential struct {
+	mu      sync.Mutex
+	base    common.CredentialIface
+	account config.Account
+	token   *roleToken
+}
+
+// refresh 在临时凭证即将过期时重新获取。刷新失败时继续使用原来的凭证，由云平台返回鉴权错误。
+func (c *roleCredential) refresh() {
+	if c.token != nil && timeNow().Before(c.token.Expiration.Add(-roleRefreshMargin)) {
+		return
+	}
+	if token, err := assumeRoleFunc(c.base, c.account); err == nil {
+		c.token = token
+	}
+}
+
+// GetSecretId 实现 common.CredentialIface
+func (c *roleCredential) GetSecretId() string {
+	secretId, _, _ := c.GetCredential()
+	return secretId
+}
+
+// GetSecretKey 实现 common.CredentialIface
+func (c *roleCredential) GetSecretKey() string {
+	_, secretKey, _ := c.GetCredential()
+	return secretKey
+}
+
+// GetToken 实现 common.CredentialIface
+func (c *roleCredential) GetToken() string {
+	_, _, token := c.GetCredential()
+	return token
+}
+
+// GetCredential 实现 common.CredentialIface，一次返回同一份临时凭证的三个部分
+func (c *roleCredential) GetCredential() (string, string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refresh()
+	if c.token == nil {
+		return "", "", ""
+	}
+	return c.token.TmpSecretId, c.token.TmpSecretKey, c.token.Token
+}
+
+// assumedRoles 缓存各账号的临时凭证，使同一进程内的批量操作共用一次 AssumeRole
+var assumedRoles = struct {
+	sync.Mutex
+	credentials map[string]*roleCredential
+}{credentials: map[string]*roleCredential{}}
+
+// assumedRoleCredential 返回账号的临时凭证，首次使用时调用 AssumeRole，失败时返回错误
+func assumedRoleCredential(name string, base common.CredentialIface, account config.Account) (common.CredentialIface, error) {
+	assumedRoles.Lock()
+	defer assumedRoles.Unlock()
+
+	if credential, ok := assumedRoles.credentials[name]; ok {
+		return credential, nil
+	}
+
+	token, err := assumeRoleFunc(base, account)
+	if err != nil {
+		return nil, fmt.Errorf("账号 %v 扮演角色 %v 失败: %w", name, account.RoleArn, err)
+	}
+
+	credential := &roleCredential{base: base, account: account, token: token}
+	assumedRoles.credentials[name] = credential
+	return credential, nil
+}
@@ -0,0 +1,189 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+
+	"lucky-go/config"
+)
+
+// useFakeAssumeRole 替换 AssumeRole 和时钟，返回的临时 SecretId 为 tmp-<基础 SecretId>-<调用次数>。
+// 返回推进时钟的函数，以及设置后使 AssumeRole 失败的错误。
+func useFakeAssumeRole(t *testing.T) (func(d time.Duration), *error) {
+	t.Helper()
+
+	var fail error
+	calls := 0
+	originalAssume, originalNow := assumeRoleFunc, timeNow
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	assumeRoleFunc = func(base common.CredentialIface, account config.Account) (*roleToken, error) {
+		if fail != nil {
+			return nil, fail
+		}
+		calls++
+		duration, _ := account.RoleDuration()
+		id := fmt.Sprintf("tmp-%v-%d", base.GetSecretId(), calls)
+		return &roleToken{TmpSecretId: id, TmpSecretKey: "tmp-key", Token: "token", Expiration: now.Add(duration)}, nil
+	}
+	t.Cleanup(func() {
+		assumeRoleFunc, timeNow = originalAssume, originalNow
+		assumedRoles.credentials = map[string]*roleCredential{}
+	})
+
+	return func(d time.Duration) { now = now.Add(d) }, &fail
+}
+
+// writeTccliCredential 在 HOME 下写入 tccli 凭证文件
+func writeTccliCredential(t *testing.T, profile, secretId string) {
+	t.Helper()
+
+	dir := filepath.Join(os.Getenv("HOME"), ".tccli")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"secretId": "` + secretId + `", "secretKey": "` + secretId + `-key"}`
+	if err := os.WriteFile(filepath.Join(dir, profile+".credential"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTencentCredential(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Accounts: map[string]config.Account{
+			"project-a":    {TccliProfile: "project-a"},
+			"project-b":    {TccliProfile: "project-b", RoleArn: "qcs::cam::uin/100000000001:roleName/ops", Duration: "2h"},
+			"ops":          {RoleArn: "qcs::cam::uin/100000000001:roleName/ops"},
+			"no-secret":    {},
+			"bad-duration": {RoleArn: "qcs::cam::uin/100000000001:roleName/ops", Duration: "24h"},
+		},
+		Dest: map[string]config.DestinationInstance{},
+	})
+	writeTccliCredential(t, "default", "default")
+	writeTccliCredential(t, "project-a", "project-a")
+	writeTccliCredential(t, "project-b", "project-b")
+	useFakeAssumeRole(t)
+
+	originalFlags := credentialFlags
+	t.Cleanup(func() { credentialFlags = originalFlags })
+
+	tests := []struct {
+		name     string
+		flags    credentialOptions
+		env      bool
+		account  string
+		secretId string
+		exitCode int
+	}{
+		{name: "Flags", flags: credentialOptions{Account: "project-a", SecretId: "flag", SecretKey: "flag-key"}, env: true, account: "project-b", secretId: "flag"},
+		{name: "AccountFlag", flags: credentialOptions{Account: "project-a"}, env: true, account: "project-b", secretId: "project-a"},
+		{name: "AccountBeforeEnv", env: true, account: "project-a", secretId: "project-a"},
+		{name: "Env", env: true, secretId: "env"},
+		{name: "TccliDefault", secretId: "default"},
+		{name: "AssumeRole", account: "project-b", secretId: "tmp-project-b-1"},
+		{name: "AssumeRoleCached", account: "project-b", secretId: "tmp-project-b-1"},
+		{name: "AssumeRoleWithDefault", env: true, account: "ops", secretId: "tmp-env-2"},
+		{name: "AccountWithoutSecret", account: "no-secret", exitCode: EXIT_CONFIG},
+		{name: "UnknownAccount", account: "missing", exitCode: EXIT_CONFIG},
+		{name: "BadDuration", account: "bad-duration", exitCode: EXIT_CONFIG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentialFlags = tt.flags
+			if tt.env {
+				t.Setenv("TENCENT_CLOUD_SECRET_ID", "env")
+				t.Setenv("TENCENT_CLOUD_SECRET_KEY", "env-key")
+			} else {
+				t.Setenv("TENCENT_CLOUD_SECRET_ID", "")
+				t.Setenv("TENCENT_CLOUD_SECRET_KEY", "")
+			}
+
+			credential, err := tencentCredential(tt.account)
+			if tt.exitCode != 0 {
+				if ExitCode(err) != tt.exitCode {
+					t.Fatalf("expected exit code %d, got: %v", tt.exitCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if got := credential.GetSecretId(); got != tt.secretId {
+				t.Errorf("expected secret id %v, got %v", tt.secretId, got)
+			}
+		})
+	}
+
+	t.Run("NoCredential", func(t *testing.T) {
+		credentialFlags = credentialOptions{}
+		t.Setenv("TENCENT_CLOUD_SECRET_ID", "")
+		if err := os.Remove(filepath.Join(os.Getenv("HOME"), ".tccli", "default.credential")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tencentCredential(""); ExitCode(err) != EXIT_CONFIG || !strings.Contains(err.Error(), "没有找到腾讯云凭证") {
+			t.Errorf("expected missing credential error, got: %v", err)
+		}
+	})
+
+	t.Run("FlagsInPairs", func(t *testing.T) {
+		_, err := runCloudCommand(t, "status", "--secret-id", "flag")
+		if ExitCode(err) != EXIT_CONFIG || !strings.Contains(err.Error(), "--secret-key") {
+			t.Errorf("expected --secret-key to be required, got: %v", err)
+		}
+
+		// 根命令只通过 ExitCode 方法决定退出码，PersistentPreRunE 的错误同样需要带上
+		var coded interface{ ExitCode() int }
+		if !errors.As(err, &coded) || coded.ExitCode() != EXIT_CONFIG {
+			t.Errorf("expected the error to carry exit code %d, got: %v", EXIT_CONFIG, err)
+		}
+	})
+}
+
+func TestRoleCredentialRefresh(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Accounts: map[string]config.Account{
+			"ops": {RoleArn: "qcs::cam::uin/100000000001:roleName/ops"},
+		},
+		Dest: map[string]config.DestinationInstance{},
+	})
+	advance, fail := useFakeAssumeRole(t)
+
+	credential, err := assumedRoleCredential("ops", common.NewCredential("base", "base-key"), config.Account{RoleArn: "qcs::cam::uin/100000000001:roleName/ops"})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	steps := []struct {
+		advance  time.Duration
+		fail     bool
+		secretId string
+	}{
+		{0, false, "tmp-base-1"},
+		{50 * time.Minute, false, "tmp-base-1"},
+		// 过期前 5 分钟内自动刷新
+		{6 * time.Minute, false, "tmp-base-2"},
+		// 刷新失败时继续使用原来的凭证
+		{time.Hour, true, "tmp-base-2"},
+		{0, false, "tmp-base-3"},
+	}
+	for i, step := range steps {
+		advance(step.advance)
+		*fail = nil
+		if step.fail {
+			*fail = errors.New("network error")
+		}
+
+		secretId, secretKey, token := credential.GetCredential()
+		if secretId != step.secretId || secretKey != "tmp-key" || token != "token" {
+			t.Errorf("step %d: expected %v, got %v %v %v", i, step.secretId, secretId, secretKey, token)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+
+	"lucky-go/config"
+)
+
+// CVM API 的服务名、版本和 DescribeInstances 单页返回的最大数量
+const (
+	cvmService  = "cvm"
+	cvmVersion  = "2017-03-12"
+	cvmPageSize = 100
+)
+
+// cvmProvider 是腾讯云云服务器（CVM）的 Provider 实现。
+// 为避免引入完整的 CVM SDK，通过通用客户端按 action 名称调用 API。
+type cvmProvider struct {
+	region string
+	// send 调用 action 并返回响应 JSON，测试中可替换
+	send func(action string, params map[string]any) ([]byte, error)
+}
+
+// cvmInstance 是 DescribeInstances 返回的实例中用到的字段
+type cvmInstance struct {
+	InstanceId         string   `json:"InstanceId"`
+	InstanceName       string   `json:"InstanceName"`
+	InstanceState      string   `json:"InstanceState"`
+	InstanceType       string   `json:"InstanceType"`
+	CPU                int64    `json:"CPU"`
+	Memory             int64    `json:"Memory"`
+	OsName             string   `json:"OsName"`
+	PublicIpAddresses  []string `json:"PublicIpAddresses"`
+	PrivateIpAddresses []string `json:"PrivateIpAddresses"`
+	CreatedTime        string   `json:"CreatedTime"`
+	ExpiredTime        string   `json:"ExpiredTime"`
+	RenewFlag          string   `json:"RenewFlag"`
+	Placement          struct {
+		Zone string `json:"Zone"`
+	} `json:"Placement"`
+	Tags []struct {
+		Key   string `json:"Key"`
+		Value string `json:"Value"`
+	} `json:"Tags"`
+}
+
+// cvmActionResponse 是开关机类 action 的响应
+type cvmActionResponse struct {
+	Response struct {
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// cvmDescribeResponse 是 DescribeInstances 的响应
+type cvmDescribeResponse struct {
+	Response struct {
+		TotalCount  int64         `json:"TotalCount"`
+		InstanceSet []cvmInstance `json:"InstanceSet"`
+	} `json:"Response"`
+}
+
+// newCVMProvider 创建指定地域的 CVM Provider，使用账号 account 的凭证
+func newCVMProvider(region, account string) (Provider, error) {
+	credential, err := tencentCredential(account)
+	if err != nil {
+		return nil, err
+	}
+
+	cpf, err := newClientProfile(cvmService, "")
+	if err != nil {
+		return nil, err
+	}
+	client := common.NewCommonClient(credential, region, cpf)
+
+	return &cvmProvider{
+		region: region,
+		send: func(action string, params map[string]any) ([]byte, error) {
+			request := tchttp.NewCommonRequest(cvmService, cvmVersion, action)
+			if err := request.SetActionParameters(params); err != nil {
+				return nil, err
+			}
+
+			response := tchttp.NewCommonResponse()
+			if err := client.Send(request, response); err != nil {
+				return nil, err
+			}
+			return response.GetBody(), nil
+		},
+	}, nil
+}
+
+// Reboot 实现 Provider 接口
+func (p *cvmProvider) Reboot(instanceId string) (string, error) {
+	return p.action("RebootInstances", instanceId)
+}
+
+// Start 实现 Provider 接口
+func (p *cvmProvider) Start(instanceId string) (string, error) {
+	return p.action("StartInstances", instanceId)
+}
+
+// Stop 实现 Provider 接口
+func (p *cvmProvider) Stop(instanceId string) (string, error) {
+	return p.action("StopInstances", instanceId)
+}
+
+// action 对单个实例调用开关机类 action，返回请求 ID
+func (p *cvmProvider) action(action, instanceId string) (string, error) {
+	body, err := p.send(action, map[string]any{"InstanceIds": []string{instanceId}})
+	if err != nil {
+		return "", err
+	}
+
+	response := &cvmActionResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return "", fmt.Errorf("解析 %v 响应失败: %w", action, err)
+	}
+	return response.Response.RequestId, nil
+}
+
+// Describe 实现 Provider 接口，CVM 按带宽计费，没有流量包
+func (p *cvmProvider) Describe(instanceId string) (*Instance, error) {
+	response, err := p.describe(map[string]any{"InstanceIds": []string{instanceId}})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Response.InstanceSet) == 0 {
+		return nil, fmt.Errorf("地域 %v 中不存在实例 %v", p.region, instanceId)
+	}
+
+	return p.instance(response.Response.InstanceSet[0]), nil
+}
+
+// List 实现 Provider 接口，分页查询地域内的全部实例
+func (p *cvmProvider) List() ([]Instance, error) {
+	var instances []Instance
+	for offset := 0; ; offset += cvmPageSize {
+		response, err := p.describe(map[string]any{"Offset": offset, "Limit": cvmPageSize})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Response.InstanceSet {
+			instances = append(instances, *p.instance(item))
+		}
+
+		if len(response.Response.InstanceSet) == 0 || int64(len(instances)) >= response.Response.TotalCount {
+			return instances, nil
+		}
+	}
+}
+
+// describe 调用 DescribeInstances 并解析响应
+func (p *cvmProvider) describe(params map[string]any) (*cvmDescribeResponse, error) {
+	body, err := p.send("DescribeInstances", params)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &cvmDescribeResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("解析 DescribeInstances 响应失败: %w", err)
+	}
+	return response, nil
+}
+
+// instance 把 CVM 实例转换为 Instance
+func (p *cvmProvider) instance(item cvmInstance) *Instance {
+	var tags map[string]string
+	for _, tag := range item.Tags {
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[tag.Key] = tag.Value
+	}
+
+	return &Instance{
+		InstanceId:  item.InstanceId,
+		Name:        item.InstanceName,
+		Provider:    config.ProviderCVM,
+		Region:      p.region,
+		Zone:        item.Placement.Zone,
+		State:       item.InstanceState,
+		PublicIPs:   item.PublicIpAddresses,
+		PrivateIPs:  item.PrivateIpAddresses,
+		BundleId:    item.InstanceType,
+		CPU:         item.CPU,
+		Memory:      item.Memory,
+		OsName:      item.OsName,
+		CreatedTime: item.CreatedTime,
+		ExpiredTime: item.ExpiredTime,
+		RenewFlag:   item.RenewFlag,
+		Tags:        tags,
+	}
+}
@@ -0,0 +1,75 @@
+package cloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCVMProvider(t *testing.T) {
+	type call struct {
+		action string
+		params string
+	}
+	var calls []call
+
+	pages := []string{
+		`{"Response":{"TotalCount":2,"InstanceSet":[{"InstanceId":"ins-a","InstanceName":"a","InstanceState":"RUNNING","InstanceType":"S5.MEDIUM4","CPU":2,"Memory":4,"PublicIpAddresses":["1.1.1.1"],"PrivateIpAddresses":["10.0.0.1"],"ExpiredTime":"2026-12-31T00:00:00Z","Placement":{"Zone":"ap-guangzhou-3"}}],"RequestId":"r1"}}`,
+		`{"Response":{"TotalCount":2,"InstanceSet":[{"InstanceId":"ins-b","InstanceState":"STOPPED"}],"RequestId":"r2"}}`,
+	}
+
+	provider := &cvmProvider{
+		region: "ap-guangzhou",
+		send: func(action string, params map[string]any) ([]byte, error) {
+			data, _ := json.Marshal(params)
+			calls = append(calls, call{action, string(data)})
+			if action != "DescribeInstances" {
+				return []byte(`{"Response":{"RequestId":"r"}}`), nil
+			}
+			if _, ok := params["Offset"]; ok {
+				return []byte(pages[len(calls)-1]), nil
+			}
+			return []byte(pages[0]), nil
+		},
+	}
+
+	t.Run("Power", func(t *testing.T) {
+		calls = nil
+		_, _ = provider.Start("ins-a")
+		_, _ = provider.Stop("ins-a")
+		_, _ = provider.Reboot("ins-a")
+
+		expected := []call{
+			{"StartInstances", `{"InstanceIds":["ins-a"]}`},
+			{"StopInstances", `{"InstanceIds":["ins-a"]}`},
+			{"RebootInstances", `{"InstanceIds":["ins-a"]}`},
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("expected %v, got %v", expected, calls)
+		}
+	})
+
+	t.Run("Describe", func(t *testing.T) {
+		instance, err := provider.Describe("ins-a")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if instance.Provider != "cvm" || instance.Zone != "ap-guangzhou-3" || instance.BundleId != "S5.MEDIUM4" || instance.PublicIPs[0] != "1.1.1.1" {
+			t.Errorf("unexpected instance: %+v", instance)
+		}
+	})
+
+	t.Run("ListPages", func(t *testing.T) {
+		calls = nil
+		instances, err := provider.List()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(instances) != 2 || instances[1].InstanceId != "ins-b" {
+			t.Errorf("expected two instances across pages, got %+v", instances)
+		}
+		if len(calls) != 2 || calls[1].params != `{"Limit":100,"Offset":100}` {
+			t.Errorf("expected second page request, got %v", calls)
+		}
+	})
+}
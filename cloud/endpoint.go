@@ -0,0 +1,57 @@
+package cloud
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+
+	"lucky-go/config"
+)
+
+// tencentEndpoint 是当前命令使用的腾讯云 API 地址（--endpoint-tencent 或配置中的 endpoints.tencent），
+// 由 cloud 命令的 PersistentPreRunE 解析一次，避免每创建一个客户端就重新读取配置
+var tencentEndpoint string
+
+// loadTencentEndpoint 解析当前命令使用的腾讯云 API 地址
+func loadTencentEndpoint() (string, error) {
+	endpoint, err := config.LoadEndpoint(config.EndpointTencent, "")
+	if err != nil {
+		return "", configErrorf("%v", err)
+	}
+	return endpoint, nil
+}
+
+// newClientProfile 返回访问腾讯云服务 service 的客户端配置。endpoint 为命令的 --endpoint，
+// 为空时使用 tencentEndpoint，都未设置时使用默认地址。
+func newClientProfile(service, endpoint string) (*profile.ClientProfile, error) {
+	if endpoint == "" {
+		endpoint = tencentEndpoint
+	}
+
+	cpf := profile.NewClientProfile()
+	if err := applyEndpoint(cpf, strings.ReplaceAll(endpoint, "{service}", service)); err != nil {
+		return nil, err
+	}
+	return cpf, nil
+}
+
+// applyEndpoint 把 endpoint 设置到客户端配置。endpoint 可以是域名（如 tat.tencentcloudapi.com），
+// 也可以是带协议的地址（如 http://127.0.0.1:8080），后者用于连接本地的替身服务。
+func applyEndpoint(cpf *profile.ClientProfile, endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	if !strings.Contains(endpoint, "://") {
+		cpf.HttpProfile.Endpoint = endpoint
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return configErrorf("endpoint %q 格式不正确，应为域名或 http(s)://host:port", endpoint)
+	}
+	cpf.HttpProfile.Scheme = strings.ToUpper(u.Scheme)
+	cpf.HttpProfile.Endpoint = u.Host + strings.TrimSuffix(u.Path, "/")
+	return nil
+}
@@ -0,0 +1,230 @@
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	sdkerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+
+	"lucky-go/config"
+)
+
+// cloud 命令的退出码，脚本可以据此区分失败原因
+const (
+	// EXIT_ERROR 是其他错误，包括参数错误和用户取消
+	EXIT_ERROR = 1
+	// EXIT_NOT_FOUND 表示目标或分组不在配置中，或者选择器没有匹配任何目标
+	EXIT_NOT_FOUND = 3
+	// EXIT_CONFIG 表示目标缺少 region、instance-id，或者 provider 不支持该操作
+	EXIT_CONFIG = 4
+	// EXIT_API 表示云平台 API 返回错误
+	EXIT_API = 5
+	// EXIT_TIMEOUT 表示等待实例或快照超时
+	EXIT_TIMEOUT = 6
+	// EXIT_PROTECTED 表示目标受保护，没有使用 --force
+	EXIT_PROTECTED = 7
+	// EXIT_REMOTE 表示 cloud run 在实例上执行的命令失败，远程命令的退出码见结果和标准错误
+	EXIT_REMOTE = 8
+)
+
+// 错误类型，出现在 JSON/YAML 结果的 error.kind 中
+const (
+	errorKindGeneral   = "error"
+	errorKindNotFound  = "not-found"
+	errorKindConfig    = "config"
+	errorKindAPI       = "api"
+	errorKindTimeout   = "timeout"
+	errorKindProtected = "protected"
+	errorKindRemote    = "remote"
+)
+
+// configError 表示目标的配置不足以执行操作
+type configError struct {
+	error
+}
+
+func (e *configError) Unwrap() error {
+	return e.error
+}
+
+// configErrorf 创建 configError
+func configErrorf(format string, args ...any) error {
+	return &configError{fmt.Errorf(format, args...)}
+}
+
+// timeoutError 表示等待超时
+type timeoutError struct {
+	error
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.error
+}
+
+// timeoutErrorf 创建 timeoutError
+func timeoutErrorf(format string, args ...any) error {
+	return &timeoutError{fmt.Errorf(format, args...)}
+}
+
+// protectedError 表示目标受保护而拒绝执行操作
+type protectedError struct {
+	error
+}
+
+func (e *protectedError) Unwrap() error {
+	return e.error
+}
+
+// protectedErrorf 创建 protectedError
+func protectedErrorf(format string, args ...any) error {
+	return &protectedError{fmt.Errorf(format, args...)}
+}
+
+// ResultError 是结果中的错误信息
+type ResultError struct {
+	Kind string `json:"kind" yaml:"kind"`
+	// Code 是云平台返回的错误码，仅 api 类型的错误有
+	Code    string `json:"code,omitempty" yaml:"code,omitempty"`
+	Message string `json:"message" yaml:"message"`
+	// RequestId 是云平台返回的请求 ID，仅 api 类型的错误有
+	RequestId string `json:"request-id,omitempty" yaml:"request-id,omitempty"`
+}
+
+// newResultError 把错误转换为结果中的错误信息，err 为 nil 时返回 nil
+func newResultError(err error) *ResultError {
+	if err == nil {
+		return nil
+	}
+
+	result := &ResultError{Kind: errorKind(err), Message: err.Error()}
+	var sdkErr *sdkerrors.TencentCloudSDKError
+	if errors.As(err, &sdkErr) {
+		result.Code = sdkErr.Code
+		result.RequestId = sdkErr.RequestId
+	}
+	return result
+}
+
+// errorKind 返回错误的类型
+func errorKind(err error) string {
+	var sdkErr *sdkerrors.TencentCloudSDKError
+	var configErr *configError
+	var timeoutErr *timeoutError
+	var protectedErr *protectedError
+	var remoteErr *remoteExitError
+
+	switch {
+	case errors.Is(err, config.ErrDestinationNotFound):
+		return errorKindNotFound
+	case errors.As(err, &configErr):
+		return errorKindConfig
+	case errors.As(err, &sdkErr):
+		return errorKindAPI
+	case errors.As(err, &timeoutErr):
+		return errorKindTimeout
+	case errors.As(err, &protectedErr):
+		return errorKindProtected
+	case errors.As(err, &remoteErr):
+		return errorKindRemote
+	default:
+		return errorKindGeneral
+	}
+}
+
+// exitCodes 把错误类型映射到退出码
+var exitCodes = map[string]int{
+	errorKindGeneral:   EXIT_ERROR,
+	errorKindNotFound:  EXIT_NOT_FOUND,
+	errorKindConfig:    EXIT_CONFIG,
+	errorKindAPI:       EXIT_API,
+	errorKindTimeout:   EXIT_TIMEOUT,
+	errorKindProtected: EXIT_PROTECTED,
+	errorKindRemote:    EXIT_REMOTE,
+}
+
+// ExitCode 返回错误对应的退出码，err 为 nil 时返回 0
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coded interface{ ExitCode() int }
+	if errors.As(err, &coded) {
+		return coded.ExitCode()
+	}
+	return exitCodes[errorKind(err)]
+}
+
+// fleetFailure 是批量操作部分目标失败时的错误，退出码为失败目标共同的退出码
+type fleetFailure struct {
+	message string
+	code    int
+}
+
+func (e *fleetFailure) Error() string {
+	return e.message
+}
+
+// ExitCode 返回失败目标共同的退出码，原因不同时为 EXIT_ERROR
+func (e *fleetFailure) ExitCode() int {
+	return e.code
+}
+
+// remoteExitError 表示远程命令执行失败。cloud run 统一以 EXIT_REMOTE 退出，
+// 避免远程命令的退出码与其他 cloud 退出码混淆，远程命令的退出码保存在 code 中
+type remoteExitError struct {
+	code int
+}
+
+func (e *remoteExitError) Error() string {
+	if e.code == 0 {
+		return "远程命令执行失败"
+	}
+	return fmt.Sprintf("远程命令退出码 %d", e.code)
+}
+
+// ExitCode 返回 EXIT_REMOTE
+func (e *remoteExitError) ExitCode() int {
+	return EXIT_REMOTE
+}
+
+// exitError 为命令返回的错误附加退出码
+type exitError struct {
+	error
+	code int
+}
+
+func (e *exitError) Unwrap() error {
+	return e.error
+}
+
+// ExitCode 返回命令的退出码
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// withExitCodes 包装命令树中每个命令的 RunE 和 PersistentPreRunE，使返回的错误带有 ExitCode 方法
+func withExitCodes(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = withExitCode(run)
+	}
+	if preRun := cmd.PersistentPreRunE; preRun != nil {
+		cmd.PersistentPreRunE = withExitCode(preRun)
+	}
+
+	for _, child := range cmd.Commands() {
+		withExitCodes(child)
+	}
+}
+
+// withExitCode 包装单个命令函数，为返回的错误附加退出码
+func withExitCode(run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		err := run(cmd, args)
+		if err == nil {
+			return nil
+		}
+		return &exitError{error: err, code: ExitCode(err)}
+	}
+}
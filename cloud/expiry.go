@@ -0,0 +1,257 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"lucky-go/output"
+)
+
+// EXPIRY_STATE_ENV 指定到期提醒状态文件的路径
+const EXPIRY_STATE_ENV = "LUCKY_GO_EXPIRY_STATE"
+
+// EXPIRY_STATE_FILE 是未设置 EXPIRY_STATE_ENV 时到期提醒状态文件的名称，位于 ~/.lucky-go 下
+const EXPIRY_STATE_FILE = "expiry-state.json"
+
+// expiryReminderDays 是提醒窗口内再次提醒的剩余天数节点，0 表示到期当天及已过期
+var expiryReminderDays = []int{7, 3, 1, 0}
+
+// ExpiryResult 是查询单个目标到期时间的结果
+type ExpiryResult struct {
+	Destination string `json:"destination" yaml:"destination"`
+	InstanceId  string `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	ExpiredTime string `json:"expired-time,omitempty" yaml:"expired-time,omitempty"`
+	// DaysRemaining 是距离到期的整天数，已过期时为负数，没有到期时间（如按量计费）时为空
+	DaysRemaining *int         `json:"days-remaining,omitempty" yaml:"days-remaining,omitempty"`
+	Error         *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// expiryDays 返回到期时间距离 now 的整天数，已过期时为负数
+func expiryDays(expired, now time.Time) int {
+	return int(math.Floor(expired.Sub(now).Hours() / 24))
+}
+
+// newExpiryResults 按查询结果创建 ExpiryResult，并按紧急程度排序：
+// 查询失败的在前，然后按剩余天数从少到多，没有到期时间的在最后
+func newExpiryResults(results []fleetResult, instances []*Instance) []ExpiryResult {
+	now := timeNow()
+	expiries := make([]ExpiryResult, len(results))
+	for i, result := range results {
+		expiries[i] = ExpiryResult{Destination: result.Name, InstanceId: result.InstanceId, Error: newResultError(result.Err)}
+		if result.Err != nil || instances[i] == nil {
+			continue
+		}
+
+		expiries[i].ExpiredTime = instances[i].ExpiredTime
+		if expired, err := time.Parse(time.RFC3339, instances[i].ExpiredTime); err == nil {
+			days := expiryDays(expired, now)
+			expiries[i].DaysRemaining = &days
+		}
+	}
+
+	rank := func(expiry ExpiryResult) (int, int) {
+		switch {
+		case expiry.Error != nil:
+			return 0, 0
+		case expiry.DaysRemaining == nil:
+			return 2, 0
+		default:
+			return 1, *expiry.DaysRemaining
+		}
+	}
+	sort.SliceStable(expiries, func(i, j int) bool {
+		groupI, daysI := rank(expiries[i])
+		groupJ, daysJ := rank(expiries[j])
+		if groupI != groupJ {
+			return groupI < groupJ
+		}
+		return daysI < daysJ
+	})
+	return expiries
+}
+
+// formatDaysRemaining 把剩余天数格式化为文字
+func formatDaysRemaining(days int) string {
+	switch {
+	case days < 0:
+		return fmt.Sprintf("已过期 %d 天", -days)
+	case days == 0:
+		return "今天到期"
+	default:
+		return fmt.Sprintf("%d 天", days)
+	}
+}
+
+// renderExpiryTable 渲染到期时间表格，7 天内到期为红色，30 天内为黄色
+func renderExpiryTable(w io.Writer, expiries []ExpiryResult) {
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
+
+	table := output.NewTable(w)
+	table.Header([]string{"目标", "实例 ID", "到期时间", "剩余"})
+	for _, expiry := range expiries {
+		switch {
+		case expiry.Error != nil:
+			_ = table.Append([]string{expiry.Destination, expiry.InstanceId, red(expiry.Error.Message), ""})
+		case expiry.DaysRemaining == nil:
+			_ = table.Append([]string{expiry.Destination, expiry.InstanceId, "-", "无到期时间"})
+		default:
+			expired, _ := time.Parse(time.RFC3339, expiry.ExpiredTime)
+			remaining := formatDaysRemaining(*expiry.DaysRemaining)
+			switch days := *expiry.DaysRemaining; {
+			case days <= 7:
+				remaining = red(remaining)
+			case days <= 30:
+				remaining = yellow(remaining)
+			}
+			_ = table.Append([]string{expiry.Destination, expiry.InstanceId, expired.Local().Format("2006-01-02 15:04"), remaining})
+		}
+	}
+
+	_ = table.Render()
+}
+
+// expiryStage 返回剩余天数在提醒窗口 within 天内所处的节点：within 和 expiryReminderDays 中
+// 不小于剩余天数的最小值。不在窗口内时 ok 为 false。
+func expiryStage(days, within int) (stage int, ok bool) {
+	if days > within {
+		return 0, false
+	}
+
+	stage = within
+	for _, reminder := range expiryReminderDays {
+		if reminder < stage && days <= reminder {
+			stage = reminder
+		}
+	}
+	return stage, true
+}
+
+// expiryState 是到期提醒的状态，记录已经提醒过的节点，避免每次运行都重复提醒
+type expiryState struct {
+	// Reminded 以实例 ID 和到期时间为键，记录最近一次提醒时所处的节点。
+	// 续费后到期时间变化，对应新的键，会重新开始提醒。
+	Reminded map[string]int `json:"reminded"`
+}
+
+// expiryStateKey 返回实例在状态文件中的键
+func expiryStateKey(instanceId, expiredTime string) string {
+	return instanceId + "|" + expiredTime
+}
+
+// expiryStatePath 返回到期提醒状态文件的路径
+func expiryStatePath() (string, error) {
+	return stateFilePath(EXPIRY_STATE_ENV, EXPIRY_STATE_FILE)
+}
+
+// loadExpiryState 读取状态文件，文件不存在时返回空状态
+func loadExpiryState(path string) (*expiryState, error) {
+	state := &expiryState{}
+	if err := readStateFile(path, state); err != nil {
+		return nil, err
+	}
+
+	if state.Reminded == nil {
+		state.Reminded = map[string]int{}
+	}
+	return state, nil
+}
+
+// save 写入状态文件
+func (state *expiryState) save(path string) error {
+	return writeStateFile(path, state)
+}
+
+// forget 删除实例在其他到期时间下的记录，即续费之前的提醒
+func (state *expiryState) forget(instanceId, keep string) {
+	for key := range state.Reminded {
+		if strings.HasPrefix(key, instanceId+"|") && key != keep {
+			delete(state.Reminded, key)
+		}
+	}
+}
+
+// dueExpiryReminders 返回 within 天内到期、且进入了新提醒节点的实例，以及它们所处的节点。
+// 同时清理状态中已续费实例的旧记录。
+func dueExpiryReminders(state *expiryState, expiries []ExpiryResult, within int) ([]ExpiryResult, map[string]int) {
+	var due []ExpiryResult
+	stages := map[string]int{}
+	for _, expiry := range expiries {
+		if expiry.DaysRemaining == nil {
+			continue
+		}
+
+		key := expiryStateKey(expiry.InstanceId, expiry.ExpiredTime)
+		state.forget(expiry.InstanceId, key)
+
+		stage, ok := expiryStage(*expiry.DaysRemaining, within)
+		if !ok {
+			continue
+		}
+		if last, reminded := state.Reminded[key]; reminded && last <= stage {
+			continue
+		}
+		due = append(due, expiry)
+		stages[key] = stage
+	}
+	return due, stages
+}
+
+// formatExpiryDigest 生成到期提醒的 Telegram 消息
+func formatExpiryDigest(due []ExpiryResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*实例到期提醒*\n\n以下 %d 个实例即将到期，请及时续费:\n", len(due))
+	for _, expiry := range due {
+		expired, _ := time.Parse(time.RFC3339, expiry.ExpiredTime)
+		fmt.Fprintf(&b, "\n%v (%v)\n到期: %v，剩余: %v\n", expiry.Destination, expiry.InstanceId,
+			expired.Local().Format("2006-01-02 15:04"), formatDaysRemaining(*expiry.DaysRemaining))
+	}
+	return b.String()
+}
+
+// notifyExpiry 把 within 天内到期且有新提醒的实例汇总为一条消息推送，并记录到状态文件。
+// dryRun 时只输出消息，不推送也不更新状态。
+func notifyExpiry(out io.Writer, expiries []ExpiryResult, within int, dryRun bool) error {
+	path, err := expiryStatePath()
+	if err != nil {
+		return err
+	}
+	state, err := loadExpiryState(path)
+	if err != nil {
+		return err
+	}
+
+	due, stages := dueExpiryReminders(state, expiries, within)
+	if len(due) == 0 {
+		fmt.Fprintf(out, "%d 天内没有新的到期提醒\n", within)
+		if dryRun {
+			return nil
+		}
+		return state.save(path)
+	}
+
+	message := formatExpiryDigest(due)
+	if dryRun {
+		fmt.Fprintf(out, "[dry-run] 将推送以下到期提醒:\n%v", message)
+		return nil
+	}
+
+	if err := sendNotification(message); err != nil {
+		return fmt.Errorf("推送到期提醒失败: %w", err)
+	}
+	for key, stage := range stages {
+		state.Reminded[key] = stage
+	}
+	if err := state.save(path); err != nil {
+		return fmt.Errorf("已推送到期提醒，但保存状态文件失败: %w", err)
+	}
+
+	fmt.Fprintf(out, "已推送 %d 个实例的到期提醒\n", len(due))
+	return nil
+}
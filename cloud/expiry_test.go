@@ -0,0 +1,177 @@
+package cloud
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestExpiryStage(t *testing.T) {
+	tests := []struct {
+		days, within int
+		stage        int
+		ok           bool
+	}{
+		{20, 14, 0, false},
+		{14, 14, 14, true},
+		{8, 14, 14, true},
+		{7, 14, 7, true},
+		{5, 14, 7, true},
+		{2, 14, 3, true},
+		{1, 14, 1, true},
+		{0, 14, 0, true},
+		{-3, 14, 0, true},
+		{5, 5, 5, true},
+		{3, 5, 3, true},
+	}
+
+	for _, tt := range tests {
+		stage, ok := expiryStage(tt.days, tt.within)
+		if stage != tt.stage || ok != tt.ok {
+			t.Errorf("expiryStage(%d, %d) = %d, %v, want %d, %v", tt.days, tt.within, stage, ok, tt.stage, tt.ok)
+		}
+	}
+}
+
+func TestExpiryCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web":   {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web"},
+			"db":    {Ssh: "root@1.1.1.2", Region: "ap-hongkong", InstanceId: "lhins-db"},
+			"batch": {Ssh: "root@1.1.1.3", Provider: config.ProviderCVM, Region: "ap-hongkong", InstanceId: "ins-batch"},
+		},
+	})
+	statePath := filepath.Join(t.TempDir(), "expiry-state.json")
+	t.Setenv(EXPIRY_STATE_ENV, statePath)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := map[string]string{
+		"lhins-web": "2026-01-11T00:00:00Z",
+		"lhins-db":  "2026-03-01T00:00:00Z",
+		"ins-batch": "",
+	}
+	originalNow, originalDescribe, originalSend := timeNow, describeInstanceFunc, sendNotification
+	t.Cleanup(func() {
+		timeNow, describeInstanceFunc, sendNotification = originalNow, originalDescribe, originalSend
+	})
+	timeNow = func() time.Time { return now }
+	describeInstanceFunc = func(dest *config.DestinationInstance) (*Instance, error) {
+		return &Instance{InstanceId: dest.InstanceId, ExpiredTime: expired[dest.InstanceId]}, nil
+	}
+	var messages []string
+	var sendErr error
+	sendNotification = func(message string) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		messages = append(messages, message)
+		return nil
+	}
+
+	t.Run("SortedByUrgency", func(t *testing.T) {
+		stdout, _, err := runCloudCommandSplit(t, "expiry", "-o", "json")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		var results []ExpiryResult
+		if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		var order []string
+		for _, result := range results {
+			order = append(order, result.Destination)
+		}
+		if strings.Join(order, ",") != "web,db,batch" {
+			t.Errorf("expected web,db,batch, got %v", order)
+		}
+		if results[0].DaysRemaining == nil || *results[0].DaysRemaining != 10 || results[2].DaysRemaining != nil {
+			t.Errorf("unexpected days remaining: %+v", results)
+		}
+	})
+
+	notify := func() string {
+		t.Helper()
+		out, err := runCloudCommand(t, "expiry", "--notify-within", "14d")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+		return out
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		out, err := runCloudCommand(t, "expiry", "--notify-within", "14d", "--dry-run")
+		if err != nil || !strings.Contains(out, "[dry-run]") || len(messages) != 0 {
+			t.Errorf("expected dry run to only print the digest, got: %v %v\n%s", err, messages, out)
+		}
+	})
+
+	t.Run("NotifyOnce", func(t *testing.T) {
+		notify()
+		if len(messages) != 1 || !strings.Contains(messages[0], "web") || strings.Contains(messages[0], "db") {
+			t.Fatalf("expected one digest for web, got %v", messages)
+		}
+
+		if out := notify(); len(messages) != 1 || !strings.Contains(out, "没有新的到期提醒") {
+			t.Errorf("expected no repeated reminder, got %v\n%s", messages, out)
+		}
+
+		// 状态文件通过临时文件原子地写入，不留下临时文件
+		entries, err := os.ReadDir(filepath.Dir(statePath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if strings.Contains(entry.Name(), ".tmp-") {
+				t.Errorf("expected no temporary state files, found %v", entry.Name())
+			}
+		}
+		if info, err := os.Stat(statePath); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("expected the state file to be written with mode 0600, got %v, %v", info, err)
+		}
+	})
+
+	t.Run("SendFailure", func(t *testing.T) {
+		now = now.Add(6 * 24 * time.Hour)
+		sendErr = errors.New("network error")
+		if _, err := runCloudCommand(t, "expiry", "--notify-within", "14d"); err == nil {
+			t.Fatal("expected send failure to be reported")
+		}
+		sendErr = nil
+	})
+
+	t.Run("NextStage", func(t *testing.T) {
+		notify()
+		if len(messages) != 2 || !strings.Contains(messages[1], "4 天") {
+			t.Fatalf("expected a reminder 4 days before expiry, got %v", messages)
+		}
+	})
+
+	t.Run("Renewed", func(t *testing.T) {
+		expired["lhins-web"] = "2027-01-11T00:00:00Z"
+		notify()
+		if len(messages) != 2 {
+			t.Errorf("expected no reminder after renewal, got %v", messages)
+		}
+
+		state, err := loadExpiryState(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(state.Reminded) != 0 {
+			t.Errorf("expected reminders before renewal to be forgotten, got %v", state.Reminded)
+		}
+	})
+
+	t.Run("BadWindow", func(t *testing.T) {
+		if _, err := runCloudCommand(t, "expiry", "--notify-within", "2h"); err == nil {
+			t.Error("expected window shorter than a day to be rejected")
+		}
+	})
+}
@@ -0,0 +1,639 @@
+package cloud
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"lucky-go/config"
+)
+
+// FAKE_CLOUD_ENV 指定 fake provider 保存状态的文件，设为 memory 时只保存在内存中
+const FAKE_CLOUD_ENV = "LUCKY_GO_FAKE_CLOUD"
+
+// FAKE_CLOUD_FILE 是未设置 FAKE_CLOUD_ENV 时 fake provider 状态文件的名称，位于 ~/.lucky-go 下
+const FAKE_CLOUD_FILE = "fake-cloud.json"
+
+// 定义变量，用于在测试中控制 fake provider 的时间
+var (
+	fakeClock           = time.Now
+	fakeTransitionDelay = 3 * time.Second
+)
+
+var (
+	// fakeMu 保护 fake provider 的状态读写
+	fakeMu sync.Mutex
+	// fakeMemory 是内存模式下的状态
+	fakeMemory = &fakeState{}
+)
+
+// fakeDefaultFirewall 是模拟实例的初始防火墙规则，与轻量应用服务器的默认规则一致
+var fakeDefaultFirewall = []config.FirewallRule{
+	{Protocol: "TCP", Port: "22", Cidr: "0.0.0.0/0", Action: "ACCEPT", Description: "Linux SSH"},
+	{Protocol: "TCP", Port: "80", Cidr: "0.0.0.0/0", Action: "ACCEPT", Description: "HTTP"},
+	{Protocol: "TCP", Port: "443", Cidr: "0.0.0.0/0", Action: "ACCEPT", Description: "HTTPS"},
+	{Protocol: "ICMP", Port: "ALL", Cidr: "0.0.0.0/0", Action: "ACCEPT", Description: "Ping"},
+}
+
+// fakeTerminated 是已销毁的模拟实例的状态，查询时视为不存在
+const fakeTerminated = "TERMINATED"
+
+// fakeState 是 fake provider 持久化的全部状态
+type fakeState struct {
+	Instances map[string]*fakeInstance `json:"instances"`
+	Snapshots map[string]*fakeSnapshot `json:"snapshots,omitempty"`
+	// Firewalls 把实例 ID 映射到其防火墙规则
+	Firewalls map[string][]config.FirewallRule `json:"firewalls,omitempty"`
+	KeyPairs  map[string]*fakeKeyPair          `json:"key-pairs,omitempty"`
+	// NextId 用于生成快照等资源的 ID
+	NextId int `json:"next-id,omitempty"`
+}
+
+// fakeSnapshot 是单个模拟快照，Target 不为空时表示正在向该状态过渡
+type fakeSnapshot struct {
+	Snapshot   Snapshot  `json:"snapshot"`
+	InstanceId string    `json:"instance-id"`
+	Target     string    `json:"target,omitempty"`
+	ReadyAt    time.Time `json:"ready-at,omitempty"`
+}
+
+// fakeKeyPair 是单个模拟密钥对，属于创建或导入时的地域
+type fakeKeyPair struct {
+	KeyPair KeyPair `json:"key-pair"`
+	Region  string  `json:"region"`
+}
+
+// nextId 生成带前缀的资源 ID
+func (state *fakeState) nextId(prefix string) string {
+	state.NextId++
+	return fmt.Sprintf("%v-%08d", prefix, state.NextId)
+}
+
+// fakeInstance 是单个模拟实例，Target 不为空时表示正在向该状态过渡
+type fakeInstance struct {
+	Instance Instance  `json:"instance"`
+	Target   string    `json:"target,omitempty"`
+	ReadyAt  time.Time `json:"ready-at,omitempty"`
+}
+
+// fakeProvider 是用于测试和演练的模拟 Provider。
+// 首次引用的实例自动以 RUNNING 状态创建；开关机和重启先进入中间状态，
+// 经过 fakeTransitionDelay 后到达目标状态。
+type fakeProvider struct {
+	region string
+	// path 为空时使用内存状态
+	path  string
+	now   func() time.Time
+	delay time.Duration
+}
+
+// newFakeProvider 创建指定地域的 fake Provider，所有账号共用同一份状态
+func newFakeProvider(region, _ string) (Provider, error) {
+	path := os.Getenv(FAKE_CLOUD_ENV)
+	switch path {
+	case "memory":
+		path = ""
+	case "":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, config.CONFIG_DIR, FAKE_CLOUD_FILE)
+	}
+
+	return &fakeProvider{region: region, path: path, now: fakeClock, delay: fakeTransitionDelay}, nil
+}
+
+// Reboot 实现 Provider 接口
+func (p *fakeProvider) Reboot(instanceId string) (string, error) {
+	return p.transition(instanceId, "RUNNING", "REBOOTING", "RUNNING", "重启")
+}
+
+// Start 实现 Provider 接口
+func (p *fakeProvider) Start(instanceId string) (string, error) {
+	return p.transition(instanceId, "STOPPED", "STARTING", "RUNNING", "开机")
+}
+
+// Stop 实现 Provider 接口
+func (p *fakeProvider) Stop(instanceId string) (string, error) {
+	return p.transition(instanceId, "RUNNING", "STOPPING", "STOPPED", "关机")
+}
+
+// Describe 实现 Provider 接口
+func (p *fakeProvider) Describe(instanceId string) (*Instance, error) {
+	var instance Instance
+	err := p.update(func(state *fakeState) error {
+		instance = p.instance(state, instanceId).Instance
+		if instance.State == fakeTerminated {
+			return fmt.Errorf("实例 %v 不存在", instanceId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &instance, nil
+}
+
+// List 实现 Provider 接口，返回地域内已创建的模拟实例
+func (p *fakeProvider) List() ([]Instance, error) {
+	var instances []Instance
+	err := p.update(func(state *fakeState) error {
+		for _, item := range state.Instances {
+			if item.Instance.Region == p.region && item.Instance.State != fakeTerminated {
+				instances = append(instances, item.Instance)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(instances, func(i, j int) bool { return instances[i].InstanceId < instances[j].InstanceId })
+	return instances, err
+}
+
+// CreateSnapshot 实现 SnapshotProvider 接口
+func (p *fakeProvider) CreateSnapshot(instanceId, name string) (string, error) {
+	var snapshotId string
+	err := p.update(func(state *fakeState) error {
+		p.instance(state, instanceId)
+
+		snapshotId = state.nextId("lhsnap")
+		state.Snapshots[snapshotId] = &fakeSnapshot{
+			Snapshot: Snapshot{
+				SnapshotId:  snapshotId,
+				Name:        name,
+				State:       "CREATING",
+				CreatedTime: p.now().UTC().Format(time.RFC3339),
+			},
+			InstanceId: instanceId,
+			Target:     "NORMAL",
+			ReadyAt:    p.now().Add(p.delay),
+		}
+		return nil
+	})
+
+	return snapshotId, err
+}
+
+// ListSnapshots 实现 SnapshotProvider 接口，按创建时间从旧到新排序
+func (p *fakeProvider) ListSnapshots(instanceId string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	err := p.update(func(state *fakeState) error {
+		for _, item := range state.Snapshots {
+			if item.InstanceId == instanceId {
+				snapshots = append(snapshots, item.Snapshot)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].SnapshotId < snapshots[j].SnapshotId })
+	return snapshots, err
+}
+
+// DeleteSnapshot 实现 SnapshotProvider 接口
+func (p *fakeProvider) DeleteSnapshot(snapshotId string) error {
+	return p.update(func(state *fakeState) error {
+		if _, ok := state.Snapshots[snapshotId]; !ok {
+			return fmt.Errorf("快照 %v 不存在", snapshotId)
+		}
+		delete(state.Snapshots, snapshotId)
+		return nil
+	})
+}
+
+// RestoreSnapshot 实现 SnapshotProvider 接口
+func (p *fakeProvider) RestoreSnapshot(instanceId, snapshotId string) error {
+	return p.update(func(state *fakeState) error {
+		item, ok := state.Snapshots[snapshotId]
+		if !ok || item.InstanceId != instanceId {
+			return fmt.Errorf("实例 %v 不存在快照 %v", instanceId, snapshotId)
+		}
+		if item.Snapshot.State != "NORMAL" {
+			return fmt.Errorf("快照 %v 当前状态为 %v，无法回滚", snapshotId, item.Snapshot.State)
+		}
+
+		item.Snapshot.State = "ROLLBACKING"
+		item.Snapshot.Percent = 0
+		item.Target = "NORMAL"
+		item.ReadyAt = p.now().Add(p.delay)
+		return nil
+	})
+}
+
+// ListFirewallRules 实现 FirewallProvider 接口
+func (p *fakeProvider) ListFirewallRules(instanceId string) ([]config.FirewallRule, error) {
+	var rules []config.FirewallRule
+	err := p.update(func(state *fakeState) error {
+		rules = append(rules, p.firewall(state, instanceId)...)
+		return nil
+	})
+
+	return rules, err
+}
+
+// AddFirewallRules 实现 FirewallProvider 接口，规则已存在时返回错误
+func (p *fakeProvider) AddFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	return p.update(func(state *fakeState) error {
+		current := p.firewall(state, instanceId)
+		for _, rule := range rules {
+			if findFirewallRule(current, rule) != nil {
+				return fmt.Errorf("实例 %v 已存在防火墙规则 %v", instanceId, rule)
+			}
+			current = append(current, rule.Normalize())
+		}
+		state.Firewalls[instanceId] = current
+		return nil
+	})
+}
+
+// RemoveFirewallRules 实现 FirewallProvider 接口，规则不存在时返回错误
+func (p *fakeProvider) RemoveFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	return p.update(func(state *fakeState) error {
+		current := p.firewall(state, instanceId)
+		for _, rule := range rules {
+			if findFirewallRule(current, rule) == nil {
+				return fmt.Errorf("实例 %v 不存在防火墙规则 %v", instanceId, rule)
+			}
+
+			var kept []config.FirewallRule
+			for _, existing := range current {
+				if existing.Key() != rule.Key() {
+					kept = append(kept, existing)
+				}
+			}
+			current = kept
+		}
+		state.Firewalls[instanceId] = current
+		return nil
+	})
+}
+
+// ReplaceFirewallRules 实现 FirewallProvider 接口
+func (p *fakeProvider) ReplaceFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	return p.update(func(state *fakeState) error {
+		p.firewall(state, instanceId)
+
+		replaced := make([]config.FirewallRule, 0, len(rules))
+		for _, rule := range rules {
+			replaced = append(replaced, rule.Normalize())
+		}
+		state.Firewalls[instanceId] = replaced
+		return nil
+	})
+}
+
+// firewall 返回实例的防火墙规则，首次访问时使用默认规则
+func (p *fakeProvider) firewall(state *fakeState, instanceId string) []config.FirewallRule {
+	p.instance(state, instanceId)
+
+	rules, ok := state.Firewalls[instanceId]
+	if !ok {
+		rules = append([]config.FirewallRule(nil), fakeDefaultFirewall...)
+		state.Firewalls[instanceId] = rules
+	}
+	return rules
+}
+
+// ListKeyPairs 实现 KeyPairProvider 接口，返回地域内的模拟密钥对
+func (p *fakeProvider) ListKeyPairs() ([]KeyPair, error) {
+	var keyPairs []KeyPair
+	err := p.update(func(state *fakeState) error {
+		for _, item := range state.KeyPairs {
+			if item.Region == p.region {
+				keyPairs = append(keyPairs, item.KeyPair)
+			}
+		}
+		return nil
+	})
+
+	sort.Slice(keyPairs, func(i, j int) bool { return keyPairs[i].KeyId < keyPairs[j].KeyId })
+	return keyPairs, err
+}
+
+// CreateKeyPair 实现 KeyPairProvider 接口，生成真实可用的 ed25519 密钥
+func (p *fakeProvider) CreateKeyPair(name string) (*KeyPair, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := ssh.MarshalPrivateKey(private, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic)))
+	keyId, err := p.ImportKeyPair(name, publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return &KeyPair{KeyId: keyId, KeyName: name, PublicKey: publicKey, CreatedTime: p.now().UTC().Format(time.RFC3339)},
+		string(pem.EncodeToMemory(block)), nil
+}
+
+// ImportKeyPair 实现 KeyPairProvider 接口，地域内名称重复时返回错误
+func (p *fakeProvider) ImportKeyPair(name, publicKey string) (string, error) {
+	var keyId string
+	err := p.update(func(state *fakeState) error {
+		for _, item := range state.KeyPairs {
+			if item.Region == p.region && item.KeyPair.KeyName == name {
+				return fmt.Errorf("地域 %v 已存在名为 %v 的密钥对", p.region, name)
+			}
+		}
+
+		keyId = state.nextId("lhkp")
+		state.KeyPairs[keyId] = &fakeKeyPair{
+			KeyPair: KeyPair{
+				KeyId:       keyId,
+				KeyName:     name,
+				PublicKey:   publicKey,
+				CreatedTime: p.now().UTC().Format(time.RFC3339),
+			},
+			Region: p.region,
+		}
+		return nil
+	})
+
+	return keyId, err
+}
+
+// AssociateKeyPairs 实现 KeyPairProvider 接口，已绑定的密钥对保持不变
+func (p *fakeProvider) AssociateKeyPairs(instanceId string, keyIds []string) error {
+	return p.update(func(state *fakeState) error {
+		p.instance(state, instanceId)
+		for _, keyId := range keyIds {
+			item, err := p.keyPair(state, keyId)
+			if err != nil {
+				return err
+			}
+			if !contains(item.KeyPair.AssociatedInstanceIds, instanceId) {
+				item.KeyPair.AssociatedInstanceIds = append(item.KeyPair.AssociatedInstanceIds, instanceId)
+			}
+		}
+		return nil
+	})
+}
+
+// DisassociateKeyPairs 实现 KeyPairProvider 接口，密钥对没有绑定实例时返回错误
+func (p *fakeProvider) DisassociateKeyPairs(instanceId string, keyIds []string) error {
+	return p.update(func(state *fakeState) error {
+		for _, keyId := range keyIds {
+			item, err := p.keyPair(state, keyId)
+			if err != nil {
+				return err
+			}
+			if !contains(item.KeyPair.AssociatedInstanceIds, instanceId) {
+				return fmt.Errorf("密钥对 %v 没有绑定实例 %v", keyId, instanceId)
+			}
+
+			var kept []string
+			for _, id := range item.KeyPair.AssociatedInstanceIds {
+				if id != instanceId {
+					kept = append(kept, id)
+				}
+			}
+			item.KeyPair.AssociatedInstanceIds = kept
+		}
+		return nil
+	})
+}
+
+// keyPair 返回地域内的密钥对，不存在时返回错误
+func (p *fakeProvider) keyPair(state *fakeState, keyId string) (*fakeKeyPair, error) {
+	item, ok := state.KeyPairs[keyId]
+	if !ok || item.Region != p.region {
+		return nil, fmt.Errorf("地域 %v 不存在密钥对 %v", p.region, keyId)
+	}
+	return item, nil
+}
+
+// CreateInstance 实现 LifecycleProvider 接口，实例先处于 PENDING 状态，延迟后变为 RUNNING
+func (p *fakeProvider) CreateInstance(spec InstanceSpec) (string, error) {
+	if spec.BlueprintId == "" || spec.BundleId == "" {
+		return "", fmt.Errorf("创建实例需要镜像和套餐")
+	}
+
+	var instanceId string
+	err := p.update(func(state *fakeState) error {
+		for _, keyId := range spec.KeyIds {
+			if _, err := p.keyPair(state, keyId); err != nil {
+				return err
+			}
+		}
+
+		instanceId = state.nextId("lhins")
+		item := p.instance(state, instanceId)
+		item.Instance.Name = spec.Name
+		item.Instance.BundleId = spec.BundleId
+		item.Instance.OsName = spec.BlueprintId
+		if spec.Zone != "" {
+			item.Instance.Zone = spec.Zone
+		}
+		item.Instance.ExpiredTime = p.now().UTC().AddDate(0, int(spec.Period), 0).Format(time.RFC3339)
+		item.Instance.State = "PENDING"
+		item.Target = "RUNNING"
+		item.ReadyAt = p.now().Add(p.delay)
+
+		for _, keyId := range spec.KeyIds {
+			keyPair := state.KeyPairs[keyId]
+			keyPair.KeyPair.AssociatedInstanceIds = append(keyPair.KeyPair.AssociatedInstanceIds, instanceId)
+		}
+		return nil
+	})
+
+	return instanceId, err
+}
+
+// IsolateInstance 实现 LifecycleProvider 接口，实例经过 STOPPING 进入 SHUTDOWN 状态
+func (p *fakeProvider) IsolateInstance(instanceId string) error {
+	return p.update(func(state *fakeState) error {
+		item := p.instance(state, instanceId)
+		if item.Instance.State != "RUNNING" && item.Instance.State != "STOPPED" {
+			return fmt.Errorf("实例 %v 当前状态为 %v，无法退还", instanceId, item.Instance.State)
+		}
+
+		item.Instance.State = "STOPPING"
+		item.Target = "SHUTDOWN"
+		item.ReadyAt = p.now().Add(p.delay)
+		return nil
+	})
+}
+
+// TerminateInstance 实现 LifecycleProvider 接口，同时解除实例绑定的密钥对
+func (p *fakeProvider) TerminateInstance(instanceId string) error {
+	return p.update(func(state *fakeState) error {
+		item := p.instance(state, instanceId)
+		if item.Instance.State != "SHUTDOWN" {
+			return fmt.Errorf("实例 %v 当前状态为 %v，只能销毁已退还（SHUTDOWN）的实例", instanceId, item.Instance.State)
+		}
+		item.Instance.State = fakeTerminated
+
+		for _, keyPair := range state.KeyPairs {
+			var kept []string
+			for _, id := range keyPair.KeyPair.AssociatedInstanceIds {
+				if id != instanceId {
+					kept = append(kept, id)
+				}
+			}
+			keyPair.KeyPair.AssociatedInstanceIds = kept
+		}
+		return nil
+	})
+}
+
+// transition 要求实例处于 from 状态，然后进入 via 状态并在延迟后到达 to 状态，返回生成的请求 ID
+func (p *fakeProvider) transition(instanceId, from, via, to, action string) (string, error) {
+	var requestId string
+	err := p.update(func(state *fakeState) error {
+		item := p.instance(state, instanceId)
+		if item.Instance.State != from {
+			return fmt.Errorf("实例 %v 当前状态为 %v，无法%v", instanceId, item.Instance.State, action)
+		}
+
+		item.Instance.State = via
+		item.Target = to
+		item.ReadyAt = p.now().Add(p.delay)
+		requestId = state.nextId("req")
+		return nil
+	})
+	return requestId, err
+}
+
+// instance 返回实例，不存在时以 RUNNING 状态创建
+func (p *fakeProvider) instance(state *fakeState, instanceId string) *fakeInstance {
+	if item, ok := state.Instances[instanceId]; ok {
+		return item
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(instanceId))
+	n := h.Sum32()
+
+	now := p.now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	item := &fakeInstance{Instance: Instance{
+		InstanceId:  instanceId,
+		Name:        instanceId,
+		Provider:    config.ProviderFake,
+		Region:      p.region,
+		Zone:        p.region + "-1",
+		State:       "RUNNING",
+		PublicIPs:   []string{fmt.Sprintf("203.0.113.%d", n%254+1)},
+		PrivateIPs:  []string{fmt.Sprintf("10.0.%d.%d", n>>8%256, n%254+1)},
+		BundleId:    "fake_bundle",
+		CPU:         2,
+		Memory:      2,
+		OsName:      "Fake Linux",
+		CreatedTime: now.Format(time.RFC3339),
+		ExpiredTime: now.AddDate(1, 0, 0).Format(time.RFC3339),
+		Traffic: &Traffic{
+			Total:     1 << 40,
+			Remaining: 1 << 40,
+			StartTime: month.Format(time.RFC3339),
+			EndTime:   month.AddDate(0, 1, 0).Format(time.RFC3339),
+		},
+	}}
+	state.Instances[instanceId] = item
+
+	return item
+}
+
+// update 读取状态、推进已到期的过渡、调用 fn 并保存
+func (p *fakeProvider) update(fn func(state *fakeState) error) error {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+
+	state, err := p.load()
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	for _, item := range state.Instances {
+		if item.Target != "" && !now.Before(item.ReadyAt) {
+			item.Instance.State = item.Target
+			item.Target = ""
+			item.ReadyAt = time.Time{}
+		}
+	}
+	for _, item := range state.Snapshots {
+		if item.Target != "" && !now.Before(item.ReadyAt) {
+			item.Snapshot.State = item.Target
+			item.Snapshot.Percent = 100
+			item.Target = ""
+			item.ReadyAt = time.Time{}
+		}
+	}
+
+	if err := fn(state); err != nil {
+		return err
+	}
+
+	return p.save(state)
+}
+
+// load 读取状态，文件不存在时返回空状态
+func (p *fakeProvider) load() (*fakeState, error) {
+	state := fakeMemory
+	if p.path != "" {
+		state = &fakeState{}
+		data, err := os.ReadFile(p.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, state); err != nil {
+				return nil, fmt.Errorf("解析 fake provider 状态文件 %v 失败: %w", p.path, err)
+			}
+		}
+	}
+
+	if state.Instances == nil {
+		state.Instances = map[string]*fakeInstance{}
+	}
+	if state.Snapshots == nil {
+		state.Snapshots = map[string]*fakeSnapshot{}
+	}
+	if state.Firewalls == nil {
+		state.Firewalls = map[string][]config.FirewallRule{}
+	}
+	if state.KeyPairs == nil {
+		state.KeyPairs = map[string]*fakeKeyPair{}
+	}
+
+	return state, nil
+}
+
+// save 把状态写回文件，内存模式下无需保存
+func (p *fakeProvider) save(state *fakeState) error {
+	if p.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0600)
+}
@@ -0,0 +1,135 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+// useFakeClock 把 fake provider 的时间固定在可手动推进的时钟上
+func useFakeClock(t *testing.T) func(d time.Duration) {
+	t.Helper()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	originalClock, originalDelay := fakeClock, fakeTransitionDelay
+	fakeClock = func() time.Time { return now }
+	fakeTransitionDelay = 10 * time.Second
+	t.Cleanup(func() { fakeClock, fakeTransitionDelay = originalClock, originalDelay })
+
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestFakeProviderTransitions(t *testing.T) {
+	advance := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, filepath.Join(t.TempDir(), "fake.json"))
+
+	provider, err := NewProvider(config.ProviderFake, "ap-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := func() string {
+		t.Helper()
+		instance, err := provider.Describe("lhins-a")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return instance.State
+	}
+
+	if got := state(); got != "RUNNING" {
+		t.Fatalf("expected new instance to be RUNNING, got %v", got)
+	}
+
+	if _, err := provider.Start("lhins-a"); err == nil || !strings.Contains(err.Error(), "RUNNING") {
+		t.Errorf("expected start of running instance to fail, got: %v", err)
+	}
+
+	if _, err := provider.Stop("lhins-a"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got := state(); got != "STOPPING" {
+		t.Errorf("expected STOPPING right after stop, got %v", got)
+	}
+
+	advance(10 * time.Second)
+	if got := state(); got != "STOPPED" {
+		t.Errorf("expected STOPPED after the transition delay, got %v", got)
+	}
+
+	// 使用新的 Provider 验证状态已持久化到文件
+	provider, _ = NewProvider(config.ProviderFake, "ap-test", "")
+	if _, err := provider.Start("lhins-a"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	advance(10 * time.Second)
+	if got := state(); got != "RUNNING" {
+		t.Errorf("expected RUNNING after start, got %v", got)
+	}
+
+	if _, err := provider.Reboot("lhins-a"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if got := state(); got != "REBOOTING" {
+		t.Errorf("expected REBOOTING right after reboot, got %v", got)
+	}
+
+	other, _ := NewProvider(config.ProviderFake, "ap-other", "")
+	if _, err := other.Describe("lhins-b"); err != nil {
+		t.Fatal(err)
+	}
+	instances, err := provider.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 1 || instances[0].InstanceId != "lhins-a" {
+		t.Errorf("expected list to only include instances in the region, got %+v", instances)
+	}
+}
+
+func TestFakeProviderFleetWorkflow(t *testing.T) {
+	advance := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, filepath.Join(t.TempDir(), "fake.json"))
+
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web-1": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web1"},
+			"web-2": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web2"},
+		},
+	})
+
+	if _, err := runCloudCommand(t, "stop", "provider=fake", "--yes"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	out, err := runCloudCommand(t, "status")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Count(out, "STOPPING") != 2 {
+		t.Errorf("expected both instances to be stopping, got:\n%s", out)
+	}
+
+	advance(time.Minute)
+	out, err = runCloudCommand(t, "list")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Count(out, "STOPPED") != 2 || !strings.Contains(out, "fake") {
+		t.Errorf("expected both instances to be stopped, got:\n%s", out)
+	}
+
+	if _, err := os.Stat(os.Getenv(FAKE_CLOUD_ENV)); err != nil {
+		t.Errorf("expected state file to be written: %v", err)
+	}
+}
+
+func TestNewProviderUnknown(t *testing.T) {
+	if _, err := NewProvider("aws", "us-east-1", ""); err == nil || !strings.Contains(err.Error(), "不支持") {
+		t.Errorf("expected error for unknown provider, got: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// FirewallProvider 是支持防火墙规则管理的 Provider 实现的可选接口。
+type FirewallProvider interface {
+	// ListFirewallRules 列出实例的全部防火墙规则
+	ListFirewallRules(instanceId string) ([]config.FirewallRule, error)
+	// AddFirewallRules 为实例添加防火墙规则
+	AddFirewallRules(instanceId string, rules []config.FirewallRule) error
+	// RemoveFirewallRules 删除实例的防火墙规则
+	RemoveFirewallRules(instanceId string, rules []config.FirewallRule) error
+	// ReplaceFirewallRules 按顺序用 rules 替换实例的全部防火墙规则
+	ReplaceFirewallRules(instanceId string, rules []config.FirewallRule) error
+}
+
+// destinationFirewall 返回目标所属 Provider 的防火墙能力，不支持时返回错误
+func destinationFirewall(dest *config.DestinationInstance) (FirewallProvider, error) {
+	provider, err := destinationProvider(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	firewall, ok := provider.(FirewallProvider)
+	if !ok {
+		return nil, configErrorf("provider %v 不支持防火墙管理", providerName(dest))
+	}
+	return firewall, nil
+}
+
+// firewallPlan 是把实例的防火墙规则同步为期望规则所需的变更
+type firewallPlan struct {
+	Add    []config.FirewallRule
+	Remove []config.FirewallRule
+	// Replace 不为 nil 时表示增删规则后顺序仍与期望不同，需要按此顺序替换全部规则
+	Replace []config.FirewallRule
+}
+
+// Empty 表示实例的防火墙规则已与期望一致
+func (plan firewallPlan) Empty() bool {
+	return len(plan.Add) == 0 && len(plan.Remove) == 0 && plan.Replace == nil
+}
+
+// FirewallResult 是对单个目标的防火墙变更及执行结果
+type FirewallResult struct {
+	Destination string                `json:"destination" yaml:"destination"`
+	InstanceId  string                `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	Add         []config.FirewallRule `json:"add,omitempty" yaml:"add,omitempty"`
+	Remove      []config.FirewallRule `json:"remove,omitempty" yaml:"remove,omitempty"`
+	// Replace 是规则顺序与配置不同时替换后的全部规则
+	Replace []config.FirewallRule `json:"replace,omitempty" yaml:"replace,omitempty"`
+	// Applied 表示变更已提交到云平台，--dry-run 或没有变更时为 false
+	Applied bool         `json:"applied" yaml:"applied"`
+	Error   *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// planFirewall 比较当前规则和期望规则，返回需要添加和删除的规则。
+// 规则按协议、端口、来源和策略比较，仅描述不同的规则视为相同。
+// 云平台按顺序匹配规则，如果删除后把新增规则追加到末尾得到的顺序与期望不同，
+// 计划改为按期望顺序替换全部规则。
+func planFirewall(current, desired []config.FirewallRule) firewallPlan {
+	currentKeys := make(map[string]bool, len(current))
+	for _, rule := range current {
+		currentKeys[rule.Key()] = true
+	}
+	desiredKeys := make(map[string]bool, len(desired))
+	for _, rule := range desired {
+		desiredKeys[rule.Key()] = true
+	}
+
+	var plan firewallPlan
+	for _, rule := range desired {
+		if !currentKeys[rule.Key()] {
+			plan.Add = append(plan.Add, rule.Normalize())
+		}
+	}
+	var result []string
+	for _, rule := range current {
+		if !desiredKeys[rule.Key()] {
+			plan.Remove = append(plan.Remove, rule.Normalize())
+			continue
+		}
+		result = append(result, rule.Key())
+	}
+	for _, rule := range plan.Add {
+		result = append(result, rule.Key())
+	}
+
+	if !sameFirewallOrder(result, desired) {
+		plan.Replace = make([]config.FirewallRule, 0, len(desired))
+		for _, rule := range desired {
+			plan.Replace = append(plan.Replace, rule.Normalize())
+		}
+	}
+	return plan
+}
+
+// sameFirewallOrder 判断规则键的顺序是否与期望规则一致
+func sameFirewallOrder(keys []string, desired []config.FirewallRule) bool {
+	if len(keys) != len(desired) {
+		return false
+	}
+	for i, rule := range desired {
+		if keys[i] != rule.Key() {
+			return false
+		}
+	}
+	return true
+}
+
+// applyFirewallPlan 先添加再删除规则，避免同步过程中放行的端口短暂不可用。
+// 顺序需要调整时一次性替换全部规则。
+func applyFirewallPlan(firewall FirewallProvider, instanceId string, plan firewallPlan) error {
+	if plan.Replace != nil {
+		if err := firewall.ReplaceFirewallRules(instanceId, plan.Replace); err != nil {
+			return fmt.Errorf("替换防火墙规则失败: %w", err)
+		}
+		return nil
+	}
+	if len(plan.Add) > 0 {
+		if err := firewall.AddFirewallRules(instanceId, plan.Add); err != nil {
+			return fmt.Errorf("添加防火墙规则失败: %w", err)
+		}
+	}
+	if len(plan.Remove) > 0 {
+		if err := firewall.RemoveFirewallRules(instanceId, plan.Remove); err != nil {
+			return fmt.Errorf("删除防火墙规则失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// renderFirewallPlan 以 +/- 的形式输出目标的防火墙变更
+func renderFirewallPlan(w io.Writer, name string, plan firewallPlan) {
+	if plan.Empty() {
+		fmt.Fprintf(w, "%v: 防火墙规则已是最新\n", name)
+		return
+	}
+
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+		fmt.Fprintf(w, "%v: 调整规则顺序\n", name)
+	} else {
+		fmt.Fprintf(w, "%v: 添加 %d 条，删除 %d 条\n", name, len(plan.Add), len(plan.Remove))
+	}
+	for _, rule := range plan.Add {
+		fmt.Fprintf(w, "  %v\n", green("+ "+rule.String()))
+	}
+	for _, rule := range plan.Remove {
+		fmt.Fprintf(w, "  %v\n", red("- "+rule.String()))
+	}
+	if plan.Replace != nil {
+		fmt.Fprintf(w, "  规则顺序与配置不同，将按以下顺序替换全部 %d 条规则:\n", len(plan.Replace))
+		for i, rule := range plan.Replace {
+			fmt.Fprintf(w, "  %d. %v\n", i+1, rule)
+		}
+	}
+}
+
+// renderFirewallTable 渲染防火墙规则列表
+func renderFirewallTable(w io.Writer, rules []config.FirewallRule) {
+	table := output.NewTable(w)
+	table.Header([]string{"协议", "端口", "来源", "策略", "描述"})
+	for _, rule := range rules {
+		rule = rule.Normalize()
+		action := color.New(color.FgGreen, color.Bold).Sprint(rule.Action)
+		if rule.Action == "DROP" {
+			action = color.New(color.FgRed, color.Bold).Sprint(rule.Action)
+		}
+		_ = table.Append([]string{rule.Protocol, rule.Port, rule.Cidr, action, rule.Description})
+	}
+
+	_ = table.Render()
+}
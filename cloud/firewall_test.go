@@ -0,0 +1,183 @@
+package cloud
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"lucky-go/config"
+)
+
+func TestPlanFirewall(t *testing.T) {
+	current := []config.FirewallRule{
+		{Protocol: "TCP", Port: "22", Cidr: "0.0.0.0/0", Action: "ACCEPT", Description: "Linux SSH"},
+		{Protocol: "TCP", Port: "80", Cidr: "0.0.0.0/0", Action: "ACCEPT"},
+		{Protocol: "ICMP", Port: "ALL", Cidr: "0.0.0.0/0", Action: "ACCEPT"},
+	}
+	desired := []config.FirewallRule{
+		{Protocol: "tcp", Port: "22", Description: "SSH"},
+		{Protocol: "TCP", Port: "443"},
+		{Protocol: "ICMP"},
+	}
+
+	plan := planFirewall(current, desired)
+	if len(plan.Add) != 1 || plan.Add[0].Port != "443" {
+		t.Errorf("expected only port 443 to be added, got %+v", plan.Add)
+	}
+	if len(plan.Remove) != 1 || plan.Remove[0].Port != "80" {
+		t.Errorf("expected only port 80 to be removed, got %+v", plan.Remove)
+	}
+
+	if plan := planFirewall(current, current); !plan.Empty() {
+		t.Errorf("expected no changes for identical rules, got %+v", plan)
+	}
+	// 443 声明在 ICMP 之前，追加到末尾无法得到声明的顺序，因此替换全部规则
+	if len(plan.Replace) != 3 || plan.Replace[1].Port != "443" || plan.Replace[2].Protocol != "ICMP" {
+		t.Errorf("expected the rules to be replaced in declared order, got %+v", plan.Replace)
+	}
+
+	// 新增规则追加在末尾即可得到声明的顺序时只增删规则
+	plan = planFirewall(current, []config.FirewallRule{{Protocol: "TCP", Port: "22"}, {Protocol: "ICMP"}, {Protocol: "TCP", Port: "443"}})
+	if plan.Replace != nil || len(plan.Add) != 1 || len(plan.Remove) != 1 {
+		t.Errorf("expected only port 443 to be added and port 80 removed, got %+v", plan)
+	}
+}
+
+func TestPlanFirewall_Order(t *testing.T) {
+	current := []config.FirewallRule{
+		{Protocol: "TCP", Port: "22", Cidr: "0.0.0.0/0", Action: "ACCEPT"},
+		{Protocol: "TCP", Port: "22", Cidr: "1.2.3.4", Action: "DROP"},
+	}
+	desired := []config.FirewallRule{
+		{Protocol: "TCP", Port: "22", Cidr: "1.2.3.4", Action: "DROP"},
+		{Protocol: "TCP", Port: "22"},
+	}
+
+	plan := planFirewall(current, desired)
+	if plan.Empty() {
+		t.Fatal("expected a plan when only the rule order differs")
+	}
+	if len(plan.Add) != 0 || len(plan.Remove) != 0 {
+		t.Errorf("expected no rules to be added or removed, got %+v", plan)
+	}
+	if len(plan.Replace) != 2 || plan.Replace[0].Action != "DROP" || plan.Replace[1].Action != "ACCEPT" {
+		t.Errorf("expected the rules to be replaced in declared order, got %+v", plan.Replace)
+	}
+
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	provider, err := newFakeProvider("ap-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	firewall := provider.(FirewallProvider)
+	if err := firewall.ReplaceFirewallRules("lhins-web1", current); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyFirewallPlan(firewall, "lhins-web1", plan); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	rules, err := firewall.ListFirewallRules("lhins-web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan := planFirewall(rules, desired); !plan.Empty() {
+		t.Errorf("expected rules to follow the declared order after apply, got %+v", rules)
+	}
+}
+
+func TestFirewallCommands(t *testing.T) {
+	useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	desired := []config.FirewallRule{
+		{Protocol: "TCP", Port: "22", Cidr: "10.0.0.0/8"},
+		{Protocol: "TCP", Port: "80,443"},
+	}
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web-1": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web1", Firewall: desired},
+			"web-2": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web2", Firewall: desired},
+			"db":    {Ssh: "root@1.1.1.3", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-db"},
+		},
+	})
+
+	apply := func(input string, args ...string) (string, error) {
+		cmd := NewCommand()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(append([]string{"firewall", "apply"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := apply("", "--dry-run")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, expected := range []string{"web-1: 添加 2 条，删除 4 条", "+ TCP 22 from 10.0.0.0/8 ACCEPT", "- TCP 22 from 0.0.0.0/0 ACCEPT (Linux SSH)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected plan to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "db") {
+		t.Errorf("expected destinations without firewall to be skipped, got:\n%s", out)
+	}
+
+	if _, err := apply("n\n"); err == nil || !strings.Contains(err.Error(), "已取消") {
+		t.Errorf("expected apply to be cancelled, got: %v", err)
+	}
+
+	// 删除规则需要输入目标名称确认
+	if _, err := apply("y\n", "web-1"); err == nil || !strings.Contains(err.Error(), "已取消") {
+		t.Errorf("expected y to be rejected when rules are removed, got: %v", err)
+	}
+	if out, err := apply("web-1\n", "web-1"); err != nil {
+		t.Fatalf("expected no error, got: %v\n%s", err, out)
+	}
+
+	// 只添加规则时输入 y 即可
+	if _, err := runCloudCommand(t, "firewall", "remove", "web-1", "--port", "80,443", "--yes"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if out, err := apply("y\n", "web-1"); err != nil {
+		t.Fatalf("expected additions to be confirmed with y, got: %v\n%s", err, out)
+	}
+
+	out, err = runCloudCommand(t, "firewall", "list", "web-1")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.Contains(out, "10.0.0.0/8") || strings.Contains(out, "Linux SSH") {
+		t.Errorf("expected rules to match config after apply, got:\n%s", out)
+	}
+
+	out, err = apply("", "--dry-run")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.Contains(out, "web-1: 防火墙规则已是最新") || !strings.Contains(out, "web-2: 添加 2 条") {
+		t.Errorf("expected only web-2 to drift, got:\n%s", out)
+	}
+
+	if _, err := runCloudCommand(t, "firewall", "add", "db", "--port", "3306", "--cidr", "10.0.0.0/8", "--description", "MySQL"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := runCloudCommand(t, "firewall", "add", "db", "--port", "3306", "--cidr", "10.0.0.0/8"); err == nil || !strings.Contains(err.Error(), "已存在") {
+		t.Errorf("expected duplicate rule to be rejected, got: %v", err)
+	}
+	if _, err := runCloudCommand(t, "firewall", "remove", "db", "--port", "3306", "--cidr", "10.0.0.0/8", "--yes"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := runCloudCommand(t, "firewall", "remove", "db", "--port", "3306", "--cidr", "10.0.0.0/8"); err == nil || !strings.Contains(err.Error(), "不存在") {
+		t.Errorf("expected removing a missing rule to fail, got: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// fleetResult 表示对单个目标执行操作的结果
+type fleetResult struct {
+	Name       string
+	InstanceId string
+	Err        error
+	Duration   time.Duration
+}
+
+// runFleet 以最多 parallel 个并发对多个目标执行 fn，结果顺序与 dests 一致
+func runFleet(dests []config.Destination, parallel int, fn func(dest *config.Destination) error) []fleetResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]fleetResult, len(dests))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i := range dests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			err := fn(&dests[i])
+			results[i] = fleetResult{
+				Name:       dests[i].Name,
+				InstanceId: dests[i].InstanceId,
+				Err:        err,
+				Duration:   time.Since(start),
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// fleetError 汇总失败的目标数量，全部成功时返回 nil。
+// 失败目标的退出码相同时沿用该退出码，否则为 EXIT_ERROR。
+func fleetError(action string, results []fleetResult) error {
+	failed, code := 0, 0
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		failed++
+		switch resultCode := ExitCode(result.Err); {
+		case code == 0:
+			code = resultCode
+		case code != resultCode:
+			code = EXIT_ERROR
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+	if len(results) == 1 {
+		return results[0].Err
+	}
+	return &fleetFailure{message: fmt.Sprintf("%d/%d 个目标%v失败", failed, len(results), action), code: code}
+}
+
+// renderFleetTable 渲染每个目标的执行结果表格
+func renderFleetTable(w io.Writer, results []fleetResult) {
+	green := color.New(color.FgGreen, color.Bold).SprintFunc()
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+
+	table := output.NewTable(w)
+	table.Header([]string{"目标", "实例 ID", "结果", "耗时", "错误"})
+	for _, result := range results {
+		status, message := green("成功"), ""
+		if result.Err != nil {
+			status, message = red("失败"), result.Err.Error()
+		}
+		_ = table.Append([]string{
+			result.Name,
+			result.InstanceId,
+			status,
+			result.Duration.Round(time.Millisecond).String(),
+			message,
+		})
+	}
+
+	_ = table.Render()
+}
@@ -0,0 +1,57 @@
+package cloud
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestRunFleet(t *testing.T) {
+	dests := make([]config.Destination, 8)
+	for i := range dests {
+		dests[i] = config.Destination{Name: string(rune('a' + i))}
+	}
+
+	var running, peak int32
+	results := runFleet(dests, 3, func(dest *config.Destination) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		if dest.Name == "c" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent operations, got %d", peak)
+	}
+	for i, result := range results {
+		if result.Name != dests[i].Name {
+			t.Errorf("expected results in input order, got %v at %d", result.Name, i)
+		}
+	}
+
+	err := fleetError("重启", results)
+	if err == nil || !strings.Contains(err.Error(), "1/8") {
+		t.Errorf("expected summary error, got: %v", err)
+	}
+
+	var buf bytes.Buffer
+	renderFleetTable(&buf, results)
+	if !strings.Contains(buf.String(), "boom") {
+		t.Errorf("expected table to include failure message, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,185 @@
+package cloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	lighthouse "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/lighthouse/v20200324"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// mutationFlags 是 cloud 命令的 --dry-run、--yes 和 --force 全局标志
+type mutationFlags struct {
+	// DryRun 只显示将要发送的 API 调用，不发送任何请求
+	DryRun bool
+	// Yes 跳过确认
+	Yes bool
+	// Force 允许对受保护的目标执行破坏性操作
+	Force bool
+}
+
+// loadMutationFlags 读取命令的全局标志，未定义的标志视为 false
+func loadMutationFlags(cmd *cobra.Command) mutationFlags {
+	enabled := func(name string) bool {
+		flag := cmd.Flag(name)
+		return flag != nil && flag.Value.String() == "true"
+	}
+	return mutationFlags{DryRun: enabled("dry-run"), Yes: enabled("yes"), Force: enabled("force")}
+}
+
+// requireUnprotected 在目标受保护且没有使用 --force 时返回错误
+func requireUnprotected(dests []config.Destination, action string, flags mutationFlags) error {
+	if flags.Force {
+		return nil
+	}
+
+	var protected []string
+	for _, dest := range dests {
+		if dest.Protected {
+			protected = append(protected, dest.Name)
+		}
+	}
+	if len(protected) == 0 {
+		return nil
+	}
+	return protectedErrorf("目标 %v 受保护（protected: true），%v需要使用 --force", strings.Join(protected, "、"), action)
+}
+
+// guardDestructive 检查受保护的目标，并要求输入目标名称确认破坏性操作。
+// 使用 --yes 或 --dry-run 时跳过确认。
+func guardDestructive(cmd *cobra.Command, out io.Writer, dests []config.Destination, action string, flags mutationFlags) error {
+	if err := requireUnprotected(dests, action, flags); err != nil {
+		return err
+	}
+	if flags.Yes || flags.DryRun {
+		return nil
+	}
+	return confirmDestinations(cmd.InOrStdin(), out, dests, action)
+}
+
+// confirmDestinations 要求输入目标名称确认，多个目标时输入目标数量
+func confirmDestinations(in io.Reader, out io.Writer, dests []config.Destination, action string) error {
+	var expected string
+	if len(dests) == 1 {
+		dest := dests[0]
+		expected = dest.Name
+		fmt.Fprintf(out, "即将%v %v（%v）。请输入目标名称 %v 确认: ", action, dest.Name, dest.InstanceId, dest.Name)
+	} else {
+		names := make([]string, len(dests))
+		for i, dest := range dests {
+			names[i] = dest.Name
+		}
+		expected = strconv.Itoa(len(dests))
+		fmt.Fprintf(out, "即将%v以下 %d 个目标: %v\n请输入目标数量 %v 确认: ", action, len(dests), strings.Join(names, "、"), expected)
+	}
+
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != expected {
+		return fmt.Errorf("输入与 %v 不符，已取消%v", expected, action)
+	}
+	return nil
+}
+
+// apiCall 是 --dry-run 时显示的一次云平台 API 调用，Params 与实际发送的参数一致
+type apiCall struct {
+	Destination string         `json:"destination" yaml:"destination"`
+	Provider    string         `json:"provider" yaml:"provider"`
+	Region      string         `json:"region" yaml:"region"`
+	Action      string         `json:"action" yaml:"action"`
+	Params      map[string]any `json:"params" yaml:"params"`
+}
+
+// String 返回调用的单行描述，参数为 JSON
+func (call apiCall) String() string {
+	params, _ := json.Marshal(call.Params)
+	return fmt.Sprintf("%v %v %v %s", call.Provider, call.Region, call.Action, params)
+}
+
+// newAPICall 创建目标的 API 调用，params 可以是 SDK 请求结构或参数 map
+func newAPICall(dest *config.Destination, action string, params any) apiCall {
+	call := apiCall{Destination: dest.Name, Provider: providerName(&dest.DestinationInstance), Region: dest.Region, Action: action}
+
+	// 统一转换为 map，使 SDK 请求结构在 JSON 和 YAML 中都使用 API 的参数名
+	data, _ := json.Marshal(params)
+	_ = json.Unmarshal(data, &call.Params)
+	return call
+}
+
+// powerCall 返回开关机类操作的 API 调用，各 provider 的参数相同
+func powerCall(dest *config.Destination, action string) apiCall {
+	return newAPICall(dest, action, map[string]any{"InstanceIds": []string{dest.InstanceId}})
+}
+
+// createSnapshotCall 返回创建快照的 API 调用
+func createSnapshotCall(dest *config.Destination, name string) apiCall {
+	return newAPICall(dest, "CreateInstanceSnapshot", &lighthouse.CreateInstanceSnapshotRequest{
+		InstanceId:   &dest.InstanceId,
+		SnapshotName: &name,
+	})
+}
+
+// deleteSnapshotCall 返回删除快照的 API 调用
+func deleteSnapshotCall(dest *config.Destination, snapshotId string) apiCall {
+	return newAPICall(dest, "DeleteSnapshots", &lighthouse.DeleteSnapshotsRequest{
+		SnapshotIds: []*string{&snapshotId},
+	})
+}
+
+// restoreSnapshotCall 返回回滚快照的 API 调用
+func restoreSnapshotCall(dest *config.Destination, snapshotId string) apiCall {
+	return newAPICall(dest, "ApplyInstanceSnapshot", &lighthouse.ApplyInstanceSnapshotRequest{
+		InstanceId: &dest.InstanceId,
+		SnapshotId: &snapshotId,
+	})
+}
+
+// firewallCalls 返回应用防火墙变更的 API 调用，顺序与 applyFirewallPlan 一致：先添加后删除
+func firewallCalls(dest *config.Destination, plan firewallPlan) []apiCall {
+	if plan.Replace != nil {
+		return []apiCall{newAPICall(dest, "ModifyFirewallRules", &lighthouse.ModifyFirewallRulesRequest{
+			InstanceId:    &dest.InstanceId,
+			FirewallRules: lighthouseFirewallRules(plan.Replace),
+		})}
+	}
+
+	var calls []apiCall
+	if len(plan.Add) > 0 {
+		calls = append(calls, newAPICall(dest, "CreateFirewallRules", &lighthouse.CreateFirewallRulesRequest{
+			InstanceId:    &dest.InstanceId,
+			FirewallRules: lighthouseFirewallRules(plan.Add),
+		}))
+	}
+	if len(plan.Remove) > 0 {
+		calls = append(calls, newAPICall(dest, "DeleteFirewallRules", &lighthouse.DeleteFirewallRulesRequest{
+			InstanceId:    &dest.InstanceId,
+			FirewallRules: lighthouseFirewallRules(plan.Remove),
+		}))
+	}
+	return calls
+}
+
+// writeDryRun 输出 --dry-run 时将要发送的 API 调用
+func writeDryRun(w io.Writer, format string, calls []apiCall) error {
+	if format != output.Table {
+		if calls == nil {
+			calls = []apiCall{}
+		}
+		return output.Write(w, format, calls)
+	}
+
+	if len(calls) == 0 {
+		fmt.Fprintln(w, "[dry-run] 没有需要发送的 API 调用")
+		return nil
+	}
+	for _, call := range calls {
+		fmt.Fprintf(w, "[dry-run] %v: %v\n", call.Destination, call)
+	}
+	return nil
+}
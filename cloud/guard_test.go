@@ -0,0 +1,170 @@
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"lucky-go/config"
+)
+
+func TestConfirmDestinations(t *testing.T) {
+	one := []config.Destination{{Name: "web-1"}}
+	two := []config.Destination{{Name: "web-1"}, {Name: "web-2"}}
+
+	tests := []struct {
+		name   string
+		dests  []config.Destination
+		input  string
+		accept bool
+	}{
+		{name: "SingleName", dests: one, input: "web-1\n", accept: true},
+		{name: "SingleYes", dests: one, input: "y\n", accept: false},
+		{name: "SingleWrongName", dests: one, input: "web-2\n", accept: false},
+		{name: "SingleEOF", dests: one, input: "", accept: false},
+		{name: "MultipleCount", dests: two, input: "2\n", accept: true},
+		{name: "MultipleName", dests: two, input: "web-1\n", accept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := confirmDestinations(strings.NewReader(tt.input), out, tt.dests, "重启")
+			if (err == nil) != tt.accept {
+				t.Errorf("expected accept=%v, got: %v", tt.accept, err)
+			}
+			if !strings.Contains(out.String(), "web-1") {
+				t.Errorf("expected prompt to list destinations, got: %q", out.String())
+			}
+		})
+	}
+}
+
+func TestDestructiveGuards(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"prod": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-prod", Protected: true},
+			"dev":  {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-dev"},
+		},
+	})
+	advance := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	run := func(input string, args ...string) (string, error) {
+		cmd := NewCommand()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+	state := func(instanceId string) string {
+		t.Helper()
+		instance, err := DescribeInstance(&config.DestinationInstance{Provider: config.ProviderFake, Region: "ap-test", InstanceId: instanceId})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return instance.State
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		out, err := run("", "stop", "dev", "--dry-run")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(out, `[dry-run] dev: fake ap-test StopInstances {"InstanceIds":["lhins-dev"]}`) {
+			t.Errorf("expected the API call to be printed, got:\n%s", out)
+		}
+		if got := state("lhins-dev"); got != "RUNNING" {
+			t.Errorf("expected dry run to leave the instance RUNNING, got %v", got)
+		}
+	})
+
+	t.Run("DryRunJSON", func(t *testing.T) {
+		_, _, err := runCloudCommandSplit(t, "snapshot", "restore", "dev", "lhsnap-1", "--dry-run", "-o", "json")
+		if err == nil || !strings.Contains(err.Error(), "不存在快照") {
+			t.Fatalf("expected unknown snapshot to be rejected before the dry run, got: %v", err)
+		}
+
+		snapshotId, err := fakeCreateSnapshot(t, "dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout, _, err := runCloudCommandSplit(t, "snapshot", "restore", "dev", snapshotId, "--dry-run", "-o", "json")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		var calls []apiCall
+		if err := json.Unmarshal([]byte(stdout), &calls); err != nil {
+			t.Fatalf("expected JSON on stdout, got %q: %v", stdout, err)
+		}
+		if len(calls) != 1 || calls[0].Action != "ApplyInstanceSnapshot" || calls[0].Params["InstanceId"] != "lhins-dev" || calls[0].Params["SnapshotId"] != snapshotId {
+			t.Errorf("unexpected calls: %+v", calls)
+		}
+	})
+
+	t.Run("TypeNameToConfirm", func(t *testing.T) {
+		if _, err := run("y\n", "reboot", "dev"); err == nil || !strings.Contains(err.Error(), "已取消") {
+			t.Errorf("expected reboot to be cancelled, got: %v", err)
+		}
+		if out, err := run("dev\n", "reboot", "dev"); err != nil || !strings.Contains(out, "已提交重启请求") {
+			t.Errorf("expected reboot to be submitted, got: %v\n%s", err, out)
+		}
+		advance(fakeTransitionDelay)
+	})
+
+	t.Run("StartNeedsNoConfirmation", func(t *testing.T) {
+		if _, err := run("", "stop", "dev", "--yes"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		advance(fakeTransitionDelay)
+		if _, err := run("", "start", "dev"); err != nil {
+			t.Errorf("expected start to run without confirmation, got: %v", err)
+		}
+		advance(fakeTransitionDelay)
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		_, err := run("", "reboot", "@all-prod", "--yes")
+		if ExitCode(err) != EXIT_NOT_FOUND {
+			t.Errorf("expected unknown group to be not found, got: %v", err)
+		}
+
+		_, err = run("prod\n", "reboot", "prod")
+		if ExitCode(err) != EXIT_PROTECTED || !strings.Contains(err.Error(), "--force") {
+			t.Errorf("expected protected error, got: %v", err)
+		}
+		if _, err := run("", "stop", "*", "--yes"); ExitCode(err) != EXIT_PROTECTED {
+			t.Errorf("expected a selector including a protected target to be refused, got: %v", err)
+		}
+		if got := state("lhins-prod"); got != "RUNNING" {
+			t.Errorf("expected protected instance to stay RUNNING, got %v", got)
+		}
+
+		if _, err := run("", "reboot", "prod", "--yes", "--force"); err != nil {
+			t.Errorf("expected --force to allow the reboot, got: %v", err)
+		}
+	})
+}
+
+// fakeCreateSnapshot 为 fake 实例创建快照并返回快照 ID
+func fakeCreateSnapshot(t *testing.T, name string) (string, error) {
+	t.Helper()
+
+	dest, err := config.LoadDestination(name)
+	if err != nil {
+		return "", err
+	}
+	snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+	if err != nil {
+		return "", err
+	}
+	return snapshots.CreateSnapshot(dest.InstanceId, "manual")
+}
@@ -0,0 +1,170 @@
+package cloud
+
+import (
+	"fmt"
+	"time"
+
+	"lucky-go/config"
+)
+
+// 定义函数变量，用于在测试中模拟
+var (
+	startInstanceFunc    = defaultStartInstance
+	stopInstanceFunc     = defaultStopInstance
+	describeInstanceFunc = defaultDescribeInstance
+	listInstancesFunc    = defaultListInstances
+	timeNow              = time.Now
+)
+
+// Instance 表示云平台上实例的当前状态。
+type Instance struct {
+	// InstanceId 是实例的唯一标识符
+	InstanceId string `json:"instance-id" yaml:"instance-id"`
+	// Name 是实例在云平台上的名称
+	Name string `json:"name" yaml:"name"`
+	// Provider 是实例所属的云平台
+	Provider string `json:"provider" yaml:"provider"`
+	// Region 和 Zone 是实例所在的地域和可用区
+	Region string `json:"region" yaml:"region"`
+	Zone   string `json:"zone,omitempty" yaml:"zone,omitempty"`
+	// State 是实例状态，如 RUNNING、STOPPED、STARTING
+	State string `json:"state" yaml:"state"`
+	// PublicIPs 和 PrivateIPs 是实例的公网和内网地址
+	PublicIPs  []string `json:"public-ips,omitempty" yaml:"public-ips,omitempty"`
+	PrivateIPs []string `json:"private-ips,omitempty" yaml:"private-ips,omitempty"`
+	// BundleId 是实例的套餐
+	BundleId string `json:"bundle-id,omitempty" yaml:"bundle-id,omitempty"`
+	// CPU 是核数，Memory 的单位为 GB
+	CPU    int64 `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory int64 `json:"memory,omitempty" yaml:"memory,omitempty"`
+	// OsName 是操作系统名称
+	OsName string `json:"os,omitempty" yaml:"os,omitempty"`
+	// CreatedTime 和 ExpiredTime 是 ISO 8601 格式的创建和到期时间
+	CreatedTime string `json:"created-time,omitempty" yaml:"created-time,omitempty"`
+	ExpiredTime string `json:"expired-time,omitempty" yaml:"expired-time,omitempty"`
+	// RenewFlag 是自动续费标识
+	RenewFlag string `json:"renew-flag,omitempty" yaml:"renew-flag,omitempty"`
+	// Tags 是实例在云平台上的标签
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Traffic 是当前周期的流量包，不支持流量包的实例为空
+	Traffic *Traffic `json:"traffic,omitempty" yaml:"traffic,omitempty"`
+}
+
+// Traffic 表示实例当前周期的流量包使用情况，单位为字节。
+type Traffic struct {
+	Used      int64  `json:"used" yaml:"used"`
+	Total     int64  `json:"total" yaml:"total"`
+	Remaining int64  `json:"remaining" yaml:"remaining"`
+	Overflow  int64  `json:"overflow,omitempty" yaml:"overflow,omitempty"`
+	StartTime string `json:"start-time,omitempty" yaml:"start-time,omitempty"`
+	EndTime   string `json:"end-time,omitempty" yaml:"end-time,omitempty"`
+}
+
+// StartInstance 开机指定的目标实例，返回云平台的请求 ID。
+func StartInstance(dest *config.DestinationInstance) (string, error) {
+	return startInstanceFunc(dest)
+}
+
+// StopInstance 关机指定的目标实例，返回云平台的请求 ID。
+func StopInstance(dest *config.DestinationInstance) (string, error) {
+	return stopInstanceFunc(dest)
+}
+
+// DescribeInstance 查询目标实例的状态、地址、套餐、到期时间和流量。
+func DescribeInstance(dest *config.DestinationInstance) (*Instance, error) {
+	return describeInstanceFunc(dest)
+}
+
+// ListInstances 列出账号在云平台地域内的全部实例，包括未写入配置的实例。
+// account 为空时使用默认凭证。
+func ListInstances(provider, region, account string) ([]Instance, error) {
+	return listInstancesFunc(provider, region, account)
+}
+
+// defaultStartInstance 是 StartInstance 的默认实现
+func defaultStartInstance(dest *config.DestinationInstance) (string, error) {
+	provider, err := destinationProvider(dest)
+	if err != nil {
+		return "", err
+	}
+	return provider.Start(dest.InstanceId)
+}
+
+// defaultStopInstance 是 StopInstance 的默认实现
+func defaultStopInstance(dest *config.DestinationInstance) (string, error) {
+	provider, err := destinationProvider(dest)
+	if err != nil {
+		return "", err
+	}
+	return provider.Stop(dest.InstanceId)
+}
+
+// defaultDescribeInstance 是 DescribeInstance 的默认实现
+func defaultDescribeInstance(dest *config.DestinationInstance) (*Instance, error) {
+	provider, err := destinationProvider(dest)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Describe(dest.InstanceId)
+}
+
+// defaultListInstances 是 ListInstances 的默认实现
+func defaultListInstances(name, region, account string) ([]Instance, error) {
+	provider, err := NewProvider(name, region, account)
+	if err != nil {
+		return nil, err
+	}
+	return provider.List()
+}
+
+// requireInstance 校验目标是否配置了云操作所需的 region 和 instance-id
+func requireInstance(dest *config.Destination) error {
+	if dest.Region == "" || dest.InstanceId == "" {
+		return configErrorf("目标 %v 未配置 region 和 instance-id", dest.Name)
+	}
+	return nil
+}
+
+// formatBytes 以 1024 为进制把字节数格式化为易读的字符串
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", value, "KMGTP"[exp])
+}
+
+// formatTraffic 把流量包格式化为 "已用 / 总量 (百分比)"
+func formatTraffic(traffic *Traffic) string {
+	if traffic == nil || traffic.Total == 0 {
+		return "-"
+	}
+
+	percent := float64(traffic.Used) / float64(traffic.Total) * 100
+	return fmt.Sprintf("%v / %v (%.0f%%)", formatBytes(traffic.Used), formatBytes(traffic.Total), percent)
+}
+
+// formatExpiry 把到期时间格式化为日期和剩余天数，无法解析时原样返回
+func formatExpiry(expiredTime string) string {
+	if expiredTime == "" {
+		return "-"
+	}
+
+	t, err := time.Parse(time.RFC3339, expiredTime)
+	if err != nil {
+		return expiredTime
+	}
+
+	days := int(t.Sub(timeNow()).Hours() / 24)
+	if days < 0 {
+		return fmt.Sprintf("%v (已过期)", t.Local().Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%v (剩 %d 天)", t.Local().Format("2006-01-02"), days)
+}
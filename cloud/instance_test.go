@@ -0,0 +1,217 @@
+package cloud
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setupCloudConfig 在临时 HOME 中写入测试配置
+func setupCloudConfig(t *testing.T, testConfig config.Config) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	t.Setenv("USERPROFILE", tempDir)
+	t.Setenv(config.CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	testConfig.Version = config.CURRENT_VERSION
+	data, err := yaml.Marshal(testConfig)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+
+	configDir := filepath.Join(tempDir, config.CONFIG_DIR)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, config.CONFIG_FILE), data, 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+// runCloudCommand 以给定参数执行 cloud 命令并返回输出
+func runCloudCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := NewCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestLifecycleCommands(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web-1": {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web1", Tags: []string{"web"}},
+			"web-2": {Ssh: "root@1.1.1.2", Region: "ap-tokyo", InstanceId: "lhins-web2", Tags: []string{"web"}},
+			"local": {Ssh: "root@127.0.0.1"},
+		},
+	})
+
+	originalStart, originalStop := startInstanceFunc, stopInstanceFunc
+	originalDescribe, originalList := describeInstanceFunc, listInstancesFunc
+	originalNow := timeNow
+	defer func() {
+		startInstanceFunc, stopInstanceFunc = originalStart, originalStop
+		describeInstanceFunc, listInstancesFunc = originalDescribe, originalList
+		timeNow = originalNow
+	}()
+	timeNow = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("StartAndStop", func(t *testing.T) {
+		var mu sync.Mutex
+		var started, stopped []string
+		startInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			started = append(started, dest.InstanceId)
+			return "", nil
+		}
+		stopInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, dest.InstanceId)
+			return "", nil
+		}
+
+		if _, err := runCloudCommand(t, "start", "web-1"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		out, err := runCloudCommand(t, "stop", "tag=web", "--yes")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if len(started) != 1 || started[0] != "lhins-web1" {
+			t.Errorf("expected web-1 to be started, got %v", started)
+		}
+		if len(stopped) != 2 {
+			t.Errorf("expected both web hosts to be stopped, got %v", stopped)
+		}
+		if !strings.Contains(out, "web-2") {
+			t.Errorf("expected per-host result table, got:\n%s", out)
+		}
+	})
+
+	t.Run("MissingInstanceId", func(t *testing.T) {
+		if _, err := runCloudCommand(t, "start", "local"); err == nil || !strings.Contains(err.Error(), "instance-id") {
+			t.Errorf("expected error about missing instance-id, got: %v", err)
+		}
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		describeInstanceFunc = func(dest *config.DestinationInstance) (*Instance, error) {
+			if dest.InstanceId == "lhins-web2" {
+				return nil, errors.New("quota exceeded")
+			}
+			return &Instance{
+				InstanceId:  dest.InstanceId,
+				State:       "RUNNING",
+				PublicIPs:   []string{"1.1.1.1"},
+				BundleId:    "bundle_starter",
+				ExpiredTime: "2026-01-31T00:00:00Z",
+				Traffic:     &Traffic{Used: 256 << 30, Total: 1024 << 30},
+			}, nil
+		}
+
+		out, err := runCloudCommand(t, "status")
+		if err == nil {
+			t.Error("expected error when one destination fails, got nil")
+		}
+
+		for _, expected := range []string{"web-1", "RUNNING", "1.1.1.1", "bundle_starter", "剩 30 天", "256.0 GB / 1.0 TB (25%)", "quota exceeded"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected status output to contain %q, got:\n%s", expected, out)
+			}
+		}
+		if strings.Contains(out, "local") {
+			t.Errorf("expected destinations without instance-id to be skipped, got:\n%s", out)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		var regions []string
+		listInstancesFunc = func(provider, region, account string) ([]Instance, error) {
+			if provider != config.ProviderLighthouse {
+				t.Errorf("expected default provider, got %v", provider)
+			}
+			regions = append(regions, region)
+			return []Instance{
+				{InstanceId: "lhins-web1", Name: "web", Region: region, State: "STOPPED"},
+				{InstanceId: "lhins-other", Name: "unmanaged", Region: region, State: "RUNNING"},
+			}, nil
+		}
+
+		out, err := runCloudCommand(t, "list")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if strings.Join(regions, ",") != "ap-hongkong,ap-tokyo" {
+			t.Errorf("expected regions from config, got %v", regions)
+		}
+		if !strings.Contains(out, "unmanaged") || !strings.Contains(out, "web-1") {
+			t.Errorf("expected both configured and unmanaged instances, got:\n%s", out)
+		}
+	})
+
+	t.Run("Describe", func(t *testing.T) {
+		describeInstanceFunc = func(dest *config.DestinationInstance) (*Instance, error) {
+			return &Instance{InstanceId: dest.InstanceId, Region: dest.Region, State: "RUNNING", CPU: 2, Memory: 4}, nil
+		}
+
+		out, err := runCloudCommand(t, "describe", "web-1")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(out, "instance-id: lhins-web1") || !strings.Contains(out, "memory: 4") {
+			t.Errorf("unexpected describe output:\n%s", out)
+		}
+
+		if _, err := runCloudCommand(t, "describe", "web-*"); err == nil {
+			t.Error("expected error when selector matches multiple destinations, got nil")
+		}
+	})
+
+	t.Run("ListBrokenConfig", func(t *testing.T) {
+		home, _ := os.UserHomeDir()
+		if err := os.WriteFile(filepath.Join(home, config.CONFIG_DIR, config.CONFIG_FILE), []byte("dest: [\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// 配置无法读取时报错，而不是列出没有目标名称的实例
+		if out, err := runCloudCommand(t, "list", "--region", "ap-hongkong"); err == nil {
+			t.Errorf("expected a config error, got:\n%s", out)
+		}
+	})
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:        "0 B",
+		1023:     "1023 B",
+		1536:     "1.5 KB",
+		20 << 30: "20.0 GB",
+		3 << 40:  "3.0 TB",
+	}
+
+	for n, expected := range tests {
+		if got := formatBytes(n); got != expected {
+			t.Errorf("formatBytes(%d): expected %q, got %q", n, expected, got)
+		}
+	}
+}
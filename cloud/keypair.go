@@ -0,0 +1,204 @@
+package cloud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"lucky-go/config"
+)
+
+// maxKeyPairName 是轻量应用服务器密钥对名称的最大长度
+const maxKeyPairName = 25
+
+// keyPairNameInvalid 匹配密钥对名称中不允许的字符，名称只能包含字母、数字和下划线
+var keyPairNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// KeyPairProvider 是支持 SSH 密钥对管理的 Provider 实现的可选接口。
+// 密钥对属于地域，与 Provider 的地域相同。
+type KeyPairProvider interface {
+	// ListKeyPairs 列出地域内的全部密钥对
+	ListKeyPairs() ([]KeyPair, error)
+	// CreateKeyPair 创建密钥对，返回密钥对和私钥，私钥只在创建时返回一次
+	CreateKeyPair(name string) (*KeyPair, string, error)
+	// ImportKeyPair 导入公钥，返回密钥对 ID
+	ImportKeyPair(name, publicKey string) (string, error)
+	// AssociateKeyPairs 把密钥对绑定到实例，运行中的实例会先关机再绑定
+	AssociateKeyPairs(instanceId string, keyIds []string) error
+	// DisassociateKeyPairs 解除密钥对与实例的绑定
+	DisassociateKeyPairs(instanceId string, keyIds []string) error
+}
+
+// KeyPair 是地域内的一个 SSH 密钥对
+type KeyPair struct {
+	KeyId     string `json:"key-id" yaml:"key-id"`
+	KeyName   string `json:"key-name" yaml:"key-name"`
+	PublicKey string `json:"public-key,omitempty" yaml:"public-key,omitempty"`
+	// AssociatedInstanceIds 是绑定了该密钥对的实例
+	AssociatedInstanceIds []string `json:"associated-instance-ids,omitempty" yaml:"associated-instance-ids,omitempty"`
+	CreatedTime           string   `json:"created-time,omitempty" yaml:"created-time,omitempty"`
+}
+
+// KeyPairEntry 是 cloud keypair list 输出的密钥对，Destinations 是绑定了该密钥对的已配置目标
+type KeyPairEntry struct {
+	Provider     string   `json:"provider" yaml:"provider"`
+	Region       string   `json:"region" yaml:"region"`
+	Destinations []string `json:"destinations,omitempty" yaml:"destinations,omitempty"`
+	KeyPair      `yaml:",inline"`
+	// PrivateKeyFile 是 create 保存私钥的路径
+	PrivateKeyFile string `json:"private-key-file,omitempty" yaml:"private-key-file,omitempty"`
+}
+
+// KeyPairResult 是绑定或解绑密钥对的结果
+type KeyPairResult struct {
+	Destination string   `json:"destination" yaml:"destination"`
+	InstanceId  string   `json:"instance-id" yaml:"instance-id"`
+	KeyIds      []string `json:"key-ids" yaml:"key-ids"`
+	// Imported 表示 --from 的公钥在地域内不存在，已导入为新的密钥对
+	Imported bool `json:"imported,omitempty" yaml:"imported,omitempty"`
+	// IdentityFile 是写入配置的 SSH 私钥路径，没有更新时为空
+	IdentityFile string `json:"identity-file,omitempty" yaml:"identity-file,omitempty"`
+}
+
+// keyPairProvider 返回指定云平台、地域和账号的密钥对管理能力，不支持时返回错误
+func keyPairProvider(name, region, account string) (KeyPairProvider, error) {
+	provider, err := NewProvider(name, region, account)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPairs, ok := provider.(KeyPairProvider)
+	if !ok {
+		if name == "" {
+			name = config.ProviderLighthouse
+		}
+		return nil, configErrorf("provider %v 不支持密钥对管理", name)
+	}
+	return keyPairs, nil
+}
+
+// destinationKeyPairs 返回目标所属 Provider 的密钥对管理能力，不支持时返回错误
+func destinationKeyPairs(dest *config.DestinationInstance) (KeyPairProvider, error) {
+	return keyPairProvider(dest.Provider, dest.Region, dest.Account)
+}
+
+// parsePublicKey 解析 authorized_keys 格式的公钥，返回去掉注释的规范形式（类型和 base64）及注释
+func parsePublicKey(data []byte) (string, string, error) {
+	key, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return "", "", fmt.Errorf("解析公钥失败: %w", err)
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))), comment, nil
+}
+
+// readPublicKey 读取公钥文件，路径开头的 ~ 展开为用户主目录
+func readPublicKey(path string) (string, string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey, comment, err := parsePublicKey(data)
+	if err != nil {
+		return "", "", fmt.Errorf("%v: %w", path, err)
+	}
+	return publicKey, comment, nil
+}
+
+// expandHome 把路径开头的 ~ 展开为用户主目录
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path[1:], "/")), nil
+}
+
+// findKeyPair 返回公钥相同的密钥对，比较时忽略注释
+func findKeyPair(keyPairs []KeyPair, publicKey string) *KeyPair {
+	for i, keyPair := range keyPairs {
+		normalized, _, err := parsePublicKey([]byte(keyPair.PublicKey))
+		if err == nil && normalized == publicKey {
+			return &keyPairs[i]
+		}
+	}
+	return nil
+}
+
+// findKeyPairById 返回指定 ID 的密钥对
+func findKeyPairById(keyPairs []KeyPair, keyId string) *KeyPair {
+	for i := range keyPairs {
+		if keyPairs[i].KeyId == keyId {
+			return &keyPairs[i]
+		}
+	}
+	return nil
+}
+
+// importKeyPairName 为导入的公钥生成密钥对名称：公钥注释（如 alice@laptop）加上指纹前缀，
+// 只保留字母、数字和下划线，不超过 25 个字符。同一公钥总是得到相同的名称。
+func importKeyPairName(publicKey, comment string) string {
+	sum := sha256.Sum256([]byte(publicKey))
+	suffix := hex.EncodeToString(sum[:])[:8]
+
+	base := strings.Trim(keyPairNameInvalid.ReplaceAllString(comment, "_"), "_")
+	if base == "" {
+		base = "lucky_go"
+	}
+	if limit := maxKeyPairName - len(suffix) - 1; len(base) > limit {
+		base = strings.TrimRight(base[:limit], "_")
+	}
+	return base + "_" + suffix
+}
+
+// validateKeyPairName 检查密钥对名称是否符合轻量应用服务器的要求
+func validateKeyPairName(name string) error {
+	if name == "" || len(name) > maxKeyPairName || keyPairNameInvalid.MatchString(name) {
+		return fmt.Errorf("密钥对名称 %q 不正确，只能包含字母、数字和下划线，不超过 %d 个字符", name, maxKeyPairName)
+	}
+	return nil
+}
+
+// identityFileFor 返回公钥文件对应的私钥路径（去掉 .pub 后缀），私钥文件不存在时返回空
+func identityFileFor(publicKeyPath string) string {
+	if !strings.HasSuffix(publicKeyPath, ".pub") {
+		return ""
+	}
+
+	identityFile := strings.TrimSuffix(publicKeyPath, ".pub")
+	path, err := expandHome(identityFile)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return identityFile
+}
+
+// setIdentityFile 把目标的 SSH 私钥路径写入配置
+func setIdentityFile(name, identityFile string) error {
+	return config.Update(func(cfg *config.Config) error {
+		dest, ok := cfg.Dest[name]
+		if !ok {
+			return fmt.Errorf("目标 %v 不在配置中", name)
+		}
+		dest.IdentityFile = identityFile
+		cfg.Dest[name] = dest
+		return nil
+	})
+}
@@ -0,0 +1,188 @@
+package cloud
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"lucky-go/config"
+)
+
+// writeTestKey 在 dir 下生成 ed25519 公钥文件和（占位的）私钥文件，返回公钥文件路径和规范形式的公钥
+func writeTestKey(t *testing.T, dir, comment string) (string, string) {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+
+	path := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(path, []byte("private"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(publicKey+" "+comment+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path + ".pub", publicKey
+}
+
+func TestImportKeyPairName(t *testing.T) {
+	tests := []struct {
+		comment, prefix string
+	}{
+		{"alice@laptop", "alice_laptop_"},
+		{"", "lucky_go_"},
+		{"@@@", "lucky_go_"},
+		{"a.very-long.comment@some.host.example", "a_very_long_comm_"},
+	}
+
+	for _, tt := range tests {
+		name := importKeyPairName("ssh-ed25519 AAAA", tt.comment)
+		if !strings.HasPrefix(name, tt.prefix) || validateKeyPairName(name) != nil {
+			t.Errorf("importKeyPairName(%q) = %q, want a valid name starting with %q", tt.comment, name, tt.prefix)
+		}
+	}
+
+	if importKeyPairName("ssh-ed25519 AAAA", "x") == importKeyPairName("ssh-ed25519 BBBB", "x") {
+		t.Error("expected different keys to get different names")
+	}
+}
+
+func TestKeyPairCommands(t *testing.T) {
+	useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web":   {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web"},
+			"db":    {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-db", Protected: true},
+			"batch": {Ssh: "root@1.1.1.3", Provider: config.ProviderCVM, Region: "ap-test", InstanceId: "ins-batch"},
+		},
+	})
+	// cvm 目标创建 Provider 时需要凭证
+	t.Setenv("TENCENT_CLOUD_SECRET_ID", "id")
+	t.Setenv("TENCENT_CLOUD_SECRET_KEY", "key")
+	from, publicKey := writeTestKey(t, t.TempDir(), "alice@laptop")
+
+	t.Run("BindFrom", func(t *testing.T) {
+		out, err := runCloudCommand(t, "keypair", "bind", "web", "--from", from, "--yes")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+		if !strings.Contains(out, "已导入密钥对") || !strings.Contains(out, "web: 已绑定密钥对") {
+			t.Errorf("expected key to be imported and bound, got:\n%s", out)
+		}
+
+		dest, err := config.LoadDestination("web")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dest.IdentityFile != strings.TrimSuffix(from, ".pub") {
+			t.Errorf("expected identity-file to be updated, got %q", dest.IdentityFile)
+		}
+	})
+
+	t.Run("BindAgain", func(t *testing.T) {
+		out, err := runCloudCommand(t, "keypair", "bind", "web", "--from", from)
+		if err != nil || !strings.Contains(out, "无需重复绑定") || strings.Contains(out, "已导入") {
+			t.Errorf("expected existing key to be reused without confirmation, got: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		stdout, _, err := runCloudCommandSplit(t, "keypair", "list", "-o", "json")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		var entries []KeyPairEntry
+		if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		if len(entries) != 1 || entries[0].PublicKey != publicKey || strings.Join(entries[0].Destinations, ",") != "web" {
+			t.Errorf("expected the imported key bound to web, got %+v", entries)
+		}
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		_, err := runCloudCommand(t, "keypair", "bind", "db", "--from", from, "--yes")
+		if ExitCode(err) != EXIT_PROTECTED {
+			t.Errorf("expected protected destination to be refused, got: %v", err)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		out, err := runCloudCommand(t, "keypair", "bind", "db", "--from", from, "--force", "--dry-run")
+		if err != nil || !strings.Contains(out, "AssociateInstancesKeyPairs") || strings.Contains(out, "ImportKeyPair") {
+			t.Errorf("expected only the association in dry run, got: %v\n%s", err, out)
+		}
+
+		dest, _ := config.LoadDestination("db")
+		if dest.IdentityFile != "" {
+			t.Errorf("expected dry run to leave the config unchanged, got %q", dest.IdentityFile)
+		}
+	})
+
+	t.Run("Unbind", func(t *testing.T) {
+		keyPairs, _ := keyPairProvider(config.ProviderFake, "ap-test", "")
+		found, _ := keyPairs.ListKeyPairs()
+		keyId := found[0].KeyId
+
+		if _, err := runCloudCommand(t, "keypair", "unbind", "web", keyId, "--yes"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if found, _ := keyPairs.ListKeyPairs(); len(found[0].AssociatedInstanceIds) != 0 {
+			t.Errorf("expected key to be unbound, got %+v", found[0])
+		}
+
+		if _, err := runCloudCommand(t, "keypair", "bind", "web", "lhkp-missing", "--yes"); err == nil {
+			t.Error("expected unknown key id to be rejected")
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "deploy")
+		out, err := runCloudCommand(t, "keypair", "create", "deploy", "--provider", config.ProviderFake, "--region", "ap-test", "--private-key-file", path)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Fatalf("expected private key with mode 0600, got %v, %v", info, err)
+		}
+		data, _ := os.ReadFile(path)
+		if _, err := ssh.ParsePrivateKey(data); err != nil {
+			t.Errorf("expected a usable private key, got: %v", err)
+		}
+
+		if _, err := runCloudCommand(t, "keypair", "create", "deploy", "--provider", config.ProviderFake, "--region", "ap-test", "--private-key-file", path); err == nil {
+			t.Error("expected existing private key file not to be overwritten")
+		}
+		if _, err := runCloudCommand(t, "keypair", "create", "bad-name", "--region", "ap-test"); err == nil {
+			t.Error("expected invalid key name to be rejected")
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := runCloudCommand(t, "keypair", "bind", "batch", "--from", from, "--yes")
+		if ExitCode(err) != EXIT_CONFIG {
+			t.Errorf("expected unsupported provider error, got: %v", err)
+		}
+	})
+}
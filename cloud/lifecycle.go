@@ -0,0 +1,141 @@
+package cloud
+
+import (
+	"fmt"
+	"time"
+
+	"lucky-go/config"
+)
+
+// 轻量应用服务器的续费标识
+const (
+	renewManual = "NOTIFY_AND_MANUAL_RENEW"
+	renewAuto   = "NOTIFY_AND_AUTO_RENEW"
+)
+
+// LifecycleProvider 是支持创建和销毁实例的 Provider 实现的可选接口。
+// 销毁分两步：先退还（隔离）实例使其进入 SHUTDOWN 状态，再彻底销毁。
+type LifecycleProvider interface {
+	// CreateInstance 按 spec 创建一台实例，返回实例 ID
+	CreateInstance(spec InstanceSpec) (string, error)
+	// IsolateInstance 退还实例，实例进入 SHUTDOWN 状态
+	IsolateInstance(instanceId string) error
+	// TerminateInstance 销毁处于 SHUTDOWN 状态的实例
+	TerminateInstance(instanceId string) error
+}
+
+// InstanceSpec 是创建实例的参数
+type InstanceSpec struct {
+	Name        string
+	BlueprintId string
+	BundleId    string
+	// Zone 为空时由云平台选择可用区
+	Zone string
+	// Period 是预付费的购买时长，单位为月
+	Period int64
+	// AutoRenew 表示到期后自动续费
+	AutoRenew bool
+	// KeyIds 是创建时绑定的密钥对
+	KeyIds []string
+}
+
+// renewFlag 返回 spec 对应的续费标识
+func (spec InstanceSpec) renewFlag() string {
+	if spec.AutoRenew {
+		return renewAuto
+	}
+	return renewManual
+}
+
+// CreateResult 是 cloud create 的结果
+type CreateResult struct {
+	Destination  string   `json:"destination" yaml:"destination"`
+	InstanceId   string   `json:"instance-id" yaml:"instance-id"`
+	Region       string   `json:"region" yaml:"region"`
+	State        string   `json:"state" yaml:"state"`
+	Ssh          string   `json:"ssh,omitempty" yaml:"ssh,omitempty"`
+	KeyIds       []string `json:"key-ids" yaml:"key-ids"`
+	IdentityFile string   `json:"identity-file,omitempty" yaml:"identity-file,omitempty"`
+}
+
+// DestroyResult 是 cloud destroy 的结果
+type DestroyResult struct {
+	Destination string `json:"destination" yaml:"destination"`
+	InstanceId  string `json:"instance-id" yaml:"instance-id"`
+	// ConfigRemoved 表示目标已从配置中删除
+	ConfigRemoved bool `json:"config-removed" yaml:"config-removed"`
+}
+
+// lifecycleProvider 返回指定云平台、地域和账号的实例创建和销毁能力，不支持时返回错误
+func lifecycleProvider(name, region, account string) (LifecycleProvider, error) {
+	provider, err := NewProvider(name, region, account)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle, ok := provider.(LifecycleProvider)
+	if !ok {
+		if name == "" {
+			name = config.ProviderLighthouse
+		}
+		return nil, configErrorf("provider %v 不支持创建和销毁实例", name)
+	}
+	return lifecycle, nil
+}
+
+// waitForState 轮询实例状态直到变为 target，返回此时的实例。
+// 实例刚创建时可能暂时查询不到，查询失败时继续重试直到超时。
+func waitForState(dest *config.Destination, target string, opts waitOptions) (*Instance, error) {
+	start := timeNow()
+	deadline := start.Add(opts.Timeout)
+
+	lastState := ""
+	for {
+		instance, err := DescribeInstance(&dest.DestinationInstance)
+		if err != nil {
+			fmt.Fprintf(opts.Out, "%v: 查询状态失败: %v\n", dest.Name, err)
+		} else {
+			if instance.State != lastState {
+				fmt.Fprintf(opts.Out, "%v: 状态 %v (%v)\n", dest.Name, instance.State, timeNow().Sub(start).Round(time.Second))
+				lastState = instance.State
+			}
+			if instance.State == target {
+				return instance, nil
+			}
+		}
+
+		if !timeNow().Before(deadline) {
+			return nil, timeoutErrorf("等待 %v 变为 %v 超时（%v），最后状态为 %v", dest.Name, target, opts.Timeout, lastState)
+		}
+		sleepFunc(opts.Interval)
+	}
+}
+
+// addDestination 把新建的目标写入配置，目标已存在时返回错误
+func addDestination(name string, dest config.DestinationInstance) error {
+	if err := dest.Validate(); err != nil {
+		return err
+	}
+
+	return config.Update(func(cfg *config.Config) error {
+		if _, ok := cfg.Dest[name]; ok {
+			return fmt.Errorf("目标 %v 已存在", name)
+		}
+		if cfg.Dest == nil {
+			cfg.Dest = map[string]config.DestinationInstance{}
+		}
+		cfg.Dest[name] = dest
+		return nil
+	})
+}
+
+// removeDestination 从配置中删除目标
+func removeDestination(name string) error {
+	return config.Update(func(cfg *config.Config) error {
+		if _, ok := cfg.Dest[name]; !ok {
+			return fmt.Errorf("目标 %v 不在配置中", name)
+		}
+		delete(cfg.Dest, name)
+		return nil
+	})
+}
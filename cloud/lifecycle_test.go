@@ -0,0 +1,126 @@
+package cloud
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestCreateAndDestroy(t *testing.T) {
+	advance := useFakeClock(t)
+	useWaitClock(t)
+	// 等待时同时推进 fake provider 的时钟，使实例完成状态过渡
+	waitSleep := sleepFunc
+	sleepFunc = func(d time.Duration) {
+		waitSleep(d)
+		advance(d)
+	}
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web", Protected: true},
+		},
+	})
+	from, _ := writeTestKey(t, t.TempDir(), "alice@laptop")
+	create := []string{"create", "--provider", config.ProviderFake, "--region", "ap-test", "--blueprint", "lhbp-ubuntu", "--bundle", "fake_bundle", "--from", from, "--tag", "test"}
+
+	t.Run("DryRun", func(t *testing.T) {
+		out, err := runCloudCommand(t, append(create, "--name", "tmp-1", "--dry-run")...)
+		if err != nil || !strings.Contains(out, "ImportKeyPair") || !strings.Contains(out, "CreateInstances") {
+			t.Errorf("expected import and create calls, got: %v\n%s", err, out)
+		}
+		if _, err := config.LoadDestination("tmp-1"); err == nil {
+			t.Error("expected dry run not to write the config")
+		}
+	})
+
+	var created CreateResult
+	t.Run("Create", func(t *testing.T) {
+		stdout, stderr, err := runCloudCommandSplit(t, append(create, "--name", "tmp-1", "-o", "json")...)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, stderr)
+		}
+		if err := json.Unmarshal([]byte(stdout), &created); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		if created.State != "RUNNING" || len(created.KeyIds) != 1 || !strings.Contains(stderr, "状态 PENDING") {
+			t.Errorf("expected to wait for RUNNING with a bound key, got %+v\n%s", created, stderr)
+		}
+
+		dest, err := config.LoadDestination("tmp-1")
+		if err != nil {
+			t.Fatalf("expected destination to be added, got: %v", err)
+		}
+		if dest.InstanceId != created.InstanceId || dest.Ssh != created.Ssh || !strings.HasPrefix(dest.Ssh, "root@") ||
+			dest.IdentityFile != strings.TrimSuffix(from, ".pub") || strings.Join(dest.Tags, ",") != "test" {
+			t.Errorf("unexpected destination: %+v", dest)
+		}
+
+		keyPairs, _ := keyPairProvider(config.ProviderFake, "ap-test", "")
+		found, _ := keyPairs.ListKeyPairs()
+		if len(found) != 1 || !contains(found[0].AssociatedInstanceIds, created.InstanceId) {
+			t.Errorf("expected key pair to be bound to the new instance, got %+v", found)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		if _, err := runCloudCommand(t, append(create, "--name", "tmp-1")...); err == nil {
+			t.Error("expected existing destination name to be rejected")
+		}
+		if _, err := runCloudCommand(t, "create", "--provider", config.ProviderFake, "--region", "ap-test", "--blueprint", "b", "--bundle", "b", "--name", "tmp-2"); err == nil {
+			t.Error("expected create without a key pair to be rejected")
+		}
+	})
+
+	t.Run("Destroy", func(t *testing.T) {
+		if _, err := runCloudCommand(t, "destroy", "web", "--yes"); ExitCode(err) != EXIT_PROTECTED {
+			t.Errorf("expected protected destination to be refused, got: %v", err)
+		}
+
+		cmd := NewCommand()
+		out := &strings.Builder{}
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		cmd.SetIn(strings.NewReader("tmp-1\n"))
+		cmd.SetArgs([]string{"destroy", "tmp-1"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+		if !strings.Contains(out.String(), "状态 SHUTDOWN") || !strings.Contains(out.String(), "已销毁实例") {
+			t.Errorf("expected isolate, wait and terminate, got:\n%s", out)
+		}
+
+		if _, err := config.LoadDestination("tmp-1"); err == nil {
+			t.Error("expected destination to be removed from the config")
+		}
+		if _, err := DescribeInstance(&config.DestinationInstance{Provider: config.ProviderFake, Region: "ap-test", InstanceId: created.InstanceId}); err == nil {
+			t.Error("expected destroyed instance to be gone")
+		}
+	})
+	t.Run("BrokenConfig", func(t *testing.T) {
+		path, err := config.ConfigFilePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("dest: [\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// 配置无法读取时在创建实例之前报错
+		before := len(fakeMemory.Instances)
+		if _, err := runCloudCommand(t, append(create, "--name", "tmp-3")...); err == nil {
+			t.Error("expected a config error, got nil")
+		}
+		if len(fakeMemory.Instances) != before {
+			t.Errorf("expected no instance to be created, got %d instances", len(fakeMemory.Instances))
+		}
+	})
+}
@@ -0,0 +1,488 @@
+package cloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	lighthouse "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/lighthouse/v20200324"
+
+	"lucky-go/config"
+)
+
+// 轻量应用服务器 API 的服务名和 DescribeInstances 单页返回的最大数量
+const (
+	lighthouseService  = "lighthouse"
+	lighthousePageSize = 100
+)
+
+// lighthouseProvider 是腾讯云轻量应用服务器的 Provider 实现
+type lighthouseProvider struct {
+	client *lighthouse.Client
+	region string
+}
+
+// newLighthouseProvider 创建指定地域的轻量应用服务器 Provider，使用账号 account 的凭证
+func newLighthouseProvider(region, account string) (Provider, error) {
+	credential, err := tencentCredential(account)
+	if err != nil {
+		return nil, err
+	}
+
+	cpf, err := newClientProfile(lighthouseService, "")
+	if err != nil {
+		return nil, err
+	}
+	client, err := lighthouse.NewClient(credential, region, cpf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lighthouseProvider{client: client, region: region}, nil
+}
+
+// Reboot 实现 Provider 接口
+func (p *lighthouseProvider) Reboot(instanceId string) (string, error) {
+	response, err := p.client.RebootInstances(&lighthouse.RebootInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	if err != nil {
+		return "", err
+	}
+	return stringValue(response.Response.RequestId), nil
+}
+
+// Start 实现 Provider 接口
+func (p *lighthouseProvider) Start(instanceId string) (string, error) {
+	response, err := p.client.StartInstances(&lighthouse.StartInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	if err != nil {
+		return "", err
+	}
+	return stringValue(response.Response.RequestId), nil
+}
+
+// Stop 实现 Provider 接口
+func (p *lighthouseProvider) Stop(instanceId string) (string, error) {
+	response, err := p.client.StopInstances(&lighthouse.StopInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	if err != nil {
+		return "", err
+	}
+	return stringValue(response.Response.RequestId), nil
+}
+
+// Describe 实现 Provider 接口，同时查询实例详情和流量包
+func (p *lighthouseProvider) Describe(instanceId string) (*Instance, error) {
+	response, err := p.client.DescribeInstances(&lighthouse.DescribeInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Response.InstanceSet) == 0 {
+		return nil, fmt.Errorf("地域 %v 中不存在实例 %v", p.region, instanceId)
+	}
+
+	instance := newInstanceFromLighthouse(response.Response.InstanceSet[0], p.region)
+
+	traffic, err := p.client.DescribeInstancesTrafficPackages(&lighthouse.DescribeInstancesTrafficPackagesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range traffic.Response.InstanceTrafficPackageSet {
+		if len(set.TrafficPackageSet) > 0 {
+			instance.Traffic = newTrafficFromLighthouse(set.TrafficPackageSet[0])
+		}
+	}
+
+	return instance, nil
+}
+
+// List 实现 Provider 接口，分页查询地域内的全部实例
+func (p *lighthouseProvider) List() ([]Instance, error) {
+	var instances []Instance
+	for offset := int64(0); ; offset += lighthousePageSize {
+		request := lighthouse.NewDescribeInstancesRequest()
+		request.Offset = common.Int64Ptr(offset)
+		request.Limit = common.Int64Ptr(lighthousePageSize)
+
+		response, err := p.client.DescribeInstances(request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Response.InstanceSet {
+			instances = append(instances, *newInstanceFromLighthouse(item, p.region))
+		}
+
+		total := int64Value(response.Response.TotalCount)
+		if len(response.Response.InstanceSet) == 0 || int64(len(instances)) >= total {
+			return instances, nil
+		}
+	}
+}
+
+// newInstanceFromLighthouse 把 SDK 返回的实例转换为 Instance
+func newInstanceFromLighthouse(item *lighthouse.Instance, region string) *Instance {
+	return &Instance{
+		InstanceId:  stringValue(item.InstanceId),
+		Name:        stringValue(item.InstanceName),
+		Provider:    config.ProviderLighthouse,
+		Region:      region,
+		Zone:        stringValue(item.Zone),
+		State:       stringValue(item.InstanceState),
+		PublicIPs:   stringValues(item.PublicAddresses),
+		PrivateIPs:  stringValues(item.PrivateAddresses),
+		BundleId:    stringValue(item.BundleId),
+		CPU:         int64Value(item.CPU),
+		Memory:      int64Value(item.Memory),
+		OsName:      stringValue(item.OsName),
+		CreatedTime: stringValue(item.CreatedTime),
+		ExpiredTime: stringValue(item.ExpiredTime),
+		RenewFlag:   stringValue(item.RenewFlag),
+		Tags:        newTagsFromLighthouse(item.Tags),
+	}
+}
+
+// newTagsFromLighthouse 把 SDK 返回的标签转换为键值映射
+func newTagsFromLighthouse(items []*lighthouse.Tag) map[string]string {
+	if len(items) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(items))
+	for _, item := range items {
+		tags[stringValue(item.Key)] = stringValue(item.Value)
+	}
+	return tags
+}
+
+// newTrafficFromLighthouse 把 SDK 返回的流量包转换为 Traffic
+func newTrafficFromLighthouse(item *lighthouse.TrafficPackage) *Traffic {
+	return &Traffic{
+		Used:      int64Value(item.TrafficUsed),
+		Total:     int64Value(item.TrafficPackageTotal),
+		Remaining: int64Value(item.TrafficPackageRemaining),
+		Overflow:  int64Value(item.TrafficOverflow),
+		StartTime: stringValue(item.StartTime),
+		EndTime:   stringValue(item.EndTime),
+	}
+}
+
+// stringValue 返回指针指向的字符串，指针为空时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// stringValues 把字符串指针切片转换为字符串切片
+func stringValues(items []*string) []string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			values = append(values, *item)
+		}
+	}
+	return values
+}
+
+// int64Value 返回指针指向的整数，指针为空时返回 0
+func int64Value(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+// CreateSnapshot 实现 SnapshotProvider 接口
+func (p *lighthouseProvider) CreateSnapshot(instanceId, name string) (string, error) {
+	response, err := p.client.CreateInstanceSnapshot(&lighthouse.CreateInstanceSnapshotRequest{
+		InstanceId:   &instanceId,
+		SnapshotName: &name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return stringValue(response.Response.SnapshotId), nil
+}
+
+// ListSnapshots 实现 SnapshotProvider 接口
+func (p *lighthouseProvider) ListSnapshots(instanceId string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	for offset := int64(0); ; offset += lighthousePageSize {
+		request := lighthouse.NewDescribeSnapshotsRequest()
+		request.Filters = []*lighthouse.Filter{{
+			Name:   common.StringPtr("instance-id"),
+			Values: []*string{&instanceId},
+		}}
+		request.Offset = common.Int64Ptr(offset)
+		request.Limit = common.Int64Ptr(lighthousePageSize)
+
+		response, err := p.client.DescribeSnapshots(request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Response.SnapshotSet {
+			snapshots = append(snapshots, Snapshot{
+				SnapshotId:  stringValue(item.SnapshotId),
+				Name:        stringValue(item.SnapshotName),
+				State:       stringValue(item.SnapshotState),
+				Percent:     int64Value(item.Percent),
+				CreatedTime: stringValue(item.CreatedTime),
+			})
+		}
+
+		total := int64Value(response.Response.TotalCount)
+		if len(response.Response.SnapshotSet) == 0 || int64(len(snapshots)) >= total {
+			return snapshots, nil
+		}
+	}
+}
+
+// DeleteSnapshot 实现 SnapshotProvider 接口
+func (p *lighthouseProvider) DeleteSnapshot(snapshotId string) error {
+	_, err := p.client.DeleteSnapshots(&lighthouse.DeleteSnapshotsRequest{
+		SnapshotIds: []*string{&snapshotId},
+	})
+	return err
+}
+
+// RestoreSnapshot 实现 SnapshotProvider 接口
+func (p *lighthouseProvider) RestoreSnapshot(instanceId, snapshotId string) error {
+	_, err := p.client.ApplyInstanceSnapshot(&lighthouse.ApplyInstanceSnapshotRequest{
+		InstanceId: &instanceId,
+		SnapshotId: &snapshotId,
+	})
+	return err
+}
+
+// ListFirewallRules 实现 FirewallProvider 接口
+func (p *lighthouseProvider) ListFirewallRules(instanceId string) ([]config.FirewallRule, error) {
+	var rules []config.FirewallRule
+	for offset := int64(0); ; offset += lighthousePageSize {
+		request := lighthouse.NewDescribeFirewallRulesRequest()
+		request.InstanceId = &instanceId
+		request.Offset = common.Int64Ptr(offset)
+		request.Limit = common.Int64Ptr(lighthousePageSize)
+
+		response, err := p.client.DescribeFirewallRules(request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Response.FirewallRuleSet {
+			cidr := stringValue(item.CidrBlock)
+			if cidr == "" {
+				cidr = stringValue(item.Ipv6CidrBlock)
+			}
+			rules = append(rules, config.FirewallRule{
+				Protocol:    stringValue(item.Protocol),
+				Port:        stringValue(item.Port),
+				Cidr:        cidr,
+				Action:      stringValue(item.Action),
+				Description: stringValue(item.FirewallRuleDescription),
+			})
+		}
+
+		total := int64Value(response.Response.TotalCount)
+		if len(response.Response.FirewallRuleSet) == 0 || int64(len(rules)) >= total {
+			return rules, nil
+		}
+	}
+}
+
+// AddFirewallRules 实现 FirewallProvider 接口
+func (p *lighthouseProvider) AddFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	_, err := p.client.CreateFirewallRules(&lighthouse.CreateFirewallRulesRequest{
+		InstanceId:    &instanceId,
+		FirewallRules: lighthouseFirewallRules(rules),
+	})
+	return err
+}
+
+// RemoveFirewallRules 实现 FirewallProvider 接口
+func (p *lighthouseProvider) RemoveFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	_, err := p.client.DeleteFirewallRules(&lighthouse.DeleteFirewallRulesRequest{
+		InstanceId:    &instanceId,
+		FirewallRules: lighthouseFirewallRules(rules),
+	})
+	return err
+}
+
+// ReplaceFirewallRules 实现 FirewallProvider 接口
+func (p *lighthouseProvider) ReplaceFirewallRules(instanceId string, rules []config.FirewallRule) error {
+	_, err := p.client.ModifyFirewallRules(&lighthouse.ModifyFirewallRulesRequest{
+		InstanceId:    &instanceId,
+		FirewallRules: lighthouseFirewallRules(rules),
+	})
+	return err
+}
+
+// lighthouseFirewallRules 把防火墙规则转换为 API 参数，IPv6 来源放在 Ipv6CidrBlock 中
+func lighthouseFirewallRules(rules []config.FirewallRule) []*lighthouse.FirewallRule {
+	result := make([]*lighthouse.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		rule = rule.Normalize()
+		item := &lighthouse.FirewallRule{
+			Protocol: common.StringPtr(rule.Protocol),
+			Port:     common.StringPtr(rule.Port),
+			Action:   common.StringPtr(rule.Action),
+		}
+		if strings.Contains(rule.Cidr, ":") {
+			item.Ipv6CidrBlock = common.StringPtr(rule.Cidr)
+		} else {
+			item.CidrBlock = common.StringPtr(rule.Cidr)
+		}
+		if rule.Description != "" {
+			item.FirewallRuleDescription = common.StringPtr(rule.Description)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// ListKeyPairs 实现 KeyPairProvider 接口
+func (p *lighthouseProvider) ListKeyPairs() ([]KeyPair, error) {
+	var keyPairs []KeyPair
+	for offset := int64(0); ; offset += lighthousePageSize {
+		request := lighthouse.NewDescribeKeyPairsRequest()
+		request.Offset = common.Int64Ptr(offset)
+		request.Limit = common.Int64Ptr(lighthousePageSize)
+
+		response, err := p.client.DescribeKeyPairs(request)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Response.KeyPairSet {
+			keyPairs = append(keyPairs, lighthouseKeyPair(item))
+		}
+
+		total := int64Value(response.Response.TotalCount)
+		if len(response.Response.KeyPairSet) == 0 || int64(len(keyPairs)) >= total {
+			return keyPairs, nil
+		}
+	}
+}
+
+// CreateKeyPair 实现 KeyPairProvider 接口
+func (p *lighthouseProvider) CreateKeyPair(name string) (*KeyPair, string, error) {
+	response, err := p.client.CreateKeyPair(&lighthouse.CreateKeyPairRequest{
+		KeyName: &name,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if response.Response.KeyPair == nil {
+		return nil, "", fmt.Errorf("CreateKeyPair 没有返回密钥对")
+	}
+
+	keyPair := lighthouseKeyPair(response.Response.KeyPair)
+	return &keyPair, stringValue(response.Response.KeyPair.PrivateKey), nil
+}
+
+// ImportKeyPair 实现 KeyPairProvider 接口
+func (p *lighthouseProvider) ImportKeyPair(name, publicKey string) (string, error) {
+	response, err := p.client.ImportKeyPair(&lighthouse.ImportKeyPairRequest{
+		KeyName:   &name,
+		PublicKey: &publicKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return stringValue(response.Response.KeyId), nil
+}
+
+// AssociateKeyPairs 实现 KeyPairProvider 接口
+func (p *lighthouseProvider) AssociateKeyPairs(instanceId string, keyIds []string) error {
+	_, err := p.client.AssociateInstancesKeyPairs(&lighthouse.AssociateInstancesKeyPairsRequest{
+		KeyIds:      common.StringPtrs(keyIds),
+		InstanceIds: []*string{&instanceId},
+	})
+	return err
+}
+
+// DisassociateKeyPairs 实现 KeyPairProvider 接口
+func (p *lighthouseProvider) DisassociateKeyPairs(instanceId string, keyIds []string) error {
+	_, err := p.client.DisassociateInstancesKeyPairs(&lighthouse.DisassociateInstancesKeyPairsRequest{
+		KeyIds:      common.StringPtrs(keyIds),
+		InstanceIds: []*string{&instanceId},
+	})
+	return err
+}
+
+// lighthouseKeyPair 把 API 返回的密钥对转换为 KeyPair，不包含私钥
+func lighthouseKeyPair(item *lighthouse.KeyPair) KeyPair {
+	keyPair := KeyPair{
+		KeyId:       stringValue(item.KeyId),
+		KeyName:     stringValue(item.KeyName),
+		PublicKey:   stringValue(item.PublicKey),
+		CreatedTime: stringValue(item.CreatedTime),
+	}
+	for _, instanceId := range item.AssociatedInstanceIds {
+		keyPair.AssociatedInstanceIds = append(keyPair.AssociatedInstanceIds, stringValue(instanceId))
+	}
+	return keyPair
+}
+
+// CreateInstance 实现 LifecycleProvider 接口
+func (p *lighthouseProvider) CreateInstance(spec InstanceSpec) (string, error) {
+	response, err := p.client.CreateInstances(lighthouseCreateRequest(spec))
+	if err != nil {
+		return "", err
+	}
+	if len(response.Response.InstanceIdSet) == 0 {
+		return "", fmt.Errorf("CreateInstances 没有返回实例 ID")
+	}
+
+	return stringValue(response.Response.InstanceIdSet[0]), nil
+}
+
+// IsolateInstance 实现 LifecycleProvider 接口
+func (p *lighthouseProvider) IsolateInstance(instanceId string) error {
+	_, err := p.client.IsolateInstances(&lighthouse.IsolateInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	return err
+}
+
+// TerminateInstance 实现 LifecycleProvider 接口
+func (p *lighthouseProvider) TerminateInstance(instanceId string) error {
+	_, err := p.client.TerminateInstances(&lighthouse.TerminateInstancesRequest{
+		InstanceIds: []*string{&instanceId},
+	})
+	return err
+}
+
+// lighthouseCreateRequest 返回创建单台预付费实例的请求，指定密钥对时以密钥登录并随机生成密码
+func lighthouseCreateRequest(spec InstanceSpec) *lighthouse.CreateInstancesRequest {
+	request := lighthouse.NewCreateInstancesRequest()
+	request.BundleId = common.StringPtr(spec.BundleId)
+	request.BlueprintId = common.StringPtr(spec.BlueprintId)
+	request.InstanceName = common.StringPtr(spec.Name)
+	request.InstanceCount = common.Uint64Ptr(1)
+	request.InstanceChargePrepaid = &lighthouse.InstanceChargePrepaid{
+		Period:    common.Int64Ptr(spec.Period),
+		RenewFlag: common.StringPtr(spec.renewFlag()),
+	}
+	if spec.Zone != "" {
+		request.Zones = common.StringPtrs([]string{spec.Zone})
+	}
+	if len(spec.KeyIds) > 0 {
+		request.LoginConfiguration = &lighthouse.LoginConfiguration{
+			AutoGeneratePassword: common.StringPtr("YES"),
+			KeyIds:               common.StringPtrs(spec.KeyIds),
+		}
+	}
+	return request
+}
@@ -0,0 +1,387 @@
+package cloud
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+
+	"lucky-go/config"
+)
+
+const (
+	// monitorService 和 monitorVersion 是云监控 API 的服务名和版本
+	monitorService = "monitor"
+	monitorVersion = "2018-07-24"
+	// maxMetricPoints 是自动选择统计周期时单个指标最多的数据点数
+	maxMetricPoints = 1440
+)
+
+// metricPeriods 是云监控支持的统计周期（秒），从小到大排列
+var metricPeriods = []int64{60, 300, 3600, 86400}
+
+// metricSpec 描述一个可以查询的监控指标
+type metricSpec struct {
+	// Name 是命令行中使用的指标名称，MetricName 是云监控的指标名称
+	Name, MetricName string
+	Unit             string
+}
+
+// metricSpecs 是支持的监控指标，轻量应用服务器和云服务器的指标名称相同
+var metricSpecs = []metricSpec{
+	{Name: "cpu", MetricName: "CpuUsage", Unit: "%"},
+	{Name: "mem", MetricName: "MemUsage", Unit: "%"},
+	{Name: "lan-in", MetricName: "LanIntraffic", Unit: "Mbps"},
+	{Name: "lan-out", MetricName: "LanOuttraffic", Unit: "Mbps"},
+	{Name: "wan-in", MetricName: "WanIntraffic", Unit: "Mbps"},
+	{Name: "wan-out", MetricName: "WanOuttraffic", Unit: "Mbps"},
+}
+
+// parseMetrics 解析逗号分隔的指标名称，未知的指标返回错误
+func parseMetrics(names []string) ([]metricSpec, error) {
+	var specs []metricSpec
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		spec, ok := findMetric(name)
+		if !ok {
+			available := make([]string, len(metricSpecs))
+			for i, spec := range metricSpecs {
+				available[i] = spec.Name
+			}
+			return nil, fmt.Errorf("不支持的指标 %v，可用: %v", name, strings.Join(available, "、"))
+		}
+		seen[name] = true
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("请使用 --metric 指定至少一个指标")
+	}
+	return specs, nil
+}
+
+// findMetric 按命令行中的名称查找指标
+func findMetric(name string) (metricSpec, bool) {
+	for _, spec := range metricSpecs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return metricSpec{}, false
+}
+
+// metricsPeriod 返回查询 since 时长的数据时使用的统计周期：数据点不超过 maxMetricPoints 的最小周期
+func metricsPeriod(since time.Duration) int64 {
+	for _, period := range metricPeriods {
+		if int64(since/time.Second)/period <= maxMetricPoints {
+			return period
+		}
+	}
+	return metricPeriods[len(metricPeriods)-1]
+}
+
+// validMetricsPeriod 检查统计周期是否为云监控支持的值
+func validMetricsPeriod(period int64) bool {
+	for _, p := range metricPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricPoint 是监控指标的一个数据点
+type MetricPoint struct {
+	Time  time.Time `json:"time" yaml:"time"`
+	Value float64   `json:"value" yaml:"value"`
+}
+
+// MetricSeries 是一个监控指标在查询时间范围内的数据
+type MetricSeries struct {
+	Metric string `json:"metric" yaml:"metric"`
+	// MetricName 是云监控的指标名称
+	MetricName string        `json:"metric-name" yaml:"metric-name"`
+	Unit       string        `json:"unit" yaml:"unit"`
+	Points     []MetricPoint `json:"points" yaml:"points"`
+}
+
+// stats 返回数据点的最小值、平均值、最大值和最新值，没有数据时 ok 为 false
+func (s MetricSeries) stats() (low, avg, high, last float64, ok bool) {
+	if len(s.Points) == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	low, high, sum := math.Inf(1), math.Inf(-1), 0.0
+	for _, point := range s.Points {
+		low = math.Min(low, point.Value)
+		high = math.Max(high, point.Value)
+		sum += point.Value
+	}
+	return low, sum / float64(len(s.Points)), high, s.Points[len(s.Points)-1].Value, true
+}
+
+// MetricsResult 是 cloud metrics 查询单个目标的结果
+type MetricsResult struct {
+	Destination string    `json:"destination" yaml:"destination"`
+	InstanceId  string    `json:"instance-id" yaml:"instance-id"`
+	Start       time.Time `json:"start" yaml:"start"`
+	End         time.Time `json:"end" yaml:"end"`
+	// Period 是统计周期，单位为秒
+	Period int64          `json:"period" yaml:"period"`
+	Series []MetricSeries `json:"series" yaml:"series"`
+}
+
+// monitorClient 查询云监控的指标数据
+type monitorClient struct {
+	namespace string
+	// send 发送 API 请求并返回响应体，测试中可替换
+	send func(action string, params map[string]any) ([]byte, error)
+}
+
+// monitorDataResponse 是 GetMonitorData 的响应
+type monitorDataResponse struct {
+	Response struct {
+		DataPoints []struct {
+			// Timestamps 是数据点的 Unix 时间戳，与 Values 一一对应
+			Timestamps []float64 `json:"Timestamps"`
+			Values     []float64 `json:"Values"`
+		} `json:"DataPoints"`
+	} `json:"Response"`
+}
+
+// monitorNamespace 返回 provider 在云监控中的命名空间
+func monitorNamespace(provider string) (string, error) {
+	switch provider {
+	case "", config.ProviderLighthouse:
+		return "QCE/LIGHTHOUSE", nil
+	case config.ProviderCVM:
+		return "QCE/CVM", nil
+	default:
+		return "", configErrorf("provider %v 不支持云监控", provider)
+	}
+}
+
+// newMonitorClient 创建目标所在地域和账号的云监控客户端，endpoint 为空时使用默认地址
+func newMonitorClient(dest *config.DestinationInstance, endpoint string) (*monitorClient, error) {
+	namespace, err := monitorNamespace(dest.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := tencentCredential(dest.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	cpf, err := newClientProfile(monitorService, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	client := common.NewCommonClient(credential, dest.Region, cpf)
+
+	return &monitorClient{
+		namespace: namespace,
+		send: func(action string, params map[string]any) ([]byte, error) {
+			request := tchttp.NewCommonRequest(monitorService, monitorVersion, action)
+			if err := request.SetActionParameters(params); err != nil {
+				return nil, err
+			}
+
+			response := tchttp.NewCommonResponse()
+			if err := client.Send(request, response); err != nil {
+				return nil, err
+			}
+			return response.GetBody(), nil
+		},
+	}, nil
+}
+
+// monitorDataParams 返回 GetMonitorData 的参数
+func monitorDataParams(namespace, instanceId string, spec metricSpec, period int64, start, end time.Time) map[string]any {
+	return map[string]any{
+		"Namespace":  namespace,
+		"MetricName": spec.MetricName,
+		"Period":     period,
+		"StartTime":  start.Format(time.RFC3339),
+		"EndTime":    end.Format(time.RFC3339),
+		"Instances": []map[string]any{{
+			"Dimensions": []map[string]string{{"Name": "InstanceId", "Value": instanceId}},
+		}},
+	}
+}
+
+// Series 查询实例在 start 到 end 之间的一个指标
+func (c *monitorClient) Series(instanceId string, spec metricSpec, period int64, start, end time.Time) (MetricSeries, error) {
+	series := MetricSeries{Metric: spec.Name, MetricName: spec.MetricName, Unit: spec.Unit, Points: []MetricPoint{}}
+
+	data, err := c.send("GetMonitorData", monitorDataParams(c.namespace, instanceId, spec, period, start, end))
+	if err != nil {
+		return series, err
+	}
+
+	var response monitorDataResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return series, err
+	}
+	for _, dataPoint := range response.Response.DataPoints {
+		if len(dataPoint.Timestamps) != len(dataPoint.Values) {
+			return series, fmt.Errorf("%v 的时间戳和数值数量不一致", spec.MetricName)
+		}
+		for i, timestamp := range dataPoint.Timestamps {
+			series.Points = append(series.Points, MetricPoint{Time: time.Unix(int64(timestamp), 0), Value: dataPoint.Values[i]})
+		}
+	}
+	sort.Slice(series.Points, func(i, j int) bool { return series.Points[i].Time.Before(series.Points[j].Time) })
+	return series, nil
+}
+
+// sparkBlocks 是迷你图从低到高使用的字符
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// downsample 把数据点按时间顺序分成 width 组，返回每组的平均值；数据点不足 width 时原样返回
+func downsample(points []MetricPoint, width int) []float64 {
+	if width <= 0 || len(points) <= width {
+		values := make([]float64, len(points))
+		for i, point := range points {
+			values[i] = point.Value
+		}
+		return values
+	}
+
+	values := make([]float64, width)
+	for i := range values {
+		from, to := i*len(points)/width, (i+1)*len(points)/width
+		sum := 0.0
+		for _, point := range points[from:to] {
+			sum += point.Value
+		}
+		values[i] = sum / float64(to-from)
+	}
+	return values
+}
+
+// chartMax 返回图表纵轴的上限，所有数值都不大于 0 时返回 0
+func chartMax(values []float64) float64 {
+	top := 0.0
+	for _, v := range values {
+		top = math.Max(top, v)
+	}
+	return top
+}
+
+// sparkline 把数值渲染为一行迷你图，纵轴从 0 到最大值
+func sparkline(values []float64) string {
+	top := chartMax(values)
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if top > 0 {
+			level = max(0, int(math.Round(v/top*float64(len(sparkBlocks)-1))))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// renderChart 把指标渲染为 height 行、最多 width 列的柱状折线图，左侧为纵轴刻度，下方为起止时间
+func renderChart(w io.Writer, series MetricSeries, width, height int) {
+	fmt.Fprintf(w, "%v (%v, %v)\n", series.Metric, series.MetricName, series.Unit)
+	if len(series.Points) == 0 {
+		fmt.Fprintln(w, "  无数据")
+		return
+	}
+
+	values := downsample(series.Points, width)
+	top := chartMax(values)
+	labels := make([]string, height)
+	labelWidth := 1
+	for row := range labels {
+		labels[row] = formatMetricValue(top * float64(height-row) / float64(height))
+		labelWidth = max(labelWidth, len(labels[row]))
+	}
+
+	// 每行按 1/8 字符的精度填充，从上到下输出
+	for row := 0; row < height; row++ {
+		label := ""
+		if row == 0 || row == height/2 {
+			label = labels[row]
+		}
+		var b strings.Builder
+		for _, v := range values {
+			eighths := 0.0
+			if top > 0 {
+				eighths = v/top*float64(height*8) - float64((height-1-row)*8)
+			}
+			switch n := int(math.Round(eighths)); {
+			case n >= 8:
+				b.WriteRune(sparkBlocks[len(sparkBlocks)-1])
+			case n <= 0:
+				b.WriteRune(' ')
+			default:
+				b.WriteRune(sparkBlocks[n-1])
+			}
+		}
+		fmt.Fprintf(w, "%*s ┤%v\n", labelWidth, label, strings.TrimRight(b.String(), " "))
+	}
+
+	start := series.Points[0].Time.Local().Format("01-02 15:04")
+	end := series.Points[len(series.Points)-1].Time.Local().Format("01-02 15:04")
+	fmt.Fprintf(w, "%*s └%v\n", labelWidth, "0", strings.Repeat("─", len(values)))
+	if end == start {
+		fmt.Fprintf(w, "%*s  %v\n", labelWidth, "", start)
+		return
+	}
+	padding := max(1, len(values)-len(start)-len(end))
+	fmt.Fprintf(w, "%*s  %v%v%v\n", labelWidth, "", start, strings.Repeat(" ", padding), end)
+}
+
+// formatMetricValue 格式化指标数值，保留最多两位小数
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// writeMetricsCSV 以 CSV 格式输出指标，每行一个时间点，每列一个指标，缺少数据的单元格为空
+func writeMetricsCSV(w io.Writer, result MetricsResult) error {
+	writer := csv.NewWriter(w)
+	header := []string{"time"}
+	rows := map[int64][]string{}
+	for i, series := range result.Series {
+		header = append(header, series.Metric)
+		for _, point := range series.Points {
+			key := point.Time.Unix()
+			if rows[key] == nil {
+				rows[key] = make([]string, len(result.Series))
+			}
+			rows[key][i] = strconv.FormatFloat(point.Value, 'f', -1, 64)
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	timestamps := make([]int64, 0, len(rows))
+	for timestamp := range rows {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	for _, timestamp := range timestamps {
+		record := append([]string{time.Unix(timestamp, 0).Format(time.RFC3339)}, rows[timestamp]...)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
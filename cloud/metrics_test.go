@@ -0,0 +1,160 @@
+package cloud
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}); got != "▁▂▃▄▅▆▇█" {
+		t.Errorf("expected full range, got %q", got)
+	}
+	if got := sparkline([]float64{0, 0, 0}); got != "▁▁▁" {
+		t.Errorf("expected flat line for zeros, got %q", got)
+	}
+
+	points := make([]MetricPoint, 6)
+	for i := range points {
+		points[i].Value = float64(i)
+	}
+	if got := downsample(points, 3); len(got) != 3 || got[0] != 0.5 || got[2] != 4.5 {
+		t.Errorf("expected bucket averages, got %v", got)
+	}
+}
+
+func TestMetricsPeriod(t *testing.T) {
+	tests := []struct {
+		since  time.Duration
+		period int64
+	}{
+		{time.Hour, 60},
+		{24 * time.Hour, 60},
+		{3 * 24 * time.Hour, 300},
+		{30 * 24 * time.Hour, 3600},
+		{365 * 24 * time.Hour, 86400},
+	}
+	for _, tt := range tests {
+		if got := metricsPeriod(tt.since); got != tt.period {
+			t.Errorf("metricsPeriod(%v) = %v, expected %v", tt.since, got, tt.period)
+		}
+	}
+}
+
+// monitorStandIn 是云监控 API 的本地替身，按指标名称返回 data 中的数据点
+type monitorStandIn struct {
+	t      *testing.T
+	data   map[string]string
+	params []map[string]any
+}
+
+func (s *monitorStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if action := r.Header.Get("X-TC-Action"); action != "GetMonitorData" {
+		s.t.Errorf("unexpected action %v", action)
+	}
+	body, _ := io.ReadAll(r.Body)
+	var params map[string]any
+	_ = json.Unmarshal(body, &params)
+	s.params = append(s.params, params)
+
+	points := s.data[params["MetricName"].(string)]
+	_, _ = io.WriteString(w, `{"Response":{"DataPoints":[`+points+`],"RequestId":"req-1"}}`)
+}
+
+func TestMetricsCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web":  {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web"},
+			"fake": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-fake"},
+		},
+	})
+	t.Setenv("TENCENT_CLOUD_SECRET_ID", "id")
+	t.Setenv("TENCENT_CLOUD_SECRET_KEY", "key")
+
+	originalNow := timeNow
+	t.Cleanup(func() { timeNow = originalNow })
+	timeNow = func() time.Time { return time.Unix(1767225600, 0) }
+
+	standIn := &monitorStandIn{t: t, data: map[string]string{
+		"CpuUsage":      `{"Timestamps":[1767225480,1767225540],"Values":[12.5,80]}`,
+		"WanOuttraffic": `{"Timestamps":[1767225540],"Values":[3.2]}`,
+	}}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	metrics := func(args ...string) (string, string, error) {
+		return runCloudCommandSplit(t, append([]string{"metrics", "web", "--endpoint", server.URL, "--metric", "cpu,wan-out"}, args...)...)
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		stdout, stderr, err := metrics("--since", "1h", "--chart")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, stderr)
+		}
+		if !strings.Contains(stdout, "80") || !strings.Contains(stdout, "▂█") || !strings.Contains(stdout, "wan-out (WanOuttraffic, Mbps)") {
+			t.Errorf("expected stats, sparkline and charts, got:\n%s", stdout)
+		}
+
+		params := standIn.params[len(standIn.params)-2]
+		if params["Namespace"] != "QCE/LIGHTHOUSE" || params["Period"] != float64(60) || !strings.Contains(params["StartTime"].(string), "T") {
+			t.Errorf("unexpected request: %v", params)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		stdout, _, err := metrics("-o", "csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(stdout), "\n")
+		if len(lines) != 3 || lines[0] != "time,cpu,wan-out" || !strings.HasSuffix(lines[1], ",12.5,") || !strings.HasSuffix(lines[2], ",80,3.2") {
+			t.Errorf("unexpected CSV:\n%s", stdout)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		stdout, _, err := metrics("-o", "json", "--since", "7d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result MetricsResult
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		if result.Period != 3600 || len(result.Series) != 2 || len(result.Series[0].Points) != 2 || result.Series[1].Unit != "Mbps" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("ConfigEndpoint", func(t *testing.T) {
+		// 未指定 --endpoint 时使用 --endpoint-tencent 或配置中的 endpoints.tencent
+		config.SetEndpoint(config.EndpointTencent, server.URL)
+		t.Cleanup(func() { config.SetEndpoint(config.EndpointTencent, "") })
+
+		count := len(standIn.params)
+		if _, stderr, err := runCloudCommandSplit(t, "metrics", "web", "--metric", "cpu", "-o", "json"); err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, stderr)
+		}
+		if len(standIn.params) != count+1 {
+			t.Errorf("expected the request to reach the stand-in, got %d requests", len(standIn.params)-count)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, _, err := metrics("--metric", "disk"); err == nil {
+			t.Error("expected unknown metric to be rejected")
+		}
+		if _, _, err := metrics("--period", "120"); err == nil {
+			t.Error("expected unsupported period to be rejected")
+		}
+		if _, _, err := runCloudCommandSplit(t, "metrics", "fake"); ExitCode(err) != EXIT_CONFIG {
+			t.Errorf("expected fake provider to be rejected, got: %v", err)
+		}
+	})
+}
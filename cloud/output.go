@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lucky-go/output"
+)
+
+// ActionResult 是对单个目标执行一次操作的结果
+type ActionResult struct {
+	Destination string `json:"destination" yaml:"destination"`
+	InstanceId  string `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	// Action 是操作名称，如 reboot、snapshot-create、firewall-apply
+	Action string `json:"action" yaml:"action"`
+	// RequestId 是云平台返回的请求 ID
+	RequestId string `json:"request-id,omitempty" yaml:"request-id,omitempty"`
+	// State 是操作后实例或快照的状态，未等待完成时为操作进入的中间状态
+	State      string `json:"state,omitempty" yaml:"state,omitempty"`
+	SnapshotId string `json:"snapshot-id,omitempty" yaml:"snapshot-id,omitempty"`
+	// DurationMs 是操作耗时，单位为毫秒
+	DurationMs int64        `json:"duration-ms" yaml:"duration-ms"`
+	Error      *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// StatusResult 是查询单个目标实例的结果
+type StatusResult struct {
+	Destination string       `json:"destination" yaml:"destination"`
+	InstanceId  string       `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	Instance    *Instance    `json:"instance,omitempty" yaml:"instance,omitempty"`
+	Error       *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// InstanceEntry 是带有配置中目标名称的实例，实例未写入配置时 Destination 为空
+type InstanceEntry struct {
+	Destination string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	Instance    `yaml:",inline"`
+}
+
+// newActionResults 按批量操作的结果创建 ActionResult，details 中已填写的请求 ID、状态等字段会保留
+func newActionResults(action string, results []fleetResult, details []ActionResult) []ActionResult {
+	actions := make([]ActionResult, len(results))
+	for i, result := range results {
+		if details != nil {
+			actions[i] = details[i]
+		}
+		actions[i].Destination = result.Name
+		actions[i].InstanceId = result.InstanceId
+		actions[i].Action = action
+		actions[i].DurationMs = result.Duration.Milliseconds()
+		actions[i].Error = newResultError(result.Err)
+		if result.Err != nil {
+			actions[i].State = ""
+		}
+	}
+	return actions
+}
+
+// newActionResult 创建单个目标、不经过 runFleet 的操作结果
+func newActionResult(destination, instanceId, action string, start time.Time, err error) ActionResult {
+	return newActionResults(action, []fleetResult{{
+		Name:       destination,
+		InstanceId: instanceId,
+		Err:        err,
+		Duration:   timeNow().Sub(start),
+	}}, nil)[0]
+}
+
+// outputFormat 返回 -o/--output 指定的输出格式，未定义该标志时为 table
+func outputFormat(cmd *cobra.Command) (string, error) {
+	flag := cmd.Flag("output")
+	if flag == nil {
+		return output.Table, nil
+	}
+
+	format := flag.Value.String()
+	if err := output.ValidateFormat(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// progressWriter 返回进度和提示信息的输出位置：表格输出时为标准输出，
+// JSON/YAML 输出时为标准错误，保证标准输出只包含结果
+func progressWriter(cmd *cobra.Command, format string) io.Writer {
+	if format == output.Table {
+		return cmd.OutOrStdout()
+	}
+	return cmd.ErrOrStderr()
+}
@@ -0,0 +1,161 @@
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sdkerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	"gopkg.in/yaml.v3"
+
+	"lucky-go/config"
+)
+
+// runCloudCommandSplit 执行 cloud 命令并分别返回标准输出和标准错误
+func runCloudCommandSplit(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+
+	cmd := NewCommand()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs(args)
+	// 失败时 cobra 会把用法说明写到标准输出，影响结果解析
+	cmd.SilenceUsage = true
+
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestExitCode(t *testing.T) {
+	apiErr := sdkerrors.NewTencentCloudSDKError("AuthFailure.SignatureFailure", "签名错误", "req-123")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Nil", err: nil, expected: 0},
+		{name: "General", err: errors.New("boom"), expected: EXIT_ERROR},
+		{name: "NotFound", err: fmt.Errorf("解析失败: %w", config.ErrDestinationNotFound), expected: EXIT_NOT_FOUND},
+		{name: "Config", err: configErrorf("目标 web 未配置 region 和 instance-id"), expected: EXIT_CONFIG},
+		{name: "API", err: fmt.Errorf("查询失败: %w", apiErr), expected: EXIT_API},
+		{name: "Timeout", err: timeoutErrorf("等待超时"), expected: EXIT_TIMEOUT},
+		{name: "Remote", err: &remoteExitError{code: 5}, expected: EXIT_REMOTE},
+		{
+			name:     "FleetSameCause",
+			err:      fleetError("重启", []fleetResult{{Name: "a", Err: apiErr}, {Name: "b", Err: apiErr}, {Name: "c"}}),
+			expected: EXIT_API,
+		},
+		{
+			name:     "FleetMixedCauses",
+			err:      fleetError("重启", []fleetResult{{Name: "a", Err: apiErr}, {Name: "b", Err: timeoutErrorf("等待超时")}}),
+			expected: EXIT_ERROR,
+		},
+		{name: "Wrapped", err: &exitError{error: errors.New("boom"), code: EXIT_TIMEOUT}, expected: EXIT_TIMEOUT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.err); code != tt.expected {
+				t.Errorf("expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+
+	result := newResultError(fmt.Errorf("重启失败: %w", apiErr))
+	if result.Kind != errorKindAPI || result.Code != "AuthFailure.SignatureFailure" || result.RequestId != "req-123" {
+		t.Errorf("unexpected result error: %+v", result)
+	}
+}
+
+func TestMachineReadableOutput(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web-1": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web1"},
+			"web-2": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web2"},
+			"bare":  {Ssh: "root@1.1.1.3"},
+		},
+	})
+	advance := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	t.Run("RebootJSON", func(t *testing.T) {
+		stdout, stderr, err := runCloudCommandSplit(t, "reboot", "web-*", "-o", "json", "--yes")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(stderr, "已提交重启请求") {
+			t.Errorf("expected progress on stderr, got: %q", stderr)
+		}
+
+		var results []ActionResult
+		if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected JSON on stdout, got %q: %v", stdout, err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 results, got: %+v", results)
+		}
+		for i, name := range []string{"web-1", "web-2"} {
+			result := results[i]
+			if result.Destination != name || result.Action != "reboot" || result.State != "REBOOTING" || result.RequestId == "" || result.Error != nil {
+				t.Errorf("unexpected result for %v: %+v", name, result)
+			}
+		}
+	})
+
+	t.Run("StatusYAML", func(t *testing.T) {
+		stdout, _, err := runCloudCommandSplit(t, "status", "web-1", "-o", "yaml")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		var results []StatusResult
+		if err := yaml.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected YAML on stdout, got %q: %v", stdout, err)
+		}
+		if len(results) != 1 || results[0].Instance == nil || results[0].Instance.InstanceId != "lhins-web1" {
+			t.Errorf("unexpected results: %+v", results)
+		}
+	})
+
+	t.Run("PartialFailure", func(t *testing.T) {
+		advance(time.Minute)
+		stdout, _, err := runCloudCommandSplit(t, "stop", "web-2", "bare", "-o", "json", "--yes")
+		if code := ExitCode(err); code != EXIT_CONFIG {
+			t.Errorf("expected exit code %d, got %d: %v", EXIT_CONFIG, code, err)
+		}
+
+		var results []ActionResult
+		if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected JSON on stdout, got %q: %v", stdout, err)
+		}
+		if len(results) != 2 || results[0].Destination != "bare" || results[0].Error == nil || results[0].Error.Kind != errorKindConfig {
+			t.Errorf("expected config error for bare, got: %+v", results)
+		}
+		if results[1].Error != nil || results[1].State != "STOPPING" {
+			t.Errorf("expected web-2 to be stopping, got: %+v", results[1])
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, _, err := runCloudCommandSplit(t, "reboot", "nope", "-o", "json")
+		if code := ExitCode(err); code != EXIT_NOT_FOUND {
+			t.Errorf("expected exit code %d, got %d: %v", EXIT_NOT_FOUND, code, err)
+		}
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		_, _, err := runCloudCommandSplit(t, "status", "-o", "xml")
+		if err == nil || !strings.Contains(err.Error(), "不支持的输出格式") {
+			t.Errorf("expected unknown format error, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package cloud
+
+import (
+	"sort"
+	"strings"
+
+	"lucky-go/config"
+)
+
+// Provider 是云平台的抽象，每个实例对应一个地域。
+type Provider interface {
+	// Reboot 重启实例，返回云平台的请求 ID
+	Reboot(instanceId string) (string, error)
+	// Start 开机实例，返回云平台的请求 ID
+	Start(instanceId string) (string, error)
+	// Stop 关机实例，返回云平台的请求 ID
+	Stop(instanceId string) (string, error)
+	// Describe 查询实例的当前状态
+	Describe(instanceId string) (*Instance, error)
+	// List 列出地域内的全部实例
+	List() ([]Instance, error)
+}
+
+// providerFactories 把 provider 名称映射到创建指定地域和账号 Provider 的函数
+var providerFactories = map[string]func(region, account string) (Provider, error){
+	config.ProviderLighthouse: newLighthouseProvider,
+	config.ProviderCVM:        newCVMProvider,
+	config.ProviderFake:       newFakeProvider,
+}
+
+// NewProvider 按名称创建指定地域的 Provider，名称为空时使用轻量应用服务器。
+// account 是配置中 accounts 的名称，为空时使用默认凭证。
+func NewProvider(name, region, account string) (Provider, error) {
+	if name == "" {
+		name = config.ProviderLighthouse
+	}
+
+	factory, ok := providerFactories[name]
+	if !ok {
+		names := make([]string, 0, len(providerFactories))
+		for name := range providerFactories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, configErrorf("不支持的 provider %v，可用: %v", name, strings.Join(names, "、"))
+	}
+
+	return factory(region, account)
+}
+
+// destinationProvider 创建目标实例所属的 Provider
+func destinationProvider(dest *config.DestinationInstance) (Provider, error) {
+	return NewProvider(dest.Provider, dest.Region, dest.Account)
+}
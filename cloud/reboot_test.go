@@ -17,11 +17,11 @@ func TestRebootInstance(t *testing.T) {
 		}()
 
 		// 模拟成功重启
-		rebootInstanceFunc = func(dest *config.DestinationInstance) error {
+		rebootInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
 			if dest.Region != "ap-beijing" || dest.InstanceId != "ins-test123" {
-				return errors.New("unexpected destination instance values")
+				return "", errors.New("unexpected destination instance values")
 			}
-			return nil
+			return "", nil
 		}
 
 		dest := &config.DestinationInstance{
@@ -29,7 +29,7 @@ func TestRebootInstance(t *testing.T) {
 			InstanceId: "ins-test123",
 		}
 
-		err := RebootInstance(dest)
+		_, err := RebootInstance(dest)
 		if err != nil {
 			t.Errorf("expected no error, got: %v", err)
 		}
@@ -44,8 +44,8 @@ func TestRebootInstance(t *testing.T) {
 
 		// 模拟重启失败
 		expectedErr := errors.New("reboot failed")
-		rebootInstanceFunc = func(dest *config.DestinationInstance) error {
-			return expectedErr
+		rebootInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
+			return "", expectedErr
 		}
 
 		dest := &config.DestinationInstance{
@@ -53,7 +53,7 @@ func TestRebootInstance(t *testing.T) {
 			InstanceId: "ins-test456",
 		}
 
-		err := RebootInstance(dest)
+		_, err := RebootInstance(dest)
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
@@ -80,18 +80,18 @@ func TestRebootInstance(t *testing.T) {
 		}()
 
 		// 模拟使用环境变量的重启函数
-		rebootInstanceFunc = func(dest *config.DestinationInstance) error {
+		rebootInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
 			secretID := os.Getenv("TENCENT_CLOUD_SECRET_ID")
 			secretKey := os.Getenv("TENCENT_CLOUD_SECRET_KEY")
 
 			if secretID != "test_secret_id" || secretKey != "test_secret_key" {
-				return errors.New("environment variables not set correctly")
+				return "", errors.New("environment variables not set correctly")
 			}
 
 			if dest.Region != "ap-guangzhou" || dest.InstanceId != "ins-test789" {
-				return errors.New("unexpected destination instance values")
+				return "", errors.New("unexpected destination instance values")
 			}
-			return nil
+			return "", nil
 		}
 
 		dest := &config.DestinationInstance{
@@ -99,7 +99,7 @@ func TestRebootInstance(t *testing.T) {
 			InstanceId: "ins-test789",
 		}
 
-		err := RebootInstance(dest)
+		_, err := RebootInstance(dest)
 		if err != nil {
 			t.Errorf("expected no error, got: %v", err)
 		}
@@ -0,0 +1,278 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"lucky-go/config"
+)
+
+// SCHEDULER_STATE_ENV 指定定时开关机状态文件的路径
+const SCHEDULER_STATE_ENV = "LUCKY_GO_SCHEDULER_STATE"
+
+// SCHEDULER_STATE_FILE 是未设置 SCHEDULER_STATE_ENV 时定时开关机状态文件的名称，位于 ~/.lucky-go 下
+const SCHEDULER_STATE_FILE = "scheduler-state.json"
+
+// scheduleLookback 是补执行错过的计划时最多回溯的时间，更早的计划不再执行
+const scheduleLookback = 7 * 24 * time.Hour
+
+// 计划的操作
+const (
+	scheduleStart = "start"
+	scheduleStop  = "stop"
+)
+
+// scheduleEvent 是计划中的一次开机或关机
+type scheduleEvent struct {
+	Action string
+	At     time.Time
+}
+
+// target 返回操作完成后实例应处于的状态
+func (event scheduleEvent) target() string {
+	if event.Action == scheduleStart {
+		return "RUNNING"
+	}
+	return "STOPPED"
+}
+
+// verb 返回操作的中文名称
+func (event scheduleEvent) verb() string {
+	if event.Action == scheduleStart {
+		return "开机"
+	}
+	return "关机"
+}
+
+// latestScheduleEvent 返回 (since, now] 内最近的一次计划操作。
+// 开机和关机计划在同一分钟时以关机为准。
+func latestScheduleEvent(schedule *config.Schedule, since, now time.Time) (scheduleEvent, bool, error) {
+	location, err := schedule.Location()
+	if err != nil {
+		return scheduleEvent{}, false, err
+	}
+
+	var latest scheduleEvent
+	found := false
+	for _, item := range []struct{ action, expr string }{{scheduleStart, schedule.Start}, {scheduleStop, schedule.Stop}} {
+		if item.expr == "" {
+			continue
+		}
+		cron, err := config.ParseCron(item.expr)
+		if err != nil {
+			return scheduleEvent{}, false, err
+		}
+
+		var last time.Time
+		for next := cron.Next(since.In(location)); !next.IsZero() && !next.After(now); next = cron.Next(next) {
+			last = next
+		}
+		if last.IsZero() {
+			continue
+		}
+		if !found || last.After(latest.At) || (last.Equal(latest.At) && item.action == scheduleStop) {
+			latest, found = scheduleEvent{Action: item.action, At: last}, true
+		}
+	}
+	return latest, found, nil
+}
+
+// nextScheduleEvent 返回 now 之后的下一次计划操作
+func nextScheduleEvent(schedule *config.Schedule, now time.Time) (scheduleEvent, bool) {
+	location, err := schedule.Location()
+	if err != nil {
+		return scheduleEvent{}, false
+	}
+
+	var next scheduleEvent
+	found := false
+	for _, item := range []struct{ action, expr string }{{scheduleStart, schedule.Start}, {scheduleStop, schedule.Stop}} {
+		cron, err := config.ParseCron(item.expr)
+		if item.expr == "" || err != nil {
+			continue
+		}
+		at := cron.Next(now.In(location))
+		if !at.IsZero() && (!found || at.Before(next.At)) {
+			next, found = scheduleEvent{Action: item.action, At: at}, true
+		}
+	}
+	return next, found
+}
+
+// schedulerState 是定时开关机的状态，记录每个目标最近一次已处理的计划时间，
+// 守护进程重启后据此判断哪些计划是停止期间错过的。
+type schedulerState struct {
+	Applied map[string]time.Time `json:"applied"`
+}
+
+// loadSchedulerState 读取状态文件，文件不存在时返回空状态
+func loadSchedulerState(path string) (*schedulerState, error) {
+	state := &schedulerState{}
+	if err := readStateFile(path, state); err != nil {
+		return nil, err
+	}
+
+	if state.Applied == nil {
+		state.Applied = map[string]time.Time{}
+	}
+	return state, nil
+}
+
+// ScheduleResult 是对单个目标执行一次计划的结果
+type ScheduleResult struct {
+	Destination string `json:"destination" yaml:"destination"`
+	InstanceId  string `json:"instance-id" yaml:"instance-id"`
+	// Action 是计划的操作：start 或 stop
+	Action      string    `json:"action" yaml:"action"`
+	ScheduledAt time.Time `json:"scheduled-at" yaml:"scheduled-at"`
+	// Status 是处理结果：applied（已执行）、unchanged（实例已处于目标状态）、
+	// pending（实例处于中间状态，下次重试）、skipped（受保护）、dry-run 或 failed
+	Status    string       `json:"status" yaml:"status"`
+	RequestId string       `json:"request-id,omitempty" yaml:"request-id,omitempty"`
+	Error     *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// scheduler 按目标的 schedule 开关机实例
+type scheduler struct {
+	out   io.Writer
+	path  string
+	state *schedulerState
+	flags mutationFlags
+	// interval 是检查间隔，计划时间早于上一次检查时视为补执行
+	interval time.Duration
+	// failed 记录已推送过失败通知的计划，重试时不再重复推送
+	failed map[string]time.Time
+}
+
+// newScheduler 读取状态文件并创建 scheduler
+func newScheduler(out io.Writer, flags mutationFlags, interval time.Duration) (*scheduler, error) {
+	path, err := stateFilePath(SCHEDULER_STATE_ENV, SCHEDULER_STATE_FILE)
+	if err != nil {
+		return nil, err
+	}
+	state, err := loadSchedulerState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduler{out: out, path: path, state: state, flags: flags, interval: interval, failed: map[string]time.Time{}}, nil
+}
+
+// tick 检查每个目标在上次处理之后是否有新的计划操作，有则执行，返回执行的结果
+func (s *scheduler) tick(dests []config.Destination) ([]ScheduleResult, error) {
+	now := timeNow()
+	var results []ScheduleResult
+	changed := false
+	for i := range dests {
+		dest := &dests[i]
+		if dest.Schedule == nil {
+			continue
+		}
+
+		since := now.Add(-scheduleLookback)
+		if last, ok := s.state.Applied[dest.Name]; ok && last.After(since) {
+			since = last
+		}
+		event, ok, err := latestScheduleEvent(dest.Schedule, since, now)
+		if err != nil {
+			return results, fmt.Errorf("%v: %w", dest.Name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		result := s.apply(dest, event, now)
+		switch result.Status {
+		case "applied", "unchanged", "skipped":
+			s.state.Applied[dest.Name] = event.At
+			delete(s.failed, dest.Name)
+			changed = true
+		}
+		results = append(results, result)
+	}
+
+	if changed && !s.flags.DryRun {
+		if err := writeStateFile(s.path, s.state); err != nil {
+			return results, fmt.Errorf("保存定时开关机状态文件失败: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// apply 对目标执行计划操作，实例已处于目标状态时不做任何操作
+func (s *scheduler) apply(dest *config.Destination, event scheduleEvent, now time.Time) ScheduleResult {
+	result := ScheduleResult{Destination: dest.Name, InstanceId: dest.InstanceId, Action: event.Action, ScheduledAt: event.At}
+	scheduled := event.At.Format("2006-01-02 15:04")
+	// 计划时间早于上一次检查，说明是守护进程停止期间错过的计划
+	late := now.Sub(event.At) > s.interval
+
+	fail := func(err error) ScheduleResult {
+		result.Status, result.Error = "failed", newResultError(err)
+		fmt.Fprintf(s.out, "%v: 按计划%v失败: %v\n", dest.Name, event.verb(), err)
+		if at, ok := s.failed[dest.Name]; !ok || !at.Equal(event.At) {
+			s.failed[dest.Name] = event.At
+			s.notify(fmt.Sprintf("*定时开关机失败*\n\n%v (%v) 按计划%v失败\n计划时间: %v\n错误: %v", dest.Name, dest.InstanceId, event.verb(), scheduled, err))
+		}
+		return result
+	}
+
+	if err := requireInstance(dest); err != nil {
+		return fail(err)
+	}
+	instance, err := DescribeInstance(&dest.DestinationInstance)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch {
+	case instance.State == event.target():
+		result.Status = "unchanged"
+		fmt.Fprintf(s.out, "%v: 计划 %v %v，实例已是 %v\n", dest.Name, scheduled, event.verb(), instance.State)
+		return result
+	case instance.State != "RUNNING" && instance.State != "STOPPED":
+		result.Status = "pending"
+		fmt.Fprintf(s.out, "%v: 计划 %v %v，实例当前状态为 %v，稍后重试\n", dest.Name, scheduled, event.verb(), instance.State)
+		return result
+	}
+
+	if event.Action == scheduleStop {
+		if err := requireUnprotected([]config.Destination{*dest}, "定时关机", s.flags); err != nil {
+			result.Status, result.Error = "skipped", newResultError(err)
+			fmt.Fprintf(s.out, "%v: 跳过计划 %v 关机: %v\n", dest.Name, scheduled, err)
+			return result
+		}
+	}
+
+	if s.flags.DryRun {
+		result.Status = "dry-run"
+		fmt.Fprintf(s.out, "[dry-run] %v: 将按计划 %v %v（当前状态 %v）\n", dest.Name, scheduled, event.verb(), instance.State)
+		return result
+	}
+
+	run := StartInstance
+	if event.Action == scheduleStop {
+		run = StopInstance
+	}
+	requestId, err := run(&dest.DestinationInstance)
+	if err != nil {
+		return fail(err)
+	}
+
+	result.Status, result.RequestId = "applied", requestId
+	fmt.Fprintf(s.out, "%v: 已按计划 %v %v，请求 ID: %v\n", dest.Name, scheduled, event.verb(), requestId)
+
+	message := fmt.Sprintf("*定时开关机*\n\n已按计划%v %v (%v)\n计划时间: %v", event.verb(), dest.Name, dest.InstanceId, scheduled)
+	if late {
+		message += "\n（守护进程停止期间错过，已补执行）"
+	}
+	s.notify(message)
+	return result
+}
+
+// notify 推送通知，失败时只输出错误，不影响计划的执行
+func (s *scheduler) notify(message string) {
+	if err := sendNotification(message); err != nil {
+		fmt.Fprintf(s.out, "推送通知失败: %v\n", err)
+	}
+}
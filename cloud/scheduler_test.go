@@ -0,0 +1,185 @@
+package cloud
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestLatestScheduleEvent(t *testing.T) {
+	schedule := &config.Schedule{Start: "0 9 * * 1-5", Stop: "0 21 * * *", Timezone: "UTC"}
+	// 2026-01-02 是星期五
+	friday := func(hour, minute int) time.Time { return time.Date(2026, 1, 2, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		since, now time.Time
+		action     string
+		at         time.Time
+	}{
+		{"Morning", friday(0, 0), friday(12, 0), scheduleStart, friday(9, 0)},
+		{"Evening", friday(0, 0), friday(21, 0), scheduleStop, friday(21, 0)},
+		{"Weekend", friday(0, 0), time.Date(2026, 1, 4, 12, 0, 0, 0, time.UTC), scheduleStop, time.Date(2026, 1, 3, 21, 0, 0, 0, time.UTC)},
+		{"AlreadyApplied", friday(9, 0), friday(12, 0), "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok, err := latestScheduleEvent(schedule, tt.since, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.action == "" {
+				if ok {
+					t.Errorf("expected no event, got %+v", event)
+				}
+				return
+			}
+			if !ok || event.Action != tt.action || !event.At.Equal(tt.at) {
+				t.Errorf("expected %v at %v, got %+v (%v)", tt.action, tt.at, event, ok)
+			}
+		})
+	}
+
+	// 同一分钟既开机又关机时以关机为准
+	same := &config.Schedule{Start: "0 21 * * *", Stop: "0 21 * * *", Timezone: "UTC"}
+	if event, _, _ := latestScheduleEvent(same, friday(0, 0), friday(22, 0)); event.Action != scheduleStop {
+		t.Errorf("expected stop to win a tie, got %+v", event)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	advanceFake := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { fakeMemory = originalMemory })
+
+	schedule := &config.Schedule{Start: "0 9 * * *", Stop: "0 21 * * *", Timezone: "UTC"}
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"dev":   {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-dev", Schedule: schedule},
+			"vault": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-vault", Protected: true, Schedule: &config.Schedule{Stop: "0 21 * * *", Timezone: "UTC"}},
+			"prod":  {Ssh: "root@1.1.1.3", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-prod"},
+		},
+	})
+	t.Setenv(SCHEDULER_STATE_ENV, filepath.Join(t.TempDir(), "scheduler-state.json"))
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	originalNow, originalSend := timeNow, sendNotification
+	t.Cleanup(func() { timeNow, sendNotification = originalNow, originalSend })
+	timeNow = func() time.Time { return now }
+	var messages []string
+	sendNotification = func(message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	// tick 把两个时钟推进到 at 并检查一次计划，返回 dev 的结果
+	tick := func(at time.Time, args ...string) ScheduleResult {
+		t.Helper()
+		advanceFake(at.Sub(now))
+		now = at
+
+		stdout, stderr, err := runCloudCommandSplit(t, append([]string{"scheduler", "run", "--once", "-o", "json"}, args...)...)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, stderr)
+		}
+		var results []ScheduleResult
+		if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		for _, result := range results {
+			if result.Destination == "prod" {
+				t.Errorf("expected destinations without schedule to be ignored, got %+v", result)
+			}
+			if result.Destination == "dev" {
+				return result
+			}
+		}
+		return ScheduleResult{}
+	}
+	state := func() string {
+		instance, err := DescribeInstance(&config.DestinationInstance{Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-dev"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return instance.State
+	}
+
+	t.Run("AlreadyRunning", func(t *testing.T) {
+		if result := tick(now); result.Status != "unchanged" || len(messages) != 0 {
+			t.Errorf("expected no action for a running instance, got %+v %v", result, messages)
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		result := tick(time.Date(2026, 1, 1, 21, 0, 30, 0, time.UTC))
+		if result.Status != "applied" || result.Action != scheduleStop {
+			t.Fatalf("expected scheduled stop, got %+v", result)
+		}
+		if len(messages) != 1 || !strings.Contains(messages[0], "已按计划关机 dev") || strings.Contains(messages[0], "补执行") {
+			t.Errorf("expected a stop notification, got %v", messages)
+		}
+
+		if result := tick(time.Date(2026, 1, 1, 21, 5, 0, 0, time.UTC)); result.Status != "" {
+			t.Errorf("expected the stop to run only once, got %+v", result)
+		}
+		if state() != "STOPPED" {
+			t.Errorf("expected instance to be stopped, got %v", state())
+		}
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		stdout, _, _ := runCloudCommandSplit(t, "scheduler", "list", "-o", "json")
+		var entries []ScheduleEntry
+		if err := json.Unmarshal([]byte(stdout), &entries); err != nil || len(entries) != 2 {
+			t.Fatalf("expected two scheduled destinations, got %q: %v", stdout, err)
+		}
+		for _, entry := range entries {
+			if entry.Destination == "vault" && (entry.LastApplied == nil || entry.NextAction != scheduleStop) {
+				t.Errorf("expected the skipped stop on vault to be recorded, got %+v", entry)
+			}
+		}
+		if instance, _ := DescribeInstance(&config.DestinationInstance{Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-vault"}); instance.State != "RUNNING" {
+			t.Errorf("expected protected instance to keep running, got %v", instance.State)
+		}
+	})
+
+	t.Run("CatchUp", func(t *testing.T) {
+		// 守护进程在 1 月 2 日 9 点开机时没有运行
+		result := tick(time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC))
+		if result.Status != "applied" || result.Action != scheduleStart || !result.ScheduledAt.Equal(time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)) {
+			t.Fatalf("expected the missed start to be caught up, got %+v", result)
+		}
+		if last := messages[len(messages)-1]; !strings.Contains(last, "已按计划开机 dev") || !strings.Contains(last, "补执行") {
+			t.Errorf("expected a catch-up notification, got %v", last)
+		}
+	})
+
+	t.Run("ManualOverride", func(t *testing.T) {
+		// 计划开机后手动关机，之后的检查不会再次开机
+		advanceFake(time.Minute)
+		if _, err := StopInstance(&config.DestinationInstance{Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-dev"}); err != nil {
+			t.Fatal(err)
+		}
+		if result := tick(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)); result.Status != "" || state() != "STOPPED" {
+			t.Errorf("expected manual stop to be left alone, got %+v %v", result, state())
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		count := len(messages)
+		at := time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC)
+		if result := tick(at, "--dry-run"); result.Status != "dry-run" || len(messages) != count || state() != "STOPPED" {
+			t.Fatalf("expected dry run to change nothing, got %+v %v", result, state())
+		}
+		if result := tick(at); result.Status != "applied" {
+			t.Errorf("expected the start to run after a dry run, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"lucky-go/config"
+)
+
+// AUTO_SNAPSHOT_PREFIX 是 lucky-go 自动创建的快照名称前缀，保留策略只清理带有该前缀的快照
+const AUTO_SNAPSHOT_PREFIX = "lucky-go-"
+
+// Snapshot 表示实例的一个快照。
+type Snapshot struct {
+	SnapshotId string `json:"snapshot-id" yaml:"snapshot-id"`
+	Name       string `json:"name" yaml:"name"`
+	// State 是快照状态，如 CREATING、NORMAL、ROLLBACKING
+	State string `json:"state" yaml:"state"`
+	// Percent 是创建或回滚的进度
+	Percent     int64  `json:"percent" yaml:"percent"`
+	CreatedTime string `json:"created-time,omitempty" yaml:"created-time,omitempty"`
+}
+
+// SnapshotProvider 是支持快照的 Provider 实现的可选接口。
+type SnapshotProvider interface {
+	// CreateSnapshot 为实例创建快照并返回快照 ID
+	CreateSnapshot(instanceId, name string) (string, error)
+	// ListSnapshots 列出实例的全部快照
+	ListSnapshots(instanceId string) ([]Snapshot, error)
+	// DeleteSnapshot 删除快照
+	DeleteSnapshot(snapshotId string) error
+	// RestoreSnapshot 把实例回滚到快照
+	RestoreSnapshot(instanceId, snapshotId string) error
+}
+
+// destinationSnapshots 返回目标所属 Provider 的快照能力，不支持时返回错误
+func destinationSnapshots(dest *config.DestinationInstance) (SnapshotProvider, error) {
+	provider, err := destinationProvider(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, ok := provider.(SnapshotProvider)
+	if !ok {
+		return nil, configErrorf("provider %v 不支持快照", providerName(dest))
+	}
+	return snapshots, nil
+}
+
+// providerName 返回目标的 provider 名称，未设置时为 lighthouse
+func providerName(dest *config.DestinationInstance) string {
+	if dest.Provider == "" {
+		return config.ProviderLighthouse
+	}
+	return dest.Provider
+}
+
+// autoSnapshotName 生成自动快照的名称，如 lucky-go-web-20260101-150405
+func autoSnapshotName(name string) string {
+	return AUTO_SNAPSHOT_PREFIX + name + "-" + timeNow().Format("20060102-150405")
+}
+
+// createSnapshotAndWait 为目标创建快照并等待其可用，返回快照 ID
+func createSnapshotAndWait(dest *config.Destination, name string, opts waitOptions) (string, error) {
+	snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+	if err != nil {
+		return "", err
+	}
+
+	snapshotId, err := snapshots.CreateSnapshot(dest.InstanceId, name)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(opts.Out, "%v: 已提交快照 %v（%v）\n", dest.Name, name, snapshotId)
+
+	return snapshotId, waitForSnapshot(dest, snapshots, snapshotId, opts)
+}
+
+// waitForSnapshot 轮询快照直到状态为 NORMAL
+func waitForSnapshot(dest *config.Destination, snapshots SnapshotProvider, snapshotId string, opts waitOptions) error {
+	start := timeNow()
+	deadline := start.Add(opts.Timeout)
+	lastProgress := ""
+
+	for {
+		list, err := snapshots.ListSnapshots(dest.InstanceId)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, snapshot := range list {
+			if snapshot.SnapshotId != snapshotId {
+				continue
+			}
+			found = true
+
+			switch snapshot.State {
+			case "NORMAL":
+				fmt.Fprintf(opts.Out, "%v: 快照 %v 已完成 (%v)\n", dest.Name, snapshotId, timeNow().Sub(start).Round(time.Second))
+				return nil
+			case "FAILED":
+				return fmt.Errorf("快照 %v 创建失败", snapshotId)
+			}
+
+			progress := fmt.Sprintf("%v %d%%", snapshot.State, snapshot.Percent)
+			if progress != lastProgress {
+				fmt.Fprintf(opts.Out, "%v: 快照 %v (%v)\n", dest.Name, progress, timeNow().Sub(start).Round(time.Second))
+				lastProgress = progress
+			}
+		}
+		if !found {
+			return fmt.Errorf("快照 %v 不存在", snapshotId)
+		}
+
+		if !timeNow().Before(deadline) {
+			return timeoutErrorf("等待快照 %v 完成超时（%v）", snapshotId, opts.Timeout)
+		}
+		sleepFunc(opts.Interval)
+	}
+}
+
+// expiredSnapshots 按保留策略返回应删除的自动快照。
+// 只考虑已完成的自动快照，并且始终保留最新的一个。
+func expiredSnapshots(snapshots []Snapshot, policy config.SnapshotPolicy) ([]Snapshot, error) {
+	age, err := policy.Age()
+	if err != nil {
+		return nil, err
+	}
+	if policy.Keep == 0 && age == 0 {
+		return nil, nil
+	}
+
+	var auto []Snapshot
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Name, AUTO_SNAPSHOT_PREFIX) && snapshot.State == "NORMAL" {
+			auto = append(auto, snapshot)
+		}
+	}
+	// 创建时间为 ISO 8601 格式，按字符串倒序即按时间从新到旧
+	sort.SliceStable(auto, func(i, j int) bool { return auto[i].CreatedTime > auto[j].CreatedTime })
+
+	var expired []Snapshot
+	for i, snapshot := range auto {
+		if i == 0 {
+			continue
+		}
+		if policy.Keep > 0 && i >= policy.Keep {
+			expired = append(expired, snapshot)
+			continue
+		}
+		if age > 0 {
+			created, err := time.Parse(time.RFC3339, snapshot.CreatedTime)
+			if err == nil && timeNow().Sub(created) > age {
+				expired = append(expired, snapshot)
+			}
+		}
+	}
+
+	return expired, nil
+}
+
+// destinationExpiredSnapshots 返回目标按保留策略应当删除的自动快照
+func destinationExpiredSnapshots(dest *config.Destination) (SnapshotProvider, []Snapshot, error) {
+	policy, err := config.LoadSnapshotPolicy(dest.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshots, err := destinationSnapshots(&dest.DestinationInstance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := snapshots.ListSnapshots(dest.InstanceId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expired, err := expiredSnapshots(list, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshots, expired, nil
+}
+
+// pruneSnapshots 按目标的保留策略删除过期的自动快照
+func pruneSnapshots(dest *config.Destination, out io.Writer) error {
+	snapshots, expired, err := destinationExpiredSnapshots(dest)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range expired {
+		if err := snapshots.DeleteSnapshot(snapshot.SnapshotId); err != nil {
+			return fmt.Errorf("删除快照 %v 失败: %w", snapshot.SnapshotId, err)
+		}
+		fmt.Fprintf(out, "%v: 已按保留策略删除快照 %v（%v）\n", dest.Name, snapshot.Name, snapshot.SnapshotId)
+	}
+
+	return nil
+}
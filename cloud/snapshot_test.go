@@ -0,0 +1,151 @@
+package cloud
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestExpiredSnapshots(t *testing.T) {
+	originalNow := timeNow
+	defer func() { timeNow = originalNow }()
+	timeNow = func() time.Time { return time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC) }
+
+	snapshots := []Snapshot{
+		{SnapshotId: "s1", Name: "lucky-go-web-1", State: "NORMAL", CreatedTime: "2026-01-01T00:00:00Z"},
+		{SnapshotId: "s2", Name: "lucky-go-web-2", State: "NORMAL", CreatedTime: "2026-01-20T00:00:00Z"},
+		{SnapshotId: "s3", Name: "manual", State: "NORMAL", CreatedTime: "2025-01-01T00:00:00Z"},
+		{SnapshotId: "s4", Name: "lucky-go-web-4", State: "NORMAL", CreatedTime: "2026-01-30T00:00:00Z"},
+		{SnapshotId: "s5", Name: "lucky-go-web-5", State: "CREATING", CreatedTime: "2025-12-01T00:00:00Z"},
+	}
+
+	tests := []struct {
+		name     string
+		policy   config.SnapshotPolicy
+		snapshot []Snapshot
+		expected []string
+	}{
+		{"NoPolicy", config.SnapshotPolicy{}, snapshots, nil},
+		{"Keep", config.SnapshotPolicy{Keep: 2}, snapshots, []string{"s1"}},
+		{"MaxAge", config.SnapshotPolicy{MaxAge: "7d"}, snapshots, []string{"s2", "s1"}},
+		{"KeepAndMaxAge", config.SnapshotPolicy{Keep: 1, MaxAge: "30d"}, snapshots, []string{"s2", "s1"}},
+		{"NewestIsAlwaysKept", config.SnapshotPolicy{MaxAge: "1h"}, snapshots[:1], nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expired, err := expiredSnapshots(tt.snapshot, tt.policy)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			var ids []string
+			for _, snapshot := range expired {
+				ids = append(ids, snapshot.SnapshotId)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v to expire, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func TestSnapshotCommands(t *testing.T) {
+	advance := useFakeClock(t)
+	t.Setenv(FAKE_CLOUD_ENV, "memory")
+
+	// 等待时推进 fake provider 的时钟，让快照完成
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	originalNow, originalSleep := timeNow, sleepFunc
+	timeNow = func() time.Time { return now }
+	sleepFunc = func(d time.Duration) {
+		now = now.Add(d)
+		advance(d)
+	}
+	originalMemory := fakeMemory
+	fakeMemory = &fakeState{}
+	t.Cleanup(func() { timeNow, sleepFunc, fakeMemory = originalNow, originalSleep, originalMemory })
+
+	setupCloudConfig(t, config.Config{
+		Snapshots: &config.SnapshotPolicy{Keep: 2},
+		Dest: map[string]config.DestinationInstance{
+			"web": {Ssh: "root@1.1.1.1", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-web"},
+			"db":  {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-db"},
+			// 保留策略无法解析，清理快照会失败
+			"cache": {Ssh: "root@1.1.1.3", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-cache", Snapshots: &config.SnapshotPolicy{MaxAge: "month"}},
+		},
+	})
+
+	if _, err := runCloudCommand(t, "snapshot", "create", "web", "--name", "manual"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		out, err := runCloudCommand(t, "reboot", "web", "--snapshot", "--interval", "5s", "--yes")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+		if !strings.Contains(out, "已完成") || !strings.Contains(out, "已提交重启请求") {
+			t.Errorf("expected snapshot to finish before reboot, got:\n%s", out)
+		}
+		advance(time.Minute)
+		now = now.Add(time.Minute)
+	}
+
+	// 清理快照失败只输出警告，不取消已提交的重启
+	out, err := runCloudCommand(t, "reboot", "cache", "--snapshot", "--interval", "5s", "--yes")
+	if err != nil {
+		t.Fatalf("expected a pruning failure not to fail the reboot, got: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "已提交重启请求") || !strings.Contains(out, "警告: cache: 按保留策略清理快照失败") {
+		t.Errorf("expected the reboot to be submitted with a pruning warning, got:\n%s", out)
+	}
+
+	out, err = runCloudCommand(t, "snapshot", "list", "web")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if strings.Count(out, "lucky-go-web-") != 2 || !strings.Contains(out, "manual") {
+		t.Errorf("expected two auto snapshots and the manual one to be kept, got:\n%s", out)
+	}
+	if strings.Contains(out, "lhsnap-00000002") {
+		t.Errorf("expected the oldest auto snapshot to be pruned, got:\n%s", out)
+	}
+
+	if _, err := runCloudCommand(t, "snapshot", "delete", "db", "lhsnap-00000001"); err == nil || !strings.Contains(err.Error(), "不存在快照") {
+		t.Errorf("expected deleting another instance's snapshot to fail, got: %v", err)
+	}
+
+	restore := func(input string, args ...string) (string, error) {
+		cmd := NewCommand()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(append([]string{"snapshot", "restore", "web", "lhsnap-00000001"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := restore("n\n"); err == nil || !strings.Contains(err.Error(), "已取消") {
+		t.Errorf("expected restore to be cancelled, got: %v", err)
+	}
+	if out, err := restore("", "--yes"); err != nil || !strings.Contains(out, "已提交回滚") {
+		t.Errorf("expected restore to be submitted, got: %v\n%s", err, out)
+	}
+
+	if _, err := runCloudCommand(t, "snapshot", "delete", "web", "lhsnap-00000001", "--yes"); err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+
+	// --snapshot 使用 --snapshot-timeout，不受 --wait 的 --timeout 限制
+	if out, err := runCloudCommand(t, "reboot", "db", "--snapshot", "--timeout", "1s", "--snapshot-timeout", "1m", "--yes"); err != nil {
+		t.Errorf("expected the snapshot to use --snapshot-timeout, got: %v\n%s", err, out)
+	}
+	out, err = runCloudCommand(t, "reboot", "db", "--snapshot", "--snapshot-timeout", "1s", "--yes")
+	if err == nil || strings.Contains(out, "已提交重启请求") {
+		t.Errorf("expected the reboot to be skipped when the snapshot times out, got: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,54 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"lucky-go/config"
+)
+
+// stateFilePath 返回状态文件的路径：环境变量 env 指定的路径，未设置时为 ~/.lucky-go 下的 name
+func stateFilePath(env, name string) (string, error) {
+	if path := os.Getenv(env); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, config.CONFIG_DIR, name), nil
+}
+
+// readStateFile 把 JSON 状态文件解析到 v，文件不存在时保持 v 不变
+func readStateFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析状态文件 %v 失败: %w", path, err)
+	}
+	return nil
+}
+
+// writeStateFile 在文件锁内把 v 以 JSON 格式原子地写入状态文件，
+// 避免崩溃或同时运行的定时任务把状态文件截断
+func writeStateFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return config.WithFileLock(path, func() error {
+		return config.WriteFileAtomic(path, data)
+	})
+}
@@ -0,0 +1,293 @@
+package cloud
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"lucky-go/config"
+)
+
+// DEFAULT_SYNC_TAG_KEY 是 cloud sync 按标签匹配时使用的标签键，标签值为目标名称
+const DEFAULT_SYNC_TAG_KEY = "lucky-go"
+
+// 同步变更的类型
+const (
+	syncNew     = "new"
+	syncChanged = "changed"
+	syncGone    = "gone"
+)
+
+// syncField 是目标的一个字段在同步前后的值
+type syncField struct {
+	Field string `json:"field" yaml:"field"`
+	Old   string `json:"old" yaml:"old"`
+	New   string `json:"new" yaml:"new"`
+}
+
+// syncChange 是配置与云平台实例之间的一处差异
+type syncChange struct {
+	Kind string `json:"kind" yaml:"kind"`
+	// Name 是配置中的目标名称，新实例为建议使用的名称
+	Name string `json:"destination" yaml:"destination"`
+	// MatchedBy 说明目标与实例的匹配方式：instance-id、tag 或 name
+	MatchedBy string      `json:"matched-by,omitempty" yaml:"matched-by,omitempty"`
+	Instance  *Instance   `json:"instance,omitempty" yaml:"instance,omitempty"`
+	Fields    []syncField `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// SyncResult 是 cloud sync 的结果
+type SyncResult struct {
+	Regions []string `json:"regions" yaml:"regions"`
+	// Instances 是同步的地域中的实例数量
+	Instances int          `json:"instances" yaml:"instances"`
+	Changes   []syncChange `json:"changes" yaml:"changes"`
+	Warnings  []string     `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Applied 是写入配置的变更数量，--dry-run 或未确认时为 0
+	Applied int `json:"applied" yaml:"applied"`
+}
+
+// syncOptions 控制实例与目标的匹配方式
+type syncOptions struct {
+	Provider string
+	Regions  []string
+	// TagKey 是按标签匹配时使用的标签键
+	TagKey string
+}
+
+// planSync 比较配置中的目标和云平台上的实例，返回新增、变化和消失的差异。
+// 目标依次按 instance-id、标签（TagKey=目标名称）和实例名称匹配实例，每个实例最多匹配一个目标。
+// 只有 provider 一致的目标参与匹配；配置了 instance-id 但在同步的地域中找不到的目标视为消失。
+func planSync(dests []config.Destination, instances []Instance, opts syncOptions) ([]syncChange, []string) {
+	regions := map[string]bool{}
+	for _, region := range opts.Regions {
+		regions[region] = true
+	}
+
+	var candidates []config.Destination
+	for _, dest := range dests {
+		if providerName(&dest.DestinationInstance) == opts.Provider {
+			candidates = append(candidates, dest)
+		}
+	}
+
+	claimed := make([]bool, len(instances))
+	matched := map[string]int{}
+	var warnings []string
+
+	match := func(by string, find func(dest *config.Destination) []int) {
+		for i := range candidates {
+			dest := &candidates[i]
+			if _, ok := matched[dest.Name]; ok {
+				continue
+			}
+
+			var free []int
+			for _, index := range find(dest) {
+				if !claimed[index] {
+					free = append(free, index)
+				}
+			}
+			switch len(free) {
+			case 0:
+			case 1:
+				claimed[free[0]] = true
+				matched[dest.Name] = free[0]
+			default:
+				warnings = append(warnings, fmt.Sprintf("%v 按 %v 匹配到 %d 个实例，已跳过", dest.Name, by, len(free)))
+			}
+		}
+	}
+
+	instancesWhere := func(fn func(instance *Instance) bool) []int {
+		var indexes []int
+		for i := range instances {
+			if fn(&instances[i]) {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+
+	match("instance-id", func(dest *config.Destination) []int {
+		return instancesWhere(func(instance *Instance) bool {
+			return dest.InstanceId != "" && instance.InstanceId == dest.InstanceId
+		})
+	})
+	if opts.TagKey != "" {
+		match("tag", func(dest *config.Destination) []int {
+			return instancesWhere(func(instance *Instance) bool { return instance.Tags[opts.TagKey] == dest.Name })
+		})
+	}
+	match("name", func(dest *config.Destination) []int {
+		return instancesWhere(func(instance *Instance) bool { return instance.Name == dest.Name })
+	})
+
+	var changes []syncChange
+	for _, dest := range candidates {
+		index, ok := matched[dest.Name]
+		if !ok {
+			if dest.InstanceId != "" && regions[dest.Region] {
+				changes = append(changes, syncChange{Kind: syncGone, Name: dest.Name, Fields: []syncField{{"instance-id", dest.InstanceId, ""}}})
+			}
+			continue
+		}
+
+		instance := &instances[index]
+		fields := syncFields(&dest.DestinationInstance, instance)
+		if len(fields) > 0 {
+			changes = append(changes, syncChange{Kind: syncChanged, Name: dest.Name, MatchedBy: matchedBy(&dest, instance, opts.TagKey), Instance: instance, Fields: fields})
+		}
+	}
+
+	taken := map[string]bool{}
+	for _, dest := range dests {
+		taken[dest.Name] = true
+	}
+	for i := range instances {
+		if claimed[i] {
+			continue
+		}
+		name := suggestDestinationName(&instances[i], taken)
+		taken[name] = true
+		changes = append(changes, syncChange{Kind: syncNew, Name: name, Instance: &instances[i]})
+	}
+
+	order := map[string]int{syncChanged: 0, syncNew: 1, syncGone: 2}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return order[changes[i].Kind] < order[changes[j].Kind]
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes, warnings
+}
+
+// matchedBy 返回目标与实例的匹配方式
+func matchedBy(dest *config.Destination, instance *Instance, tagKey string) string {
+	switch {
+	case dest.InstanceId != "" && dest.InstanceId == instance.InstanceId:
+		return "instance-id"
+	case tagKey != "" && instance.Tags[tagKey] == dest.Name:
+		return "tag"
+	default:
+		return "name"
+	}
+}
+
+// syncFields 返回目标需要按实例更新的 instance-id、region 和 ssh 字段。
+// 只有当 ssh 的主机部分是 IP 地址且不是实例的公网 IP 时才更新 ssh，主机名和别名保持不变。
+func syncFields(dest *config.DestinationInstance, instance *Instance) []syncField {
+	var fields []syncField
+	if dest.InstanceId != instance.InstanceId {
+		fields = append(fields, syncField{"instance-id", dest.InstanceId, instance.InstanceId})
+	}
+	if dest.Region != instance.Region {
+		fields = append(fields, syncField{"region", dest.Region, instance.Region})
+	}
+
+	user, host := splitSsh(dest.Ssh)
+	if len(instance.PublicIPs) > 0 && net.ParseIP(host) != nil && !contains(instance.PublicIPs, host) {
+		fields = append(fields, syncField{"ssh", dest.Ssh, joinSsh(user, instance.PublicIPs[0])})
+	}
+
+	return fields
+}
+
+// splitSsh 把 ssh 字段拆分为用户和主机
+func splitSsh(ssh string) (user, host string) {
+	if i := strings.LastIndex(ssh, "@"); i >= 0 {
+		return ssh[:i], ssh[i+1:]
+	}
+	return "", ssh
+}
+
+// joinSsh 把用户和主机组合为 ssh 字段
+func joinSsh(user, host string) string {
+	if user == "" {
+		return host
+	}
+	return user + "@" + host
+}
+
+// contains 判断 values 中是否包含 value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidNameChars 匹配目标名称中不允许出现的字符
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// suggestDestinationName 根据实例名称生成未被占用的目标名称，名称不可用时使用实例 ID
+func suggestDestinationName(instance *Instance, taken map[string]bool) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(instance.Name, "-"), "-._")
+	if config.ValidateDestinationName(name) != nil {
+		name = instance.InstanceId
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%v-%d", name, i)
+	}
+	return candidate
+}
+
+// applySync 把差异写入配置：更新变化的目标，addNew 时添加新实例。消失的目标只报告，不会删除。
+func applySync(cfg *config.Config, changes []syncChange, addNew bool, user, provider string) ([]string, error) {
+	var skipped []string
+	for _, change := range changes {
+		switch change.Kind {
+		case syncChanged:
+			dest, ok := cfg.Dest[change.Name]
+			if !ok {
+				skipped = append(skipped, fmt.Sprintf("%v 不在主配置文件中，已跳过", change.Name))
+				continue
+			}
+			for _, field := range change.Fields {
+				switch field.Field {
+				case "instance-id":
+					dest.InstanceId = field.New
+				case "region":
+					dest.Region = field.New
+				case "ssh":
+					dest.Ssh = field.New
+				}
+			}
+			cfg.Dest[change.Name] = dest
+
+		case syncNew:
+			if !addNew {
+				continue
+			}
+			if len(change.Instance.PublicIPs) == 0 {
+				skipped = append(skipped, fmt.Sprintf("%v 没有公网 IP，已跳过", change.Instance.InstanceId))
+				continue
+			}
+			dest := config.DestinationInstance{
+				Ssh:        joinSsh(user, change.Instance.PublicIPs[0]),
+				Region:     change.Instance.Region,
+				InstanceId: change.Instance.InstanceId,
+			}
+			if provider != config.ProviderLighthouse {
+				dest.Provider = provider
+			}
+			if err := dest.Validate(); err != nil {
+				return skipped, fmt.Errorf("实例 %v 无法添加为目标: %w", change.Instance.InstanceId, err)
+			}
+			if cfg.Dest == nil {
+				cfg.Dest = map[string]config.DestinationInstance{}
+			}
+			cfg.Dest[change.Name] = dest
+		}
+	}
+
+	return skipped, nil
+}
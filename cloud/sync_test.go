@@ -0,0 +1,149 @@
+package cloud
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"lucky-go/config"
+)
+
+func TestPlanSync(t *testing.T) {
+	dests := []config.Destination{
+		{Name: "web", DestinationInstance: config.DestinationInstance{Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-old"}},
+		{Name: "api", DestinationInstance: config.DestinationInstance{Ssh: "ubuntu@2.2.2.2", Region: "ap-hongkong", InstanceId: "lhins-api"}},
+		{Name: "db", DestinationInstance: config.DestinationInstance{Ssh: "db.example.com"}},
+		{Name: "gone", DestinationInstance: config.DestinationInstance{Ssh: "root@3.3.3.3", Region: "ap-hongkong", InstanceId: "lhins-gone"}},
+		{Name: "elsewhere", DestinationInstance: config.DestinationInstance{Ssh: "root@4.4.4.4", Region: "ap-tokyo", InstanceId: "lhins-tokyo"}},
+		{Name: "cvm", DestinationInstance: config.DestinationInstance{Ssh: "root@5.5.5.5", Provider: config.ProviderCVM, Region: "ap-hongkong", InstanceId: "ins-cvm"}},
+	}
+	instances := []Instance{
+		{InstanceId: "lhins-api", Name: "api", Region: "ap-hongkong", PublicIPs: []string{"2.2.2.2"}},
+		{InstanceId: "lhins-new-web", Name: "web-rebuilt", Region: "ap-hongkong", PublicIPs: []string{"9.9.9.9"}, Tags: map[string]string{"lucky-go": "web"}},
+		{InstanceId: "lhins-db", Name: "db", Region: "ap-hongkong", PublicIPs: []string{"8.8.8.8"}},
+		{InstanceId: "lhins-extra", Name: "web", Region: "ap-hongkong", PublicIPs: []string{"7.7.7.7"}},
+	}
+
+	changes, warnings := planSync(dests, instances, syncOptions{Provider: config.ProviderLighthouse, Regions: []string{"ap-hongkong"}, TagKey: "lucky-go"})
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+
+	var summary []string
+	for _, change := range changes {
+		line := change.Kind + " " + change.Name + " " + change.MatchedBy
+		for _, field := range change.Fields {
+			line += " " + field.Field + "=" + field.New
+		}
+		summary = append(summary, strings.TrimSpace(line))
+	}
+
+	expected := []string{
+		// db 的 ssh 是主机名，只更新 instance-id 和 region
+		"changed db name instance-id=lhins-db region=ap-hongkong",
+		// web 按标签匹配到重建后的实例，而不是同名的 lhins-extra
+		"changed web tag instance-id=lhins-new-web ssh=root@9.9.9.9",
+		// 同名的目标已被占用，新实例使用带序号的名称
+		"new web-2",
+		"gone gone  instance-id=",
+	}
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected plan\nexpected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+}
+
+func TestSyncCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web": {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-old"},
+		},
+	})
+
+	original := listInstancesFunc
+	t.Cleanup(func() { listInstancesFunc = original })
+	listInstancesFunc = func(provider, region, account string) ([]Instance, error) {
+		return []Instance{
+			{InstanceId: "lhins-web", Name: "web", Region: region, PublicIPs: []string{"9.9.9.9"}},
+			{InstanceId: "lhins-cache", Name: "redis cache", Region: region, PublicIPs: []string{"6.6.6.6"}},
+		}, nil
+	}
+
+	run := func(input string, args ...string) (string, error) {
+		cmd := NewCommand()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetErr(out)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(append([]string{"sync"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := run("n\n")
+	if err == nil || !strings.Contains(err.Error(), "已取消") {
+		t.Fatalf("expected sync to be cancelled, got: %v", err)
+	}
+	if !strings.Contains(out, `instance-id: "lhins-old" → "lhins-web"`) || !strings.Contains(out, "redis-cache") {
+		t.Errorf("expected diff in output, got:\n%s", out)
+	}
+
+	if out, err := run("y\n", "--add", "--user", "ubuntu"); err != nil {
+		t.Fatalf("expected no error, got: %v\n%s", err, out)
+	}
+
+	web, err := config.LoadDestination("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if web.InstanceId != "lhins-web" || web.Ssh != "root@9.9.9.9" {
+		t.Errorf("expected web to be updated, got %+v", web.DestinationInstance)
+	}
+	cache, err := config.LoadDestination("redis-cache")
+	if err != nil {
+		t.Fatalf("expected new instance to be added: %v", err)
+	}
+	if cache.Ssh != "ubuntu@6.6.6.6" || cache.Region != "ap-hongkong" {
+		t.Errorf("unexpected new destination %+v", cache.DestinationInstance)
+	}
+
+	out, err = run("")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.Contains(out, "配置已与云平台一致") {
+		t.Errorf("expected no changes after sync, got:\n%s", out)
+	}
+
+	t.Run("EmptyConfig", func(t *testing.T) {
+		setupCloudConfig(t, config.Config{})
+
+		out, err := run("", "--regions", "ap-tokyo", "--add", "--yes")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v\n%s", err, out)
+		}
+		if _, err := config.LoadDestination("redis-cache"); err != nil {
+			t.Errorf("expected instances to be added to an empty config: %v", err)
+		}
+	})
+
+	t.Run("UnreadableConfig", func(t *testing.T) {
+		setupCloudConfig(t, config.Config{})
+		path, err := config.ConfigFilePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		original, _ := os.ReadFile(path)
+		// 无效的环境变量覆盖使读取目标失败，但不影响直接修改配置文件
+		t.Setenv("LUCKY_GO_DEST__WEB__SSH", "[unclosed")
+		config.SetEndpoint(config.EndpointTencent, "http://127.0.0.1:1")
+		t.Cleanup(func() { config.SetEndpoint(config.EndpointTencent, "") })
+
+		if _, err := run("", "--regions", "ap-tokyo", "--add", "--yes"); err == nil {
+			t.Error("expected an unreadable config to abort the sync")
+		}
+		if data, _ := os.ReadFile(path); string(data) != string(original) {
+			t.Errorf("expected the config not to be modified, got:\n%s", data)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package cloud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+
+	"lucky-go/config"
+)
+
+const (
+	// tatService 和 tatVersion 是自动化助手（TAT）API 的服务名和版本
+	tatService = "tat"
+	tatVersion = "2020-10-28"
+)
+
+// TAT 执行任务的状态
+const (
+	tatTaskSuccess     = "SUCCESS"
+	tatTaskFailed      = "FAILED"
+	tatTaskTimeout     = "TIMEOUT"
+	tatTaskTaskTimeout = "TASK_TIMEOUT"
+)
+
+// tatPendingStates 是执行任务尚未结束的状态，其余状态都表示任务已结束
+var tatPendingStates = map[string]bool{
+	"PENDING":         true,
+	"DELIVERING":      true,
+	"DELIVER_DELAYED": true,
+	"RUNNING":         true,
+	"CANCELLING":      true,
+}
+
+// tatClient 通过自动化助手在实例上执行命令，轻量应用服务器和云服务器都使用同一套 API
+type tatClient struct {
+	// send 发送 API 请求并返回响应体，测试中可替换
+	send func(action string, params map[string]any) ([]byte, error)
+}
+
+// tatTask 是一次执行任务的状态和结果
+type tatTask struct {
+	InvocationTaskId string `json:"InvocationTaskId"`
+	InstanceId       string `json:"InstanceId"`
+	TaskStatus       string `json:"TaskStatus"`
+	// ErrorInfo 是投递或启动失败的原因，如实例上的自动化助手不在线
+	ErrorInfo  string `json:"ErrorInfo"`
+	TaskResult struct {
+		ExitCode int `json:"ExitCode"`
+		// Output 是 base64 编码的命令输出，超过 24KB 时被截断
+		Output string `json:"Output"`
+	} `json:"TaskResult"`
+}
+
+// tatRunResponse 是 RunCommand 的响应
+type tatRunResponse struct {
+	Response struct {
+		InvocationId string `json:"InvocationId"`
+		RequestId    string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// tatTasksResponse 是 DescribeInvocationTasks 的响应
+type tatTasksResponse struct {
+	Response struct {
+		InvocationTaskSet []tatTask `json:"InvocationTaskSet"`
+	} `json:"Response"`
+}
+
+// newTATClient 创建目标所在地域和账号的自动化助手客户端，endpoint 为空时使用默认地址
+func newTATClient(dest *config.DestinationInstance, endpoint string) (*tatClient, error) {
+	switch dest.Provider {
+	case "", config.ProviderLighthouse, config.ProviderCVM:
+	default:
+		return nil, configErrorf("provider %v 不支持自动化助手", dest.Provider)
+	}
+
+	credential, err := tencentCredential(dest.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	cpf, err := newClientProfile(tatService, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	client := common.NewCommonClient(credential, dest.Region, cpf)
+
+	return &tatClient{
+		send: func(action string, params map[string]any) ([]byte, error) {
+			request := tchttp.NewCommonRequest(tatService, tatVersion, action)
+			if err := request.SetActionParameters(params); err != nil {
+				return nil, err
+			}
+
+			response := tchttp.NewCommonResponse()
+			if err := client.Send(request, response); err != nil {
+				return nil, err
+			}
+			return response.GetBody(), nil
+		},
+	}, nil
+}
+
+// runCommandParams 返回 RunCommand 的参数，命令以 root 身份通过 shell 执行
+func runCommandParams(instanceId, command string, timeoutSeconds int64) map[string]any {
+	return map[string]any{
+		"Content":     base64.StdEncoding.EncodeToString([]byte(command)),
+		"InstanceIds": []string{instanceId},
+		"CommandType": "SHELL",
+		"Timeout":     timeoutSeconds,
+		"Username":    "root",
+	}
+}
+
+// Run 在实例上执行 shell 命令，返回执行活动 ID
+func (c *tatClient) Run(instanceId, command string, timeoutSeconds int64) (string, error) {
+	data, err := c.send("RunCommand", runCommandParams(instanceId, command, timeoutSeconds))
+	if err != nil {
+		return "", err
+	}
+
+	var response tatRunResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", err
+	}
+	if response.Response.InvocationId == "" {
+		return "", fmt.Errorf("RunCommand 没有返回执行活动 ID")
+	}
+	return response.Response.InvocationId, nil
+}
+
+// Task 查询执行活动在实例上的任务，包括目前为止的输出
+func (c *tatClient) Task(invocationId string) (*tatTask, error) {
+	data, err := c.send("DescribeInvocationTasks", map[string]any{
+		"Filters":    []map[string]any{{"Name": "invocation-id", "Values": []string{invocationId}}},
+		"HideOutput": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response tatTasksResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Response.InvocationTaskSet) == 0 {
+		// 任务刚创建时可能还查询不到
+		return &tatTask{TaskStatus: "PENDING"}, nil
+	}
+	return &response.Response.InvocationTaskSet[0], nil
+}
+
+// output 返回解码后的命令输出
+func (task *tatTask) output() (string, error) {
+	data, err := base64.StdEncoding.DecodeString(task.TaskResult.Output)
+	if err != nil {
+		return "", fmt.Errorf("解码命令输出失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// RunResult 是 cloud run 在实例上执行命令的结果
+type RunResult struct {
+	Destination  string `json:"destination" yaml:"destination"`
+	InstanceId   string `json:"instance-id" yaml:"instance-id"`
+	InvocationId string `json:"invocation-id,omitempty" yaml:"invocation-id,omitempty"`
+	// Status 是执行任务的最终状态，如 SUCCESS、FAILED、TIMEOUT
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	// ExitCode 是命令的退出码，命令没有运行结束时为空
+	ExitCode *int         `json:"exit-code,omitempty" yaml:"exit-code,omitempty"`
+	Output   string       `json:"output" yaml:"output"`
+	Error    *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
@@ -0,0 +1,158 @@
+package cloud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"lucky-go/config"
+)
+
+// tatStandIn 是自动化助手 API 的本地替身，按顺序返回 tasks 中的执行任务，最后一个重复返回
+type tatStandIn struct {
+	t        *testing.T
+	commands []string
+	tasks    []string
+	runError string
+}
+
+func (s *tatStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var params map[string]any
+	_ = json.Unmarshal(body, &params)
+
+	switch action := r.Header.Get("X-TC-Action"); action {
+	case "RunCommand":
+		if s.runError != "" {
+			_, _ = io.WriteString(w, `{"Response":{"Error":{"Code":"`+s.runError+`","Message":"instance not found"},"RequestId":"req-1"}}`)
+			return
+		}
+		content, _ := base64.StdEncoding.DecodeString(params["Content"].(string))
+		s.commands = append(s.commands, string(content))
+		_, _ = io.WriteString(w, `{"Response":{"InvocationId":"inv-1","RequestId":"req-1"}}`)
+	case "DescribeInvocationTasks":
+		task := s.tasks[0]
+		if len(s.tasks) > 1 {
+			s.tasks = s.tasks[1:]
+		}
+		_, _ = io.WriteString(w, `{"Response":{"TotalCount":1,"InvocationTaskSet":[`+task+`],"RequestId":"req-2"}}`)
+	default:
+		s.t.Errorf("unexpected action %v", action)
+	}
+}
+
+// tatTaskJSON 返回执行任务的 JSON，output 会被 base64 编码
+func tatTaskJSON(status string, exitCode int, output string) string {
+	return `{"InvocationTaskId":"invt-1","InstanceId":"lhins-web","TaskStatus":"` + status + `","ErrorInfo":"agent offline",` +
+		`"TaskResult":{"ExitCode":` + strconv.Itoa(exitCode) + `,"Output":"` + base64.StdEncoding.EncodeToString([]byte(output)) + `"}}`
+}
+
+func TestRunCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web":  {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web"},
+			"fake": {Ssh: "root@1.1.1.2", Provider: config.ProviderFake, Region: "ap-test", InstanceId: "lhins-fake"},
+		},
+	})
+	t.Setenv("TENCENT_CLOUD_SECRET_ID", "id")
+	t.Setenv("TENCENT_CLOUD_SECRET_KEY", "key")
+	useWaitClock(t)
+
+	standIn := &tatStandIn{t: t}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	run := func(args ...string) (string, string, error) {
+		return runCloudCommandSplit(t, append([]string{"run", "--endpoint", server.URL, "--interval", "1s"}, args...)...)
+	}
+
+	t.Run("StreamOutput", func(t *testing.T) {
+		standIn.tasks = []string{
+			tatTaskJSON("RUNNING", 0, "line1\n"),
+			tatTaskJSON("RUNNING", 0, "line1\n"),
+			tatTaskJSON("SUCCESS", 0, "line1\nline2\n"),
+		}
+		stdout, stderr, err := run("web", "--", "systemctl", "restart", "sshd")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if stdout != "line1\nline2\n" {
+			t.Errorf("expected output to be streamed once, got %q", stdout)
+		}
+		if !strings.Contains(stderr, "inv-1") || !strings.Contains(stderr, "退出码 0") {
+			t.Errorf("expected progress on stderr, got %q", stderr)
+		}
+		if len(standIn.commands) != 1 || standIn.commands[0] != "systemctl restart sshd" {
+			t.Errorf("unexpected commands: %v", standIn.commands)
+		}
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		standIn.tasks = []string{tatTaskJSON("FAILED", 3, "disk full\n")}
+		stdout, _, err := run("web", "-o", "json", "--", "df", "-h")
+		// 远程命令的退出码不能与 EXIT_NOT_FOUND 等 cloud 退出码混淆
+		if ExitCode(err) != EXIT_REMOTE || !strings.Contains(err.Error(), "退出码 3") {
+			t.Fatalf("expected EXIT_REMOTE with the remote exit code in the message, got: %v", err)
+		}
+
+		var result RunResult
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		if result.Status != "FAILED" || result.ExitCode == nil || *result.ExitCode != 3 || result.Output != "disk full\n" ||
+			result.Error == nil || result.Error.Kind != errorKindRemote {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("AgentOffline", func(t *testing.T) {
+		standIn.tasks = []string{tatTaskJSON("DELIVER_FAILED", 0, "")}
+		_, _, err := run("web", "--", "uptime")
+		if ExitCode(err) != EXIT_ERROR || !strings.Contains(err.Error(), "agent offline") {
+			t.Errorf("expected delivery failure, got: %v", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		standIn.tasks = []string{tatTaskJSON("RUNNING", 0, "")}
+		_, _, err := run("web", "--timeout", "5s", "--", "sleep", "60")
+		if ExitCode(err) != EXIT_TIMEOUT {
+			t.Errorf("expected timeout, got: %v", err)
+		}
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		standIn.runError = "InvalidInstanceId.NotFound"
+		defer func() { standIn.runError = "" }()
+
+		_, _, err := run("web", "--", "uptime")
+		if ExitCode(err) != EXIT_API {
+			t.Errorf("expected API error, got: %v", err)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		commands := len(standIn.commands)
+		stdout, _, err := run("web", "--dry-run", "--", "uptime")
+		if err != nil || !strings.Contains(stdout, "RunCommand") || !strings.Contains(stdout, base64.StdEncoding.EncodeToString([]byte("uptime"))) {
+			t.Errorf("expected RunCommand to be printed, got: %v\n%s", err, stdout)
+		}
+		if len(standIn.commands) != commands {
+			t.Error("expected dry run to send nothing")
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		if _, _, err := run("fake", "--", "uptime"); ExitCode(err) != EXIT_CONFIG {
+			t.Errorf("expected fake provider to be unsupported, got: %v", err)
+		}
+		if _, _, err := runCloudCommandSplit(t, "run", "web", "--endpoint", "ftp://example.com", "--", "uptime"); ExitCode(err) != EXIT_CONFIG {
+			t.Errorf("expected bad endpoint to be rejected, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"lucky-go/config"
+	"lucky-go/notify"
+)
+
+// sendNotification 发送告警消息，测试中可替换
+var sendNotification = notify.SendTelegramMessage
+
+// trafficPercent 返回流量包的使用率百分比，总量为 0 时返回 0
+func trafficPercent(traffic *Traffic) float64 {
+	if traffic == nil || traffic.Total == 0 {
+		return 0
+	}
+	return float64(traffic.Used) / float64(traffic.Total) * 100
+}
+
+// projectExhaustion 按周期开始以来的平均用量估算流量包用完的时间。
+// 无法估算（没有用量或缺少周期开始时间）或预计在周期结束前不会用完时 ok 为 false。
+func projectExhaustion(traffic *Traffic, now time.Time) (exhausted time.Time, ok bool) {
+	if traffic == nil || traffic.Total == 0 || traffic.Used <= 0 {
+		return time.Time{}, false
+	}
+	if traffic.Used >= traffic.Total {
+		return now, true
+	}
+
+	start, err := time.Parse(time.RFC3339, traffic.StartTime)
+	if err != nil || !now.After(start) {
+		return time.Time{}, false
+	}
+
+	elapsed := now.Sub(start)
+	rate := float64(traffic.Used) / float64(elapsed)
+	exhausted = start.Add(time.Duration(float64(traffic.Total) / rate))
+
+	if end, err := time.Parse(time.RFC3339, traffic.EndTime); err == nil && exhausted.After(end) {
+		return exhausted, false
+	}
+	return exhausted, true
+}
+
+// formatExhaustion 把预计用完时间格式化为日期和剩余天数
+func formatExhaustion(traffic *Traffic) string {
+	if traffic == nil || traffic.Total == 0 {
+		return "-"
+	}
+
+	now := timeNow()
+	exhausted, ok := projectExhaustion(traffic, now)
+	switch {
+	case traffic.Used >= traffic.Total:
+		return "已用完"
+	case !ok:
+		return "周期内不会用完"
+	}
+
+	days := int(exhausted.Sub(now).Hours() / 24)
+	return fmt.Sprintf("%v (约 %d 天后)", exhausted.Local().Format("2006-01-02"), days)
+}
+
+// formatPeriodEnd 把流量包周期的结束时间格式化为日期
+func formatPeriodEnd(traffic *Traffic) string {
+	if traffic == nil || traffic.EndTime == "" {
+		return "-"
+	}
+	end, err := time.Parse(time.RFC3339, traffic.EndTime)
+	if err != nil {
+		return traffic.EndTime
+	}
+	return end.Local().Format("2006-01-02")
+}
+
+// TrafficResult 是查询单个目标流量包的结果
+type TrafficResult struct {
+	Destination string   `json:"destination" yaml:"destination"`
+	InstanceId  string   `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
+	Traffic     *Traffic `json:"traffic,omitempty" yaml:"traffic,omitempty"`
+	// Percent 是使用率百分比
+	Percent float64 `json:"percent" yaml:"percent"`
+	// ExhaustedTime 是按平均用量估算的用完时间，周期内不会用完时为空
+	ExhaustedTime string       `json:"exhausted-time,omitempty" yaml:"exhausted-time,omitempty"`
+	Error         *ResultError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// newTrafficResults 按查询结果创建 TrafficResult
+func newTrafficResults(results []fleetResult, instances []*Instance) []TrafficResult {
+	now := timeNow()
+	traffics := make([]TrafficResult, len(results))
+	for i, result := range results {
+		traffics[i] = TrafficResult{Destination: result.Name, InstanceId: result.InstanceId, Error: newResultError(result.Err)}
+		if result.Err != nil || instances[i] == nil || instances[i].Traffic == nil {
+			continue
+		}
+
+		traffic := instances[i].Traffic
+		traffics[i].Traffic = traffic
+		traffics[i].Percent = math.Round(trafficPercent(traffic)*10) / 10
+		if exhausted, ok := projectExhaustion(traffic, now); ok {
+			traffics[i].ExhaustedTime = exhausted.UTC().Format(time.RFC3339)
+		}
+	}
+	return traffics
+}
+
+// trafficWatcher 记录每个目标在当前周期内已告警的最高阈值，避免重复告警
+type trafficWatcher struct {
+	out io.Writer
+	mu  sync.Mutex
+	// alerted 以目标名称和周期开始时间为键，周期切换后重新告警
+	alerted map[string]int
+	// dryRun 时只显示关机请求，force 时受保护的目标也会自动关机
+	dryRun, force bool
+}
+
+// newTrafficWatcher 创建流量监控器
+func newTrafficWatcher(out io.Writer) *trafficWatcher {
+	return &trafficWatcher{out: out, alerted: map[string]int{}}
+}
+
+// check 查询一次目标的流量，超过新的告警阈值时推送告警，超过关机阈值时关机
+func (watcher *trafficWatcher) check(dest *config.Destination) error {
+	instance, err := DescribeInstance(&dest.DestinationInstance)
+	if err != nil {
+		return err
+	}
+	if instance.Traffic == nil || instance.Traffic.Total == 0 {
+		return nil
+	}
+
+	policy, err := config.LoadTrafficPolicy(dest.Name)
+	if err != nil {
+		return err
+	}
+
+	percent := trafficPercent(instance.Traffic)
+	if threshold := watcher.crossed(dest.Name, instance.Traffic, percent, policy.Thresholds()); threshold > 0 {
+		message := fmt.Sprintf("*流量告警* %v\n\n已用 %v，超过 %d%% 阈值\n剩余 %v，预计用完: %v",
+			dest.Name, formatTraffic(instance.Traffic), threshold,
+			formatBytes(instance.Traffic.Remaining), formatExhaustion(instance.Traffic))
+		// 推送失败时不记录阈值，下次检查时重新告警，也不影响下面的自动关机
+		if err := sendNotification(message); err != nil {
+			fmt.Fprintf(watcher.out, "%v: 推送流量告警失败: %v\n", dest.Name, err)
+		} else {
+			watcher.record(dest.Name, instance.Traffic, threshold)
+			fmt.Fprintf(watcher.out, "%v: 流量使用率 %.0f%%，已推送 %d%% 阈值告警\n", dest.Name, percent, threshold)
+		}
+	}
+
+	if policy.StopAt > 0 && percent >= float64(policy.StopAt) && instance.State == "RUNNING" {
+		if dest.Protected && !watcher.force {
+			fmt.Fprintf(watcher.out, "%v: 流量使用率 %.0f%% 超过 %d%%，目标受保护，跳过自动关机\n", dest.Name, percent, policy.StopAt)
+			return nil
+		}
+		if watcher.dryRun {
+			fmt.Fprintf(watcher.out, "[dry-run] %v: %v\n", dest.Name, powerCall(dest, "StopInstances"))
+			return nil
+		}
+
+		if _, err := StopInstance(&dest.DestinationInstance); err != nil {
+			return fmt.Errorf("流量超过 %d%% 后自动关机失败: %w", policy.StopAt, err)
+		}
+		fmt.Fprintf(watcher.out, "%v: 流量使用率 %.0f%% 超过 %d%%，已自动关机\n", dest.Name, percent, policy.StopAt)
+
+		message := fmt.Sprintf("*流量超额关机* %v\n\n已用 %v，超过 %d%% 上限，已自动关机", dest.Name, formatTraffic(instance.Traffic), policy.StopAt)
+		if err := sendNotification(message); err != nil {
+			fmt.Fprintf(watcher.out, "%v: 推送关机通知失败: %v\n", dest.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// crossed 返回本周期内新达到的最高告警阈值，没有新阈值时返回 0。
+// 告警推送成功后才调用 record 记录阈值。
+func (watcher *trafficWatcher) crossed(name string, traffic *Traffic, percent float64, thresholds []int) int {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+
+	highest := 0
+	for _, threshold := range thresholds {
+		if percent >= float64(threshold) {
+			highest = threshold
+		}
+	}
+
+	if highest <= watcher.alerted[alertKey(name, traffic)] {
+		return 0
+	}
+	return highest
+}
+
+// record 记录目标在本周期内已告警的阈值
+func (watcher *trafficWatcher) record(name string, traffic *Traffic, threshold int) {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+
+	key := alertKey(name, traffic)
+	watcher.alerted[key] = max(watcher.alerted[key], threshold)
+}
+
+// alertKey 返回目标在流量周期内的告警记录键
+func alertKey(name string, traffic *Traffic) string {
+	return name + "|" + traffic.StartTime
+}
@@ -0,0 +1,157 @@
+package cloud
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+func TestProjectExhaustion(t *testing.T) {
+	now := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+	period := func(used int64) *Traffic {
+		return &Traffic{Used: used, Total: 1000, StartTime: "2026-01-01T00:00:00Z", EndTime: "2026-02-01T00:00:00Z"}
+	}
+
+	tests := []struct {
+		name     string
+		traffic  *Traffic
+		expected string
+		ok       bool
+	}{
+		{"HalfUsedInTenDays", period(500), "2026-01-21", true},
+		{"WillNotRunOut", period(100), "", false},
+		{"AlreadyExhausted", period(1200), "2026-01-11", true},
+		{"NoUsage", period(0), "", false},
+		{"NoPackage", nil, "", false},
+		{"MissingStartTime", &Traffic{Used: 500, Total: 1000}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exhausted, ok := projectExhaustion(tt.traffic, now)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v (%v)", tt.ok, ok, exhausted)
+			}
+			if ok && exhausted.Format("2006-01-02") != tt.expected {
+				t.Errorf("expected exhaustion on %v, got %v", tt.expected, exhausted)
+			}
+		})
+	}
+}
+
+func TestTrafficCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Traffic: &config.TrafficPolicy{Alerts: []int{50, 80}, StopAt: 100},
+		Dest: map[string]config.DestinationInstance{
+			"web": {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web"},
+			"db":  {Ssh: "root@1.1.1.2", Region: "ap-hongkong", InstanceId: "lhins-db", Traffic: &config.TrafficPolicy{Alerts: []int{95}}},
+		},
+	})
+	useWaitClock(t)
+	timeNow = func() time.Time { return time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC) }
+
+	// 每次查询 web 的用量依次为 60%、85%、110%、110%，db 始终为 90%
+	usages := []int64{600, 850, 1100, 1100}
+	state := "RUNNING"
+	originalDescribe, originalStop, originalSend := describeInstanceFunc, stopInstanceFunc, sendNotification
+	t.Cleanup(func() {
+		describeInstanceFunc, stopInstanceFunc, sendNotification = originalDescribe, originalStop, originalSend
+	})
+
+	describeInstanceFunc = func(dest *config.DestinationInstance) (*Instance, error) {
+		traffic := &Traffic{Used: 900, Total: 1000, Remaining: 100, StartTime: "2026-01-01T00:00:00Z", EndTime: "2026-02-01T00:00:00Z"}
+		instanceState := "RUNNING"
+		if dest.InstanceId == "lhins-web" {
+			traffic.Used = usages[0]
+			if len(usages) > 1 {
+				usages = usages[1:]
+			}
+			instanceState = state
+		}
+		return &Instance{InstanceId: dest.InstanceId, State: instanceState, Traffic: traffic}, nil
+	}
+	var stopped []string
+	stopInstanceFunc = func(dest *config.DestinationInstance) (string, error) {
+		stopped = append(stopped, dest.InstanceId)
+		state = "STOPPED"
+		return "", nil
+	}
+	var messages []string
+	sendNotification = func(message string) error {
+		messages = append(messages, message)
+		return nil
+	}
+
+	t.Run("Table", func(t *testing.T) {
+		out, err := runCloudCommand(t, "traffic", "db")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		for _, expected := range []string{"900 B", "90.0%", "2026-02-01", "2026-01-12"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected traffic output to contain %q, got:\n%s", expected, out)
+			}
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		out, err := runCloudCommand(t, "traffic", "--watch", "--count", "4", "--interval", "1m", "--parallel", "1")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if len(stopped) != 1 || stopped[0] != "lhins-web" {
+			t.Errorf("expected web to be stopped exactly once, got %v", stopped)
+		}
+
+		// web: 50% 和 80% 阈值各告警一次，另有一次关机通知；db 未达到自身的 95% 阈值
+		var alerts, shutdowns int
+		for _, message := range messages {
+			if strings.Contains(message, "db") {
+				t.Errorf("expected no alert for db, got %q", message)
+			}
+			if strings.Contains(message, "流量告警") {
+				alerts++
+			}
+			if strings.Contains(message, "超额关机") {
+				shutdowns++
+			}
+		}
+		if alerts != 2 || shutdowns != 1 {
+			t.Errorf("expected 2 alerts and 1 shutdown notice, got %d and %d: %v", alerts, shutdowns, messages)
+		}
+		if !strings.Contains(out, "已自动关机") {
+			t.Errorf("expected shutdown in output, got:\n%s", out)
+		}
+	})
+	t.Run("NotifyFailure", func(t *testing.T) {
+		// 推送失败时仍然自动关机，告警在下次检查时重新推送
+		usages, state, stopped, messages = []int64{1100, 1100}, "RUNNING", nil, nil
+		failures := 2
+		sendNotification = func(message string) error {
+			if failures > 0 {
+				failures--
+				return errors.New("telegram unavailable")
+			}
+			messages = append(messages, message)
+			return nil
+		}
+
+		out, err := runCloudCommand(t, "traffic", "web", "--watch", "--count", "2", "--interval", "1m")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(stopped) != 1 || stopped[0] != "lhins-web" {
+			t.Errorf("expected web to be stopped despite the failed alert, got %v", stopped)
+		}
+		if len(messages) != 1 || !strings.Contains(messages[0], "80%") {
+			t.Errorf("expected the alert to be resent on the next check, got %v", messages)
+		}
+		if !strings.Contains(out, "推送流量告警失败") {
+			t.Errorf("expected the failure in output, got:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lucky-go/config"
+)
+
+// 定义函数变量，用于在测试中模拟网络探测和等待
+var (
+	dialTimeout = net.DialTimeout
+	sleepFunc   = time.Sleep
+)
+
+// defaultSshPort 是未配置 port 时探测的 SSH 端口
+const defaultSshPort = 22
+
+// observeWindow 是等待实例离开 RUNNING 状态的最长时间。
+// 操作刚提交时云平台可能仍返回 RUNNING，超过该时间仍未观察到中间状态则认为操作已完成。
+const observeWindow = 15 * time.Second
+
+// waitOptions 控制 waitForInstance 的超时、轮询间隔和进度输出
+type waitOptions struct {
+	Timeout  time.Duration
+	Interval time.Duration
+	Out      io.Writer
+}
+
+// waitForInstance 轮询实例状态直到经过中间状态回到 RUNNING，然后探测 SSH 端口直到可以连接。
+// 超过 Timeout 仍未完成时返回错误。
+func waitForInstance(dest *config.Destination, opts waitOptions) error {
+	start := timeNow()
+	deadline := start.Add(opts.Timeout)
+	progress := func(format string, args ...any) {
+		elapsed := timeNow().Sub(start).Round(time.Second)
+		fmt.Fprintf(opts.Out, "%v: %v (%v)\n", dest.Name, fmt.Sprintf(format, args...), elapsed)
+	}
+
+	lastState, left := "", false
+	for {
+		instance, err := DescribeInstance(&dest.DestinationInstance)
+		if err != nil {
+			progress("查询状态失败: %v", err)
+		} else {
+			if instance.State != lastState {
+				progress("状态 %v", instance.State)
+				lastState = instance.State
+			}
+			if instance.State != "RUNNING" {
+				left = true
+			} else if left || timeNow().Sub(start) >= observeWindow {
+				break
+			}
+		}
+
+		if !timeNow().Before(deadline) {
+			return timeoutErrorf("等待 %v 恢复为 RUNNING 超时（%v），最后状态为 %v", dest.Name, opts.Timeout, lastState)
+		}
+		sleepFunc(opts.Interval)
+	}
+
+	if dest.ProxyJump != "" {
+		progress("通过跳板机 %v 连接，跳过 SSH 端口探测", dest.ProxyJump)
+		return nil
+	}
+
+	address := sshAddress(&dest.DestinationInstance)
+	for {
+		conn, err := dialTimeout("tcp", address, opts.Interval)
+		if err == nil {
+			conn.Close()
+			progress("SSH 端口 %v 已可连接", address)
+			return nil
+		}
+
+		if !timeNow().Before(deadline) {
+			return timeoutErrorf("等待 %v 的 SSH 端口 %v 超时（%v）: %w", dest.Name, address, opts.Timeout, err)
+		}
+		sleepFunc(opts.Interval)
+	}
+}
+
+// sshAddress 从 ssh 字段中提取主机并拼接 SSH 端口
+func sshAddress(dest *config.DestinationInstance) string {
+	host := dest.Ssh
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+
+	port := dest.Port
+	if port == 0 {
+		port = defaultSshPort
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// syncWriter 串行化并发写入，避免多个目标的进度输出交错
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write 实现 io.Writer 接口
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
@@ -0,0 +1,165 @@
+package cloud
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"lucky-go/config"
+)
+
+// useWaitClock 把 timeNow 和 sleepFunc 替换为由 sleep 推进的模拟时钟
+func useWaitClock(t *testing.T) {
+	t.Helper()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	originalNow, originalSleep := timeNow, sleepFunc
+	timeNow = func() time.Time { return now }
+	sleepFunc = func(d time.Duration) { now = now.Add(d) }
+	t.Cleanup(func() { timeNow, sleepFunc = originalNow, originalSleep })
+}
+
+// stubStates 让 DescribeInstance 依次返回 states，最后一个状态重复返回
+func stubStates(t *testing.T, states ...string) {
+	t.Helper()
+
+	original := describeInstanceFunc
+	describeInstanceFunc = func(dest *config.DestinationInstance) (*Instance, error) {
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		return &Instance{InstanceId: dest.InstanceId, State: state}, nil
+	}
+	t.Cleanup(func() { describeInstanceFunc = original })
+}
+
+// stubDial 让前 failures 次拨号失败，之后成功，并记录拨号地址
+func stubDial(t *testing.T, failures int, addresses *[]string) {
+	t.Helper()
+
+	original := dialTimeout
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		*addresses = append(*addresses, address)
+		if failures > 0 {
+			failures--
+			return nil, errors.New("connection refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	t.Cleanup(func() { dialTimeout = original })
+}
+
+func TestWaitForInstance(t *testing.T) {
+	dest := &config.Destination{
+		Name:                "web",
+		DestinationInstance: config.DestinationInstance{Ssh: "root@1.2.3.4", Region: "ap-test", InstanceId: "lhins-web"},
+	}
+	opts := func(out *bytes.Buffer) waitOptions {
+		return waitOptions{Timeout: time.Minute, Interval: 5 * time.Second, Out: out}
+	}
+
+	t.Run("ThroughRebootingToSsh", func(t *testing.T) {
+		useWaitClock(t)
+		stubStates(t, "RUNNING", "REBOOTING", "REBOOTING", "RUNNING")
+		var addresses []string
+		stubDial(t, 2, &addresses)
+
+		out := &bytes.Buffer{}
+		if err := waitForInstance(dest, opts(out)); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		for _, expected := range []string{"web: 状态 REBOOTING (5s)", "web: 状态 RUNNING (15s)", "SSH 端口 1.2.3.4:22 已可连接 (25s)"} {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("expected progress %q, got:\n%s", expected, out.String())
+			}
+		}
+		if len(addresses) != 3 {
+			t.Errorf("expected three dial attempts, got %v", addresses)
+		}
+	})
+
+	t.Run("NeverLeavesRunning", func(t *testing.T) {
+		useWaitClock(t)
+		stubStates(t, "RUNNING")
+		var addresses []string
+		stubDial(t, 0, &addresses)
+
+		out := &bytes.Buffer{}
+		if err := waitForInstance(dest, opts(out)); err != nil {
+			t.Fatalf("expected no error after the observe window, got: %v", err)
+		}
+	})
+
+	t.Run("StateTimeout", func(t *testing.T) {
+		useWaitClock(t)
+		stubStates(t, "REBOOTING")
+
+		err := waitForInstance(dest, opts(&bytes.Buffer{}))
+		if err == nil || !strings.Contains(err.Error(), "超时") || !strings.Contains(err.Error(), "REBOOTING") {
+			t.Errorf("expected timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("SshTimeout", func(t *testing.T) {
+		useWaitClock(t)
+		stubStates(t, "REBOOTING", "RUNNING")
+		var addresses []string
+		stubDial(t, 1000, &addresses)
+
+		custom := *dest
+		custom.Port = 2222
+		err := waitForInstance(&custom, opts(&bytes.Buffer{}))
+		if err == nil || !strings.Contains(err.Error(), "1.2.3.4:2222") {
+			t.Errorf("expected ssh timeout error, got: %v", err)
+		}
+	})
+
+	t.Run("ProxyJumpSkipsProbe", func(t *testing.T) {
+		useWaitClock(t)
+		stubStates(t, "REBOOTING", "RUNNING")
+		var addresses []string
+		stubDial(t, 0, &addresses)
+
+		custom := *dest
+		custom.ProxyJump = "bastion"
+		if err := waitForInstance(&custom, opts(&bytes.Buffer{})); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(addresses) != 0 {
+			t.Errorf("expected no dial through proxy jump, got %v", addresses)
+		}
+	})
+}
+
+func TestRebootWaitCommand(t *testing.T) {
+	setupCloudConfig(t, config.Config{
+		Dest: map[string]config.DestinationInstance{
+			"web": {Ssh: "root@1.2.3.4", Region: "ap-test", InstanceId: "lhins-web"},
+		},
+	})
+	useWaitClock(t)
+	stubStates(t, "REBOOTING")
+
+	originalReboot := rebootInstanceFunc
+	rebootInstanceFunc = func(dest *config.DestinationInstance) (string, error) { return "", nil }
+	defer func() { rebootInstanceFunc = originalReboot }()
+
+	out, err := runCloudCommand(t, "reboot", "web", "--wait", "--timeout", "30s", "--interval", "10s", "--yes")
+	if err == nil || !strings.Contains(err.Error(), "超时") {
+		t.Errorf("expected reboot --wait to fail when the instance never comes back, got: %v", err)
+	}
+	if !strings.Contains(out, "web: 已提交重启请求") {
+		t.Errorf("expected submit message, got:\n%s", out)
+	}
+
+	if _, err := runCloudCommand(t, "stop", "web", "--wait"); err == nil {
+		t.Error("expected stop to reject --wait, got nil")
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRoleSessionName 是未配置 role-session-name 时 AssumeRole 使用的会话名称
+	DefaultRoleSessionName = "lucky-go"
+	// DefaultRoleDuration 是未配置 duration 时临时凭证的有效期
+	DefaultRoleDuration = time.Hour
+	// MaxRoleDuration 是 STS 允许的临时凭证最长有效期
+	MaxRoleDuration = 12 * time.Hour
+)
+
+// Account 是一个腾讯云账号（如项目使用的子账号）的凭证来源，目标通过 account 字段引用。
+// 密钥默认从密钥库的 tencent-cloud-secret-id@<账号> 和 tencent-cloud-secret-key@<账号> 条目读取。
+type Account struct {
+	// TccliProfile 非空时从 tccli 的凭证文件 ~/.tccli/<profile>.credential 读取密钥
+	TccliProfile string `yaml:"tccli-profile,omitempty"`
+	// RoleArn 非空时用账号的密钥调用 STS AssumeRole，使用得到的临时凭证访问云平台。
+	// 账号没有自己的密钥时使用默认凭证扮演角色。
+	RoleArn string `yaml:"role-arn,omitempty"`
+	// RoleSessionName 是 AssumeRole 的会话名称，为空时使用 DefaultRoleSessionName
+	RoleSessionName string `yaml:"role-session-name,omitempty"`
+	// Duration 是临时凭证的有效期，如 2h，为空时使用 DefaultRoleDuration
+	Duration string `yaml:"duration,omitempty"`
+}
+
+// Validate 校验账号的 tccli-profile、role-arn 和 duration。
+func (account Account) Validate() error {
+	if strings.ContainsAny(account.TccliProfile, `/\`) {
+		return fmt.Errorf("tccli-profile %q 不能包含路径分隔符", account.TccliProfile)
+	}
+	if account.RoleArn != "" && !strings.HasPrefix(account.RoleArn, "qcs::cam::") {
+		return fmt.Errorf("role-arn %q 格式不正确，应类似 qcs::cam::uin/100000000001:roleName/ops", account.RoleArn)
+	}
+	if account.RoleArn == "" && (account.RoleSessionName != "" || account.Duration != "") {
+		return fmt.Errorf("role-session-name 和 duration 需要和 role-arn 一起使用")
+	}
+	if _, err := account.RoleDuration(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RoleDuration 返回临时凭证的有效期，未配置时为 DefaultRoleDuration。
+func (account Account) RoleDuration() (time.Duration, error) {
+	if account.Duration == "" {
+		return DefaultRoleDuration, nil
+	}
+
+	duration, err := time.ParseDuration(account.Duration)
+	if err != nil || duration <= 0 || duration > MaxRoleDuration {
+		return 0, fmt.Errorf("duration %q 格式不正确，应为不超过 12h 的时长，如 2h", account.Duration)
+	}
+	return duration, nil
+}
+
+// SessionName 返回 AssumeRole 的会话名称。
+func (account Account) SessionName() string {
+	if account.RoleSessionName == "" {
+		return DefaultRoleSessionName
+	}
+	return account.RoleSessionName
+}
+
+// AccountSecretName 返回账号在密钥库中的凭证名称，如 tencent-cloud-secret-id@project-a。
+func AccountSecretName(secret, account string) string {
+	return secret + "@" + account
+}
+
+// LoadAccount 读取配置中的账号并校验。
+// 账号决定云操作使用哪份凭证，只从主配置文件和环境变量覆盖中读取，忽略项目本地配置文件。
+func LoadAccount(name string) (Account, error) {
+	config, err := loadTrustedConfig()
+	if err != nil {
+		return Account{}, err
+	}
+
+	account, ok := config.Accounts[name]
+	if !ok {
+		return Account{}, fmt.Errorf("配置中不存在账号 %v", name)
+	}
+	if err := account.Validate(); err != nil {
+		return Account{}, fmt.Errorf("账号 %v: %w", name, err)
+	}
+	return account, nil
+}
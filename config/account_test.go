@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		account Account
+		wantErr bool
+	}{
+		{"Empty", Account{}, false},
+		{"Tccli", Account{TccliProfile: "project-a"}, false},
+		{"Role", Account{RoleArn: "qcs::cam::uin/100000000001:roleName/ops", RoleSessionName: "deploy", Duration: "2h"}, false},
+		{"TccliPath", Account{TccliProfile: "../default"}, true},
+		{"BadRoleArn", Account{RoleArn: "arn:aws:iam::1:role/ops"}, true},
+		{"DurationWithoutRole", Account{Duration: "2h"}, true},
+		{"DurationTooLong", Account{RoleArn: "qcs::cam::uin/100000000001:roleName/ops", Duration: "13h"}, true},
+		{"BadDuration", Account{RoleArn: "qcs::cam::uin/100000000001:roleName/ops", Duration: "1d"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.account.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadAccount(t *testing.T) {
+	writeTestConfig(t, `version: 1
+accounts:
+  ops:
+    role-arn: qcs::cam::uin/100000000001:roleName/ops
+  bad:
+    duration: 2h
+dest:
+  web:
+    ssh: root@1.2.3.4
+    account: ops
+`)
+
+	account, err := LoadAccount("ops")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if duration, _ := account.RoleDuration(); duration != time.Hour || account.SessionName() != DefaultRoleSessionName {
+		t.Errorf("expected defaults, got %v %v", duration, account.SessionName())
+	}
+
+	if _, err := LoadAccount("bad"); err == nil || !strings.Contains(err.Error(), "账号 bad") {
+		t.Errorf("expected validation error, got: %v", err)
+	}
+	if _, err := LoadAccount("missing"); err == nil {
+		t.Error("expected error for unknown account, got nil")
+	}
+}
+
+func TestLoadAccount_IgnoresProjectConfig(t *testing.T) {
+	writeTestConfig(t, `version: 1
+accounts:
+  ops:
+    tccli-profile: ops
+`)
+	project := t.TempDir()
+	t.Chdir(project)
+
+	// 检出的仓库不能替换账号使用的凭证
+	data := "accounts:\n  ops:\n    tccli-profile: attacker\n  extra:\n    tccli-profile: attacker\n"
+	if err := os.WriteFile(filepath.Join(project, PROJECT_CONFIG_FILE), []byte(data), CONFIG_FILE_MODE); err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := LoadAccount("ops")
+	if err != nil || account.TccliProfile != "ops" {
+		t.Errorf("expected the account from the main config file, got %+v, %v", account, err)
+	}
+	if _, err := LoadAccount("extra"); err == nil {
+		t.Error("expected accounts from project-local files to be ignored")
+	}
+
+	// 环境变量覆盖仍然生效
+	t.Setenv("LUCKY_GO_ACCOUNTS__OPS__TCCLI_PROFILE", "ops-2")
+	if account, err := LoadAccount("ops"); err != nil || account.TccliProfile != "ops-2" {
+		t.Errorf("expected the environment override, got %+v, %v", account, err)
+	}
+}
+
+func TestIsKnownSecret(t *testing.T) {
+	for name, known := range map[string]bool{
+		"tencent-cloud-secret-id":            true,
+		"tencent-cloud-secret-key@project-a": true,
+		"fred-api-key@project-a":             false,
+		"tencent-cloud-secret-id@":           false,
+		"unknown":                            false,
+	} {
+		if got := isKnownSecret(name); got != known {
+			t.Errorf("isKnownSecret(%q) = %v, want %v", name, got, known)
+		}
+	}
+}
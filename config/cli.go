@@ -0,0 +1,451 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"lucky-go/output"
+)
+
+// NewCommand 为配置模块创建并返回 config 命令及其子命令。
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "管理配置文件中的目标",
+		Long: `管理 ~/.lucky-go/config.yaml 中的目标（dest），无需手动编辑 YAML。
+
+示例:
+  lucky-go config add web --ssh root@1.2.3.4 --region ap-hongkong --instance-id lhins-abc123 --tag prod
+  lucky-go config list
+  lucky-go config list tag=prod,region=ap-hongkong
+  lucky-go config group set web 'web-*' db-1
+  lucky-go config edit web --region ap-singapore
+  lucky-go config rename web web-1
+  lucky-go config remove web-1
+  lucky-go config import ssh-config
+  lucky-go config export ssh-config -o ~/.ssh/lucky-go.conf
+  lucky-go config view --resolved`,
+	}
+
+	cmd.AddCommand(newAddCommand())
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newShowCommand())
+	cmd.AddCommand(newEditCommand())
+	cmd.AddCommand(newRemoveCommand())
+	cmd.AddCommand(newRenameCommand())
+	cmd.AddCommand(newViewCommand())
+	cmd.AddCommand(newGroupCommand())
+	cmd.AddCommand(newImportCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newSecretsCommand())
+	cmd.AddCommand(newMigrateCommand())
+
+	return cmd
+}
+
+// newAddCommand 创建 config add 子命令，用于新增目标。
+func newAddCommand() *cobra.Command {
+	var dest DestinationInstance
+
+	cmd := &cobra.Command{
+		Use:   "add [destination]",
+		Short: "新增目标",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if err := ValidateDestinationName(name); err != nil {
+				return err
+			}
+			if err := dest.Validate(); err != nil {
+				return err
+			}
+
+			err := Update(func(config *Config) error {
+				if _, ok := config.Dest[name]; ok {
+					return fmt.Errorf("目标 %v 已存在", name)
+				}
+				if config.Dest == nil {
+					config.Dest = map[string]DestinationInstance{}
+				}
+				config.Dest[name] = dest
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已添加目标 %v\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dest.Ssh, "ssh", "", "SSH 连接字符串，如 root@1.2.3.4")
+	cmd.Flags().IntVar(&dest.Port, "port", 0, "SSH 端口")
+	cmd.Flags().StringVar(&dest.IdentityFile, "identity-file", "", "SSH 私钥路径")
+	cmd.Flags().StringVar(&dest.ProxyJump, "proxy-jump", "", "SSH 跳板机，如 user@bastion")
+	cmd.Flags().StringVar(&dest.Region, "region", "", "云区域，如 ap-beijing")
+	cmd.Flags().StringVar(&dest.InstanceId, "instance-id", "", "实例 ID，如 lhins-xxxxxxxx")
+	cmd.Flags().StringVar(&dest.Provider, "provider", "", "云平台: lighthouse（默认）、cvm 或 fake")
+	cmd.Flags().StringSliceVar(&dest.Tags, "tag", nil, "标签，可重复指定或用逗号分隔")
+	cmd.Flags().StringVar(&dest.Account, "account", "", "腾讯云账号，对应配置中 accounts 的名称")
+	cmd.Flags().BoolVar(&dest.Protected, "protected", false, "保护目标，cloud 命令的破坏性操作需要 --force")
+	_ = cmd.MarkFlagRequired("ssh")
+
+	return cmd
+}
+
+// newListCommand 创建 config list 子命令，以表格形式列出所有目标。
+func newListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list [selector...]",
+		Aliases: []string{"ls"},
+		Short:   "列出目标，可按选择器过滤",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			if len(config.Dest) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "配置中没有任何目标")
+				return nil
+			}
+
+			if len(args) == 0 {
+				args = []string{"*"}
+			}
+			dests, err := config.Select(args...)
+			if err != nil {
+				return err
+			}
+
+			renderDestinationTable(cmd.OutOrStdout(), dests)
+			return nil
+		},
+	}
+}
+
+// newShowCommand 创建 config show 子命令，以 YAML 形式输出单个目标。
+func newShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [destination]",
+		Short: "显示目标详情",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dest, err := LoadDestinationInstance(args[0])
+			if err != nil {
+				return err
+			}
+
+			bytes, err := yaml.Marshal(map[string]DestinationInstance{args[0]: *dest})
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(bytes)
+			return err
+		},
+	}
+}
+
+// newEditCommand 创建 config edit 子命令，只修改通过标志显式指定的字段。
+func newEditCommand() *cobra.Command {
+	var ssh, provider, region, instanceId, identityFile, proxyJump, account string
+	var port int
+	var tags []string
+	var protected bool
+
+	cmd := &cobra.Command{
+		Use:   "edit [destination]",
+		Short: "修改目标字段",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			flags := cmd.Flags()
+			changed := false
+			for _, flag := range []string{"ssh", "port", "identity-file", "proxy-jump", "provider", "region", "instance-id", "tag", "account", "protected"} {
+				changed = changed || flags.Changed(flag)
+			}
+			if !changed {
+				return errors.New("至少需要指定 --ssh、--port、--identity-file、--proxy-jump、--provider、--region、--instance-id、--tag、--account、--protected 中的一个")
+			}
+
+			err := Update(func(config *Config) error {
+				dest, ok := config.Dest[name]
+				if !ok {
+					return fmt.Errorf("配置中不存在目标 %v", name)
+				}
+
+				if flags.Changed("ssh") {
+					dest.Ssh = ssh
+				}
+				if flags.Changed("port") {
+					dest.Port = port
+				}
+				if flags.Changed("identity-file") {
+					dest.IdentityFile = identityFile
+				}
+				if flags.Changed("proxy-jump") {
+					dest.ProxyJump = proxyJump
+				}
+				if flags.Changed("region") {
+					dest.Region = region
+				}
+				if flags.Changed("provider") {
+					dest.Provider = provider
+				}
+				if flags.Changed("instance-id") {
+					dest.InstanceId = instanceId
+				}
+				if flags.Changed("tag") {
+					dest.Tags = tags
+				}
+				if flags.Changed("account") {
+					dest.Account = account
+				}
+				if flags.Changed("protected") {
+					dest.Protected = protected
+				}
+
+				if err := dest.Validate(); err != nil {
+					return err
+				}
+				config.Dest[name] = dest
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已更新目标 %v\n", name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&ssh, "ssh", "", "SSH 连接字符串")
+	cmd.Flags().IntVar(&port, "port", 0, "SSH 端口，0 表示使用默认端口")
+	cmd.Flags().StringVar(&identityFile, "identity-file", "", "SSH 私钥路径")
+	cmd.Flags().StringVar(&proxyJump, "proxy-jump", "", "SSH 跳板机")
+	cmd.Flags().StringVar(&region, "region", "", "云区域")
+	cmd.Flags().StringVar(&instanceId, "instance-id", "", "实例 ID")
+	cmd.Flags().StringVar(&provider, "provider", "", "云平台，传入空字符串恢复默认")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "替换全部标签，传入空字符串可清空")
+	cmd.Flags().StringVar(&account, "account", "", "腾讯云账号，传入空字符串恢复默认凭证")
+	cmd.Flags().BoolVar(&protected, "protected", false, "保护目标，使用 --protected=false 取消保护")
+
+	return cmd
+}
+
+// newRemoveCommand 创建 config remove 子命令，用于删除目标。
+func newRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove [destination]",
+		Aliases: []string{"rm"},
+		Short:   "删除目标",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			err := Update(func(config *Config) error {
+				if _, ok := config.Dest[name]; !ok {
+					return fmt.Errorf("配置中不存在目标 %v", name)
+				}
+				delete(config.Dest, name)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已删除目标 %v\n", name)
+			return nil
+		},
+	}
+}
+
+// newRenameCommand 创建 config rename 子命令，用于重命名目标。
+func newRenameCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename [destination] [new-name]",
+		Short: "重命名目标",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldName, newName := args[0], args[1]
+			if err := ValidateDestinationName(newName); err != nil {
+				return err
+			}
+
+			err := Update(func(config *Config) error {
+				dest, ok := config.Dest[oldName]
+				if !ok {
+					return fmt.Errorf("配置中不存在目标 %v", oldName)
+				}
+				if _, ok := config.Dest[newName]; ok {
+					return fmt.Errorf("目标 %v 已存在", newName)
+				}
+
+				delete(config.Dest, oldName)
+				config.Dest[newName] = dest
+
+				// 同步更新分组中对该目标的直接引用
+				for group, members := range config.Groups {
+					for i, member := range members {
+						if member == oldName {
+							config.Groups[group][i] = newName
+						}
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已将目标 %v 重命名为 %v\n", oldName, newName)
+			return nil
+		},
+	}
+}
+
+// newViewCommand 创建 config view 子命令，用于查看主配置文件或合并后的最终配置。
+func newViewCommand() *cobra.Command {
+	var resolvedView bool
+
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "查看配置",
+		Long: `查看当前生效的主配置文件内容。
+
+配置按以下顺序确定主配置文件：--config 标志、LUCKY_GO_CONFIG 环境变量、
+$XDG_CONFIG_HOME/lucky-go/config.yaml、~/.lucky-go/config.yaml。
+当前目录及其上级目录中的 .lucky-go.yaml 会依次叠加在主配置之上，
+最后应用 LUCKY_GO_ 前缀的环境变量覆盖（如 LUCKY_GO_DEST__WEB__SSH）。
+accounts、endpoints 和 secrets 决定使用哪份凭证以及凭证发往哪里，只从主配置文件和环境变量读取，
+.lucky-go.yaml 中的这些配置项会被忽略。
+
+使用 --resolved 查看合并后的最终配置以及每个值的来源。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			if !resolvedView {
+				path, err := getConfigFilePath()
+				if err != nil {
+					return err
+				}
+				bytes, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "# %v\n", path)
+				_, err = out.Write(bytes)
+				return err
+			}
+
+			resolved, err := Resolve()
+			if err != nil {
+				return err
+			}
+
+			node := &yaml.Node{}
+			if err := node.Encode(resolved.Config); err != nil {
+				return err
+			}
+			annotateSources(node, "", resolved.Sources)
+
+			for _, layer := range resolved.Layers {
+				fmt.Fprintf(out, "# layer: %v\n", layer)
+			}
+
+			encoder := yaml.NewEncoder(out)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(node); err != nil {
+				return err
+			}
+			return encoder.Close()
+		},
+	}
+
+	cmd.Flags().BoolVar(&resolvedView, "resolved", false, "显示合并后的最终配置及每个值的来源")
+
+	return cmd
+}
+
+// newMigrateCommand 创建 config migrate 子命令，将主配置文件升级到当前结构版本。
+func newMigrateCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "升级配置文件结构版本",
+		Long: `将主配置文件逐步升级到当前程序支持的结构版本，升级前会写入带时间戳的备份。
+
+读取旧版本配置文件时只在内存中迁移，不修改文件；修改配置时才会备份并写回。使用 --dry-run 只显示将要发生的变更。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			path, err := getConfigFilePath()
+			if err != nil {
+				return err
+			}
+
+			return WithFileLock(path, func() error {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				result, err := migrateBytes(data)
+				if err != nil {
+					return err
+				}
+
+				if len(result.steps) == 0 {
+					fmt.Fprintf(out, "配置文件已是最新版本 %d\n", CURRENT_VERSION)
+					return nil
+				}
+
+				for _, step := range result.steps {
+					fmt.Fprintf(out, "迁移 %v\n", step)
+				}
+
+				if dryRun {
+					fmt.Fprintf(out, "--- %v\n+++ %v (版本 %d)\n", path, path, result.to)
+					fmt.Fprint(out, lineDiff(string(result.before), string(result.after)))
+					return nil
+				}
+
+				backup, err := writeMigrated(path, result)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(out, "配置文件已升级到版本 %d，原文件备份为 %v\n", result.to, backup)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示差异，不修改文件")
+
+	return cmd
+}
+
+// renderDestinationTable 渲染目标列表表格
+func renderDestinationTable(w io.Writer, dests []Destination) {
+	table := output.NewTable(w)
+
+	table.Header([]string{"名称", "SSH", "区域", "实例 ID", "标签"})
+	for _, dest := range dests {
+		_ = table.Append([]string{dest.Name, dest.Ssh, dest.Region, dest.InstanceId, strings.Join(dest.Tags, ",")})
+	}
+
+	_ = table.Render()
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newGroupCommand 创建 config group 命令组，用于管理目标分组。
+func newGroupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "group",
+		Short: "管理目标分组",
+		Long: `管理 groups 中的命名分组。分组成员可以是目标名称、通配符、
+tag=prod 之类的条件或其他分组（@name），之后可以在任何接受目标的地方使用 @分组名。
+
+示例:
+  lucky-go config group set web 'web-*' tag=frontend
+  lucky-go config group list
+  lucky-go cloud reboot @web`,
+	}
+
+	cmd.AddCommand(newGroupSetCommand())
+	cmd.AddCommand(newGroupListCommand())
+	cmd.AddCommand(newGroupRemoveCommand())
+
+	return cmd
+}
+
+// newGroupSetCommand 创建 config group set 子命令，创建或覆盖分组
+func newGroupSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set [group] [selector...]",
+		Short: "创建或覆盖分组",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			group, members := args[0], args[1:]
+			if err := ValidateDestinationName(group); err != nil {
+				return err
+			}
+
+			var count int
+			err := Update(func(config *Config) error {
+				if config.Groups == nil {
+					config.Groups = map[string][]string{}
+				}
+				config.Groups[group] = members
+
+				dests, err := config.Select("@" + group)
+				if err != nil {
+					return err
+				}
+				count = len(dests)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已保存分组 %v，当前匹配 %d 个目标\n", group, count)
+			return nil
+		},
+	}
+}
+
+// newGroupListCommand 创建 config group list 子命令
+func newGroupListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出所有分组及其成员",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			if len(config.Groups) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "配置中没有任何分组")
+				return nil
+			}
+
+			groups := make([]string, 0, len(config.Groups))
+			for group := range config.Groups {
+				groups = append(groups, group)
+			}
+			sort.Strings(groups)
+
+			for _, group := range groups {
+				fmt.Fprintf(cmd.OutOrStdout(), "@%v: %v\n", group, strings.Join(config.Groups[group], " "))
+			}
+			return nil
+		},
+	}
+}
+
+// newGroupRemoveCommand 创建 config group rm 子命令
+func newGroupRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm [group]",
+		Aliases: []string{"remove"},
+		Short:   "删除分组",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			group := args[0]
+
+			err := Update(func(config *Config) error {
+				if _, ok := config.Groups[group]; !ok {
+					return fmt.Errorf("配置中不存在分组 %v", group)
+				}
+				delete(config.Groups, group)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已删除分组 %v\n", group)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// newSecretsCommand 创建 config secrets 命令组，用于管理加密保存的凭证。
+func newSecretsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "管理加密保存的 API 凭证",
+		Long: `在配置文件中加密保存 API 凭证，密钥由口令经 scrypt 派生，使用 AES-GCM 加密。
+
+口令依次从 LUCKY_GO_PASSPHRASE、LUCKY_GO_PASSPHRASE_FILE 环境变量读取，
+均未设置时在终端提示输入。cloud、pe、cape 等命令会优先使用这里保存的凭证，
+不存在时回退到对应的环境变量。
+
+已知凭证:
+  tencent-cloud-secret-id   (TENCENT_CLOUD_SECRET_ID)
+  tencent-cloud-secret-key  (TENCENT_CLOUD_SECRET_KEY)
+  fred-api-key              (FRED_API_KEY)
+  telegram-bot-token        (TELEGRAM_BOT_TOKEN)
+  telegram-chat-id          (TELEGRAM_CHAT_ID)
+
+配置中 accounts 下的腾讯云账号使用 tencent-cloud-secret-id@<账号> 和
+tencent-cloud-secret-key@<账号> 保存各自的密钥。
+
+示例:
+  lucky-go config secrets set fred-api-key           # 在终端中输入值，不会留在 shell 历史中
+  lucky-go config secrets set tencent-cloud-secret-key@project-a
+  echo "$TOKEN" | lucky-go config secrets set telegram-bot-token
+  lucky-go config secrets list
+  lucky-go config secrets rm fred-api-key`,
+	}
+
+	cmd.AddCommand(newSecretsSetCommand())
+	cmd.AddCommand(newSecretsGetCommand())
+	cmd.AddCommand(newSecretsListCommand())
+	cmd.AddCommand(newSecretsRemoveCommand())
+
+	return cmd
+}
+
+// newSecretsSetCommand 创建 config secrets set 子命令
+func newSecretsSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set [name]",
+		Short: "加密保存凭证，值从标准输入读取",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if !isKnownSecret(name) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "警告: %v 不是已知凭证名称\n", name)
+			}
+
+			value, err := readSecretValue(cmd.InOrStdin(), cmd.ErrOrStderr(), name)
+			if err != nil {
+				return err
+			}
+
+			// 在获取配置文件锁之前完成口令输入，避免长时间占用锁
+			current, err := loadConfigFile()
+			if err != nil {
+				return err
+			}
+
+			var created *SecretStore
+			if current.Secrets == nil {
+				if created, err = newSecretStore(); err != nil {
+					return err
+				}
+			} else if _, err := current.Secrets.key(); err != nil {
+				return err
+			}
+
+			err = Update(func(config *Config) error {
+				if config.Secrets == nil {
+					if created == nil {
+						return errors.New("密钥库已被其他进程删除，请重试")
+					}
+					config.Secrets = created
+				}
+				return config.Secrets.Set(name, value)
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已保存凭证 %v\n", name)
+			return nil
+		},
+	}
+}
+
+// newSecretsGetCommand 创建 config secrets get 子命令
+func newSecretsGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get [name]",
+		Short: "解密并输出凭证",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfigFile()
+			if err != nil {
+				return err
+			}
+
+			if config.Secrets == nil {
+				return errors.New("配置中没有密钥库")
+			}
+
+			value, err := config.Secrets.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+// newSecretsListCommand 创建 config secrets list 子命令，只列出名称
+func newSecretsListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "列出已保存的凭证名称",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfigFile()
+			if err != nil {
+				return err
+			}
+
+			if config.Secrets == nil || len(config.Secrets.Entries) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "密钥库中没有任何凭证")
+				return nil
+			}
+
+			for _, name := range config.Secrets.Names() {
+				fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+			return nil
+		},
+	}
+}
+
+// newSecretsRemoveCommand 创建 config secrets rm 子命令
+func newSecretsRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm [name]",
+		Aliases: []string{"remove"},
+		Short:   "删除凭证",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			err := Update(func(config *Config) error {
+				if config.Secrets == nil {
+					return errors.New("配置中没有密钥库")
+				}
+				if _, ok := config.Secrets.Entries[name]; !ok {
+					return fmt.Errorf("密钥库中不存在凭证 %v", name)
+				}
+				delete(config.Secrets.Entries, name)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已删除凭证 %v\n", name)
+			return nil
+		},
+	}
+}
+
+// readSecretValue 从标准输入读取凭证值：终端下不回显，否则读取第一行
+func readSecretValue(in io.Reader, prompt io.Writer, name string) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(prompt, "请输入 %v 的值: ", name)
+		bytes, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", err
+		}
+		return validateSecretValue(string(bytes))
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return validateSecretValue(strings.TrimRight(line, "\r\n"))
+}
+
+// validateSecretValue 拒绝空值
+func validateSecretValue(value string) (string, error) {
+	if value == "" {
+		return "", errors.New("凭证值不能为空")
+	}
+	return value, nil
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// 导入时遇到同名目标的处理方式
+const (
+	conflictAsk       = "ask"
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+)
+
+// newImportCommand 创建 config import 命令组
+func newImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "从其他来源导入目标",
+	}
+
+	cmd.AddCommand(newImportSSHConfigCommand())
+
+	return cmd
+}
+
+// newExportCommand 创建 config export 命令组
+func newExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "把目标导出为其他格式",
+	}
+
+	cmd.AddCommand(newExportSSHConfigCommand())
+
+	return cmd
+}
+
+// importAction 是单个导入目标的处理结果
+type importAction struct {
+	// name 是写入配置时使用的名称，重命名时与来源中的 Host 不同
+	name string
+	dest DestinationInstance
+	// overwrite 表示更新已有目标的 SSH 字段
+	overwrite bool
+}
+
+// newImportSSHConfigCommand 创建 config import ssh-config 子命令
+func newImportSSHConfigCommand() *cobra.Command {
+	var onConflict string
+
+	cmd := &cobra.Command{
+		Use:   "ssh-config [file]",
+		Short: "从 ~/.ssh/config 导入 Host 块",
+		Long: `解析 ssh_config 中的 Host 块（HostName、User、Port、IdentityFile、ProxyJump），
+导入为配置中的目标。通配符 Host 和 Match 块会被跳过，Include 的文件会被展开。
+
+与已有目标的 SSH 字段不同时视为冲突，默认逐个询问跳过、覆盖或重命名；
+覆盖只更新 SSH 相关字段，保留 region、instance-id 和标签。`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch onConflict {
+			case conflictAsk, conflictSkip, conflictOverwrite:
+			default:
+				return fmt.Errorf("--on-conflict 只能是 ask、skip 或 overwrite")
+			}
+
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			} else {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return err
+				}
+				path = filepath.Join(home, ".ssh", "config")
+			}
+
+			imported, warnings, err := ParseSSHConfig(path)
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "警告: %v\n", warning)
+			}
+
+			// 在获取配置文件锁之前完成交互，避免长时间占用锁
+			current, err := loadConfigFile()
+			if err != nil {
+				return err
+			}
+
+			// 重命名时不能使用已有目标或本次导入的其他目标的名称
+			taken := map[string]bool{}
+			for name := range current.Dest {
+				taken[name] = true
+			}
+			for _, dest := range imported {
+				taken[dest.Name] = true
+			}
+
+			prompt := &conflictPrompt{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.OutOrStdout()}
+			var actions []importAction
+			unchanged, skipped := 0, 0
+			for _, dest := range imported {
+				existing, ok := current.Dest[dest.Name]
+				if !ok {
+					actions = append(actions, importAction{name: dest.Name, dest: dest.DestinationInstance})
+					continue
+				}
+				if sameSSHFields(existing, dest.DestinationInstance) {
+					unchanged++
+					continue
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "冲突: 目标 %v 已存在\n", dest.Name)
+				for _, line := range diffSSHFields(existing, dest.DestinationInstance) {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %v\n", line)
+				}
+
+				choice := onConflict
+				newName := ""
+				if choice == conflictAsk {
+					choice, newName, err = prompt.ask(taken)
+					if err != nil {
+						return err
+					}
+				}
+
+				switch choice {
+				case conflictOverwrite:
+					actions = append(actions, importAction{name: dest.Name, dest: dest.DestinationInstance, overwrite: true})
+				case "rename":
+					taken[newName] = true
+					actions = append(actions, importAction{name: newName, dest: dest.DestinationInstance})
+				default:
+					skipped++
+				}
+			}
+
+			added, updated := 0, 0
+			err = Update(func(config *Config) error {
+				added, updated = 0, 0
+				if config.Dest == nil {
+					config.Dest = map[string]DestinationInstance{}
+				}
+				for _, action := range actions {
+					existing, ok := config.Dest[action.name]
+					if ok && !action.overwrite {
+						return fmt.Errorf("目标 %v 已被其他进程添加，请重试", action.name)
+					}
+					if ok {
+						existing.Ssh = action.dest.Ssh
+						existing.Port = action.dest.Port
+						existing.IdentityFile = action.dest.IdentityFile
+						existing.ProxyJump = action.dest.ProxyJump
+						config.Dest[action.name] = existing
+						updated++
+						continue
+					}
+					config.Dest[action.name] = action.dest
+					added++
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "导入完成: 新增 %d 个，更新 %d 个，跳过 %d 个，未变化 %d 个\n", added, updated, skipped, unchanged)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&onConflict, "on-conflict", conflictAsk, "遇到同名目标时的处理方式: ask、skip 或 overwrite")
+
+	return cmd
+}
+
+// newExportSSHConfigCommand 创建 config export ssh-config 子命令
+func newExportSSHConfigCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "ssh-config [selector...]",
+		Short: "把目标导出为 ssh_config 格式",
+		Long: `把目标导出为 ssh_config 格式的 Host 块，默认导出全部目标并输出到标准输出。
+
+使用 --output 写入文件后，在 ~/.ssh/config 开头加入 Include 即可直接使用 ssh <名称>:
+  lucky-go config export ssh-config -o ~/.ssh/lucky-go.conf
+  # ~/.ssh/config 第一行
+  Include lucky-go.conf`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				if len(config.Dest) == 0 {
+					return fmt.Errorf("配置中没有任何目标")
+				}
+				args = []string{"*"}
+			}
+			dests, err := config.Select(args...)
+			if err != nil {
+				return err
+			}
+
+			content := RenderSSHConfig(dests)
+			if output == "" {
+				_, err := io.WriteString(cmd.OutOrStdout(), content)
+				return err
+			}
+
+			if err := WriteFileAtomic(output, []byte(content)); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "已导出 %d 个目标到 %v\n", len(dests), output)
+			fmt.Fprintf(cmd.ErrOrStderr(), "提示: 在 ~/.ssh/config 开头加入 Include %v\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "写入的文件路径，默认输出到标准输出")
+
+	return cmd
+}
+
+// conflictPrompt 在导入遇到冲突时询问处理方式
+type conflictPrompt struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask 询问如何处理冲突，返回 skip、overwrite 或 rename 及重命名后的名称。
+// 输入结束时按跳过处理。
+func (prompt *conflictPrompt) ask(taken map[string]bool) (string, string, error) {
+	for {
+		fmt.Fprint(prompt.out, "[s]跳过 / [o]覆盖 / [r]重命名后导入（默认跳过）: ")
+		answer, eof, err := prompt.readLine()
+		if err != nil {
+			return "", "", err
+		}
+
+		switch strings.ToLower(answer) {
+		case "", "s", "skip":
+			return conflictSkip, "", nil
+		case "o", "overwrite":
+			return conflictOverwrite, "", nil
+		case "r", "rename":
+			newName, err := prompt.askName(taken)
+			if err != nil {
+				return "", "", err
+			}
+			if newName == "" {
+				return conflictSkip, "", nil
+			}
+			return "rename", newName, nil
+		}
+
+		if eof {
+			return conflictSkip, "", nil
+		}
+		fmt.Fprintf(prompt.out, "无法识别的选项 %q\n", answer)
+	}
+}
+
+// askName 询问新的目标名称，名称不合法或已存在时重新询问，输入结束时返回空字符串
+func (prompt *conflictPrompt) askName(taken map[string]bool) (string, error) {
+	for {
+		fmt.Fprint(prompt.out, "新的名称: ")
+		name, eof, err := prompt.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if name != "" {
+			if err := ValidateDestinationName(name); err != nil {
+				fmt.Fprintln(prompt.out, err)
+			} else if taken[name] {
+				fmt.Fprintf(prompt.out, "目标 %v 已存在\n", name)
+			} else {
+				return name, nil
+			}
+		}
+
+		if eof {
+			return "", nil
+		}
+	}
+}
+
+// readLine 读取一行并去掉首尾空白
+func (prompt *conflictPrompt) readLine() (string, bool, error) {
+	line, err := prompt.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+	return strings.TrimSpace(line), err == io.EOF, nil
+}
+
+// sameSSHFields 判断两个目标的 SSH 相关字段是否相同
+func sameSSHFields(a, b DestinationInstance) bool {
+	return a.Ssh == b.Ssh && a.Port == b.Port && a.IdentityFile == b.IdentityFile && a.ProxyJump == b.ProxyJump
+}
+
+// diffSSHFields 列出两个目标 SSH 相关字段的差异，格式为 "字段: 旧值 → 新值"
+func diffSSHFields(old, new DestinationInstance) []string {
+	port := func(p int) string {
+		if p == 0 {
+			return ""
+		}
+		return strconv.Itoa(p)
+	}
+
+	fields := []struct{ name, old, new string }{
+		{"ssh", old.Ssh, new.Ssh},
+		{"port", port(old.Port), port(new.Port)},
+		{"identity-file", old.IdentityFile, new.IdentityFile},
+		{"proxy-jump", old.ProxyJump, new.ProxyJump},
+	}
+
+	var lines []string
+	for _, field := range fields {
+		if field.old != field.new {
+			lines = append(lines, fmt.Sprintf("%v: %q → %q", field.name, field.old, field.new))
+		}
+	}
+	return lines
+}
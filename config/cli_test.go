@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// setupTempHome 将 HOME 指向临时目录，并在测试结束后恢复
+func setupTempHome(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	originalHomeDir := os.Getenv("HOME")
+	originalUserProfile := os.Getenv("USERPROFILE")
+
+	os.Setenv("HOME", tempDir)
+	os.Setenv("USERPROFILE", tempDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHomeDir)
+		os.Setenv("USERPROFILE", originalUserProfile)
+	})
+
+	return tempDir
+}
+
+// runConfigCommand 以给定参数执行 config 命令并返回输出
+func runConfigCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := NewCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs(args)
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestConfigCommands(t *testing.T) {
+	setupTempHome(t)
+
+	t.Run("Add", func(t *testing.T) {
+		_, err := runConfigCommand(t, "add", "web", "--ssh", "root@1.2.3.4", "--region", "ap-hongkong", "--instance-id", "lhins-abc123")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		dest, err := LoadDestinationInstance("web")
+		if err != nil {
+			t.Fatalf("expected destination to be saved, got: %v", err)
+		}
+		if dest.Ssh != "root@1.2.3.4" || dest.Region != "ap-hongkong" || dest.InstanceId != "lhins-abc123" {
+			t.Errorf("unexpected destination: %+v", dest)
+		}
+	})
+
+	t.Run("AddDuplicate", func(t *testing.T) {
+		_, err := runConfigCommand(t, "add", "web", "--ssh", "root@5.6.7.8")
+		if err == nil || !strings.Contains(err.Error(), "已存在") {
+			t.Errorf("expected duplicate error, got: %v", err)
+		}
+	})
+
+	t.Run("AddInvalid", func(t *testing.T) {
+		_, err := runConfigCommand(t, "add", "bad", "--ssh", "root@1.2.3.4", "--region", "ap-beijing")
+		if err == nil {
+			t.Error("expected validation error when instance-id is missing, got nil")
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		out, err := runConfigCommand(t, "list")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(out, "web") || !strings.Contains(out, "lhins-abc123") {
+			t.Errorf("expected table to contain destination, got:\n%s", out)
+		}
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		_, err := runConfigCommand(t, "edit", "web", "--region", "ap-singapore")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		dest, _ := LoadDestinationInstance("web")
+		if dest.Region != "ap-singapore" {
+			t.Errorf("expected region 'ap-singapore', got '%s'", dest.Region)
+		}
+		if dest.Ssh != "root@1.2.3.4" {
+			t.Errorf("expected ssh to stay unchanged, got '%s'", dest.Ssh)
+		}
+	})
+
+	t.Run("EditProtected", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "edit", "web", "--protected"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if dest, _ := LoadDestinationInstance("web"); !dest.Protected {
+			t.Error("expected destination to be protected")
+		}
+
+		if _, err := runConfigCommand(t, "edit", "web", "--protected=false"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if dest, _ := LoadDestinationInstance("web"); dest.Protected {
+			t.Error("expected destination to be unprotected")
+		}
+	})
+
+	t.Run("EditAccount", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "edit", "web", "--account", "project-a"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if dest, _ := LoadDestinationInstance("web"); dest.Account != "project-a" {
+			t.Errorf("expected account 'project-a', got '%s'", dest.Account)
+		}
+
+		if _, err := runConfigCommand(t, "edit", "web", "--account", ""); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if dest, _ := LoadDestinationInstance("web"); dest.Account != "" {
+			t.Errorf("expected account to be cleared, got '%s'", dest.Account)
+		}
+	})
+
+	t.Run("EditWithoutFlags", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "edit", "web"); err == nil {
+			t.Error("expected error when no field is given, got nil")
+		}
+	})
+
+	t.Run("Show", func(t *testing.T) {
+		out, err := runConfigCommand(t, "show", "web")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(out, "instance-id: lhins-abc123") {
+			t.Errorf("expected yaml output, got:\n%s", out)
+		}
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "rename", "web", "web-1"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if _, err := LoadDestinationInstance("web"); err == nil {
+			t.Error("expected old name to be gone")
+		}
+		if _, err := LoadDestinationInstance("web-1"); err != nil {
+			t.Errorf("expected new name to exist, got: %v", err)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "remove", "web-1"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if _, err := LoadDestinationInstance("web-1"); err == nil {
+			t.Error("expected destination to be removed")
+		}
+	})
+
+	t.Run("RemoveNonExistent", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "remove", "non-existent"); err == nil {
+			t.Error("expected error for non-existent destination, got nil")
+		}
+	})
+}
+
+func TestDestinationInstanceValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dest    DestinationInstance
+		wantErr bool
+	}{
+		{"SshOnly", DestinationInstance{Ssh: "root@example.com"}, false},
+		{"HostOnly", DestinationInstance{Ssh: "example.com"}, false},
+		{"Full", DestinationInstance{Ssh: "root@1.2.3.4", Region: "ap-beijing", InstanceId: "lhins-abc123"}, false},
+		{"CvmInstance", DestinationInstance{Ssh: "root@1.2.3.4", Region: "ap-shanghai-fsi", InstanceId: "ins-abc123"}, false},
+		{"EmptySsh", DestinationInstance{}, true},
+		{"SshWithSpace", DestinationInstance{Ssh: "root@exa mple.com"}, true},
+		{"SshMissingUser", DestinationInstance{Ssh: "@example.com"}, true},
+		{"SshMissingHost", DestinationInstance{Ssh: "root@"}, true},
+		{"SshLeadingDash", DestinationInstance{Ssh: "-oProxyCommand=curl${IFS}example.com"}, true},
+		{"ProxyJumpLeadingDash", DestinationInstance{Ssh: "root@1.2.3.4", ProxyJump: "-oProxyCommand=id"}, true},
+		{"IdentityFileLeadingDash", DestinationInstance{Ssh: "root@1.2.3.4", IdentityFile: "-oProxyCommand=id"}, true},
+		{"RegionWithoutInstance", DestinationInstance{Ssh: "root@1.2.3.4", Region: "ap-beijing"}, true},
+		{"BadRegion", DestinationInstance{Ssh: "root@1.2.3.4", Region: "Beijing", InstanceId: "lhins-abc123"}, true},
+		{"BadInstanceId", DestinationInstance{Ssh: "root@1.2.3.4", Region: "ap-beijing", InstanceId: "vm-123"}, true},
+		{"BadPort", DestinationInstance{Ssh: "root@1.2.3.4", Port: 70000}, true},
+		{"CvmProvider", DestinationInstance{Ssh: "root@1.2.3.4", Provider: ProviderCVM, Region: "ap-beijing", InstanceId: "ins-abc123"}, false},
+		{"UnknownProvider", DestinationInstance{Ssh: "root@1.2.3.4", Provider: "aws"}, true},
+		{"Account", DestinationInstance{Ssh: "root@1.2.3.4", Account: "project-a"}, false},
+		{"BadAccount", DestinationInstance{Ssh: "root@1.2.3.4", Account: "project a"}, true},
+		{"SnapshotPolicy", DestinationInstance{Ssh: "root@1.2.3.4", Snapshots: &SnapshotPolicy{Keep: 3, MaxAge: "30d"}}, false},
+		{"BadSnapshotAge", DestinationInstance{Ssh: "root@1.2.3.4", Snapshots: &SnapshotPolicy{MaxAge: "month"}}, true},
+		{"NegativeSnapshotKeep", DestinationInstance{Ssh: "root@1.2.3.4", Snapshots: &SnapshotPolicy{Keep: -1}}, true},
+		{"TrafficPolicy", DestinationInstance{Ssh: "root@1.2.3.4", Traffic: &TrafficPolicy{Alerts: []int{80, 100}, StopAt: 120}}, false},
+		{"BadTrafficAlert", DestinationInstance{Ssh: "root@1.2.3.4", Traffic: &TrafficPolicy{Alerts: []int{0}}}, true},
+		{"Firewall", DestinationInstance{Ssh: "root@1.2.3.4", Firewall: []FirewallRule{{Protocol: "TCP", Port: "22"}, {Protocol: "ICMP"}}}, false},
+		{"BadFirewallRule", DestinationInstance{Ssh: "root@1.2.3.4", Firewall: []FirewallRule{{Protocol: "TCP"}}}, true},
+		{"DuplicateFirewallRule", DestinationInstance{Ssh: "root@1.2.3.4", Firewall: []FirewallRule{{Protocol: "tcp", Port: "22"}, {Protocol: "TCP", Port: "22", Description: "SSH"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dest.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
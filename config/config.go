@@ -3,9 +3,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,36 +17,229 @@ import (
 const CONFIG_DIR = ".lucky-go"
 const CONFIG_FILE = "config.yaml"
 
+// 目标实例所属的云平台，未设置 provider 时为轻量应用服务器
+const (
+	ProviderLighthouse = "lighthouse"
+	ProviderCVM        = "cvm"
+	ProviderFake       = "fake"
+)
+
 // Config 表示应用程序的主要配置结构。
 type Config struct {
+	// Version 是配置文件结构版本，用于自动迁移
+	Version int `yaml:"version"`
 	// Dest 将目标名称映射到目标实例
 	Dest map[string]DestinationInstance `yaml:"dest"`
+	// Groups 将分组名称映射到成员选择器列表
+	Groups map[string][]string `yaml:"groups,omitempty"`
+	// Snapshots 是默认的快照保留策略，目标可以单独覆盖
+	Snapshots *SnapshotPolicy `yaml:"snapshots,omitempty"`
+	// Traffic 是默认的流量告警和超额关机策略，目标可以单独覆盖
+	Traffic *TrafficPolicy `yaml:"traffic,omitempty"`
+	// Accounts 将账号名称映射到腾讯云账号的凭证来源
+	Accounts map[string]Account `yaml:"accounts,omitempty"`
+	// Secrets 是加密保存的 API 凭证
+	Secrets *SecretStore `yaml:"secrets,omitempty"`
+	// Endpoints 替换上游服务的 API 地址
+	Endpoints *Endpoints `yaml:"endpoints,omitempty"`
+	// Extra 保存无法识别的字段，使其在保存时不会丢失
+	Extra map[string]any `yaml:",inline"`
 }
 
 // DestinationInstance 表示具有SSH连接详细信息的云实例。
 type DestinationInstance struct {
 	// Ssh 包含SSH连接字符串
 	Ssh string `yaml:"ssh"`
+	// Port 是 SSH 端口，为 0 时使用 ssh 的默认值
+	Port int `yaml:"port,omitempty"`
+	// IdentityFile 是 SSH 私钥路径
+	IdentityFile string `yaml:"identity-file,omitempty"`
+	// ProxyJump 是 SSH 跳板机，格式同 ssh -J
+	ProxyJump string `yaml:"proxy-jump,omitempty"`
+	// Provider 是实例所属的云平台，为空时表示轻量应用服务器
+	Provider string `yaml:"provider,omitempty"`
 	// Region 指定云区域
-	Region string `yaml:"region"`
+	Region string `yaml:"region,omitempty"`
 	// InstanceId 是实例的唯一标识符
-	InstanceId string `yaml:"instance-id"`
+	InstanceId string `yaml:"instance-id,omitempty"`
+	// Tags 是用于选择器的标签
+	Tags []string `yaml:"tags,omitempty"`
+	// Account 是目标所属的腾讯云账号，对应配置中 accounts 的名称，为空时使用默认凭证
+	Account string `yaml:"account,omitempty"`
+	// Protected 表示目标受保护，cloud 命令的破坏性操作需要 --force 才会执行
+	Protected bool `yaml:"protected,omitempty"`
+	// Snapshots 覆盖全局的快照保留策略
+	Snapshots *SnapshotPolicy `yaml:"snapshots,omitempty"`
+	// Traffic 覆盖全局的流量策略
+	Traffic *TrafficPolicy `yaml:"traffic,omitempty"`
+	// Firewall 是期望的防火墙规则，由 cloud firewall apply 同步到云平台
+	Firewall []FirewallRule `yaml:"firewall,omitempty"`
+	// Schedule 是定时开关机计划，由 cloud scheduler run 执行
+	Schedule *Schedule `yaml:"schedule,omitempty"`
+	// Extra 保存无法识别的字段，使其在保存时不会丢失
+	Extra map[string]any `yaml:",inline"`
 }
 
-// SaveConfig 将配置保存到配置文件中。
-// 它将配置结构体编组为YAML格式并写入配置文件。
-func (config Config) SaveConfig() error {
-	bytes, err := yaml.Marshal(config)
-	if err != nil {
+var (
+	// destNamePattern 限定目标名称只能包含字母、数字、点、下划线和连字符
+	destNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	// regionPattern 匹配腾讯云地域，如 ap-beijing、na-siliconvalley、ap-shanghai-fsi
+	regionPattern = regexp.MustCompile(`^[a-z]+-[a-z]+(-[a-z0-9]+)?$`)
+	// instanceIdPattern 匹配轻量应用服务器（lhins-）和云服务器（ins-）实例 ID
+	instanceIdPattern = regexp.MustCompile(`^(lhins|ins)-[a-z0-9]+$`)
+)
+
+// ValidateDestinationName 校验目标名称是否合法。
+func ValidateDestinationName(name string) error {
+	if !destNamePattern.MatchString(name) {
+		return fmt.Errorf("目标名称 %q 不合法，只能包含字母、数字、点、下划线和连字符", name)
+	}
+	return nil
+}
+
+// Validate 校验目标实例的 ssh、region 和 instance-id 字段。
+// ssh 为必填项；region 和 instance-id 用于云操作，要么同时提供，要么都不提供。
+func (dest DestinationInstance) Validate() error {
+	if err := validateSsh(dest.Ssh); err != nil {
 		return err
 	}
 
+	if dest.Port < 0 || dest.Port > 65535 {
+		return fmt.Errorf("port %d 超出范围 1-65535", dest.Port)
+	}
+
+	if strings.ContainsAny(dest.ProxyJump, " \t\n") {
+		return fmt.Errorf("proxy-jump %q 不能包含空白字符", dest.ProxyJump)
+	}
+	if strings.HasPrefix(dest.ProxyJump, "-") {
+		return fmt.Errorf("proxy-jump %q 不能以 - 开头", dest.ProxyJump)
+	}
+
+	if strings.HasPrefix(dest.IdentityFile, "-") {
+		return fmt.Errorf("identity-file %q 不能以 - 开头", dest.IdentityFile)
+	}
+
+	switch dest.Provider {
+	case "", ProviderLighthouse, ProviderCVM, ProviderFake:
+	default:
+		return fmt.Errorf("provider %q 不受支持，可用: lighthouse、cvm、fake", dest.Provider)
+	}
+
+	if (dest.Region == "") != (dest.InstanceId == "") {
+		return errors.New("region 和 instance-id 必须同时提供")
+	}
+
+	if dest.Region != "" && !regionPattern.MatchString(dest.Region) {
+		return fmt.Errorf("region %q 格式不正确，应类似 ap-beijing", dest.Region)
+	}
+
+	if dest.InstanceId != "" && !instanceIdPattern.MatchString(dest.InstanceId) {
+		return fmt.Errorf("instance-id %q 格式不正确，应类似 lhins-xxxxxxxx", dest.InstanceId)
+	}
+
+	if dest.Account != "" && !destNamePattern.MatchString(dest.Account) {
+		return fmt.Errorf("账号名称 %q 不合法，只能包含字母、数字、点、下划线和连字符", dest.Account)
+	}
+
+	for _, tag := range dest.Tags {
+		if !destNamePattern.MatchString(tag) {
+			return fmt.Errorf("标签 %q 不合法，只能包含字母、数字、点、下划线和连字符", tag)
+		}
+	}
+
+	if dest.Snapshots != nil {
+		if err := dest.Snapshots.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if dest.Traffic != nil {
+		if err := dest.Traffic.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if dest.Schedule != nil {
+		if dest.InstanceId == "" {
+			return errors.New("schedule 需要同时提供 region 和 instance-id")
+		}
+		if err := dest.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range dest.Firewall {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if seen[rule.Key()] {
+			return fmt.Errorf("防火墙规则 %v 重复", rule.Normalize())
+		}
+		seen[rule.Key()] = true
+	}
+
+	return nil
+}
+
+// SshArgs 返回连接该目标时传给 ssh 命令的参数，目标地址位于最后，
+// 并以 -- 与选项分隔，使目标地址不会被当作 ssh 选项解析。
+func (dest DestinationInstance) SshArgs() []string {
+	var args []string
+	if dest.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(dest.Port))
+	}
+	if dest.IdentityFile != "" {
+		args = append(args, "-i", dest.IdentityFile)
+	}
+	if dest.ProxyJump != "" {
+		args = append(args, "-J", dest.ProxyJump)
+	}
+
+	return append(args, "--", dest.Ssh)
+}
+
+// validateSsh 校验 SSH 连接字符串，格式为 host 或 user@host。
+func validateSsh(ssh string) error {
+	if ssh == "" {
+		return errors.New("ssh 不能为空")
+	}
+
+	if strings.ContainsAny(ssh, " \t\n") {
+		return fmt.Errorf("ssh %q 不能包含空白字符", ssh)
+	}
+	if strings.HasPrefix(ssh, "-") {
+		return fmt.Errorf("ssh %q 不能以 - 开头", ssh)
+	}
+
+	user, host, found := strings.Cut(ssh, "@")
+	if found && user == "" {
+		return fmt.Errorf("ssh %q 缺少用户名", ssh)
+	}
+	if !found {
+		host = user
+	}
+	if host == "" || strings.Contains(host, "@") {
+		return fmt.Errorf("ssh %q 格式不正确，应为 user@host", ssh)
+	}
+
+	return nil
+}
+
+// ConfigFilePath 返回当前生效的主配置文件路径。
+func ConfigFilePath() (string, error) {
+	return getConfigFilePath()
+}
+
+// SaveConfig 将配置保存到主配置文件中。
+// 它在文件锁内原子地写入，保留原文件中的注释和键顺序，文件权限为 0600。
+func (config Config) SaveConfig() error {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, bytes, 0644)
+	return saveConfigFile(path, &config)
 }
 
 // LoadDestinationInstance 按名称从配置中加载目标实例。
@@ -55,15 +252,26 @@ func LoadDestinationInstance(dest string) (*DestinationInstance, error) {
 
 	res, ok := config.Dest[dest]
 	if !ok {
-		return nil, fmt.Errorf("配置中不存在目标 %v", dest)
+		return nil, notFoundf("配置中不存在目标 %v", dest)
 	}
 
 	return &res, nil
 }
 
-// loadConfig 从配置文件中加载配置。
-// 它读取YAML文件并将其解组到Config结构体中。
+// loadConfig 加载经过分层合并后的最终配置。
+// 合并顺序见 Resolve。
 func loadConfig() (*Config, error) {
+	resolved, err := Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved.Config, nil
+}
+
+// loadConfigFile 只加载主配置文件，不叠加项目本地配置和环境变量覆盖。
+// 修改配置时应使用它，避免把覆盖值写回主配置文件。
+func loadConfigFile() (*Config, error) {
 	path, err := getConfigFilePath()
 	if err != nil {
 		return nil, err
@@ -74,32 +282,36 @@ func loadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// 旧版本的文件只在内存中迁移，保存时才写回
+	migrated, err := migrateBytes(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("迁移配置文件 %v 失败: %w", path, err)
+	}
+
 	config := Config{}
 
-	err = yaml.Unmarshal(bytes, &config)
+	err = yaml.Unmarshal(migrated.after, &config)
 	if err != nil {
 		return nil, err
 	}
+	warnUnknownKeys(path, &config)
 
 	return &config, nil
 }
 
-// getConfigFilePath 返回配置文件的路径，必要时创建目录。
+// getConfigFilePath 返回主配置文件的路径，必要时创建目录。
 // 它确保配置目录和文件存在，如果不存在则创建它们。
 func getConfigFilePath() (string, error) {
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, CONFIG_DIR)
+	configFile := resolveConfigFilePath()
 
-	err := os.MkdirAll(configDir, 0755)
+	err := os.MkdirAll(filepath.Dir(configFile), 0700)
 	if err != nil {
 		return "", err
 	}
 
-	configFile := filepath.Join(configDir, CONFIG_FILE)
-
 	_, err = os.Stat(configFile)
 	if os.IsNotExist(err) {
-		f, err := os.Create(configFile)
+		f, err := os.OpenFile(configFile, os.O_CREATE|os.O_WRONLY, CONFIG_FILE_MODE)
 
 		if err != nil {
 			return "", err
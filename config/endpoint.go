@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 可以替换 API 地址的上游服务，对应配置中 endpoints 的字段和 --endpoint-<名称> 标志
+const (
+	EndpointTencent     = "tencent"
+	EndpointTelegram    = "telegram"
+	EndpointFred        = "fred"
+	EndpointFrankfurter = "frankfurter"
+	EndpointMultpl      = "multpl"
+)
+
+// EndpointNames 按显示顺序列出可以替换 API 地址的上游服务
+var EndpointNames = []string{EndpointTencent, EndpointTelegram, EndpointFred, EndpointFrankfurter, EndpointMultpl}
+
+// Endpoints 是上游服务的 API 地址，用于镜像、代理或本地的替身服务，为空时使用默认地址。
+type Endpoints struct {
+	// Tencent 是腾讯云 API 的地址，所有腾讯云服务的请求都发送到这里。
+	// 可以是域名或 http(s)://host:port，其中的 {service} 会替换为服务名，如 {service}.internal.example.com
+	Tencent string `yaml:"tencent,omitempty"`
+	// Telegram 替换 https://api.telegram.org
+	Telegram string `yaml:"telegram,omitempty"`
+	// Fred 替换 https://api.stlouisfed.org
+	Fred string `yaml:"fred,omitempty"`
+	// Frankfurter 替换 https://api.frankfurter.app
+	Frankfurter string `yaml:"frankfurter,omitempty"`
+	// Multpl 替换 https://www.multpl.com
+	Multpl string `yaml:"multpl,omitempty"`
+}
+
+// endpointOverrides 保存 --endpoint-<名称> 标志指定的地址，优先于配置文件。
+// 它只在命令执行前由 SetEndpoint 设置，执行期间只读，因此并发读取不需要加锁。
+var endpointOverrides = map[string]string{}
+
+// SetEndpoint 设置 --endpoint-<名称> 标志指定的地址，value 为空时使用配置文件中的地址。
+// 必须在命令开始执行之前调用，不能与 LoadEndpoint 并发调用。
+func SetEndpoint(name, value string) {
+	if value == "" {
+		delete(endpointOverrides, name)
+		return
+	}
+	endpointOverrides[name] = value
+}
+
+// Get 返回上游服务 name 的地址，未配置时返回空字符串。
+func (endpoints Endpoints) Get(name string) string {
+	switch name {
+	case EndpointTencent:
+		return endpoints.Tencent
+	case EndpointTelegram:
+		return endpoints.Telegram
+	case EndpointFred:
+		return endpoints.Fred
+	case EndpointFrankfurter:
+		return endpoints.Frankfurter
+	case EndpointMultpl:
+		return endpoints.Multpl
+	default:
+		return ""
+	}
+}
+
+// ValidateEndpoint 校验上游服务 name 的地址：腾讯云可以是域名或 http(s) 地址，其他服务必须是 http(s) 地址。
+func ValidateEndpoint(name, endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	if strings.ContainsAny(endpoint, " \t\n") {
+		return fmt.Errorf("endpoints.%v %q 不能包含空白字符", name, endpoint)
+	}
+	if name == EndpointTencent && !strings.Contains(endpoint, "://") {
+		return nil
+	}
+
+	u, err := url.Parse(strings.ReplaceAll(endpoint, "{service}", "service"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		if name == EndpointTencent {
+			return fmt.Errorf("endpoints.%v %q 格式不正确，应为域名或 http(s)://host:port", name, endpoint)
+		}
+		return fmt.Errorf("endpoints.%v %q 格式不正确，应为 http(s)://host[:port][/path]", name, endpoint)
+	}
+	return nil
+}
+
+// LoadEndpoint 返回上游服务 name 的地址：--endpoint-<名称> 标志优先，其次为主配置文件和环境变量中的 endpoints，
+// 都未设置时返回 fallback。返回的地址不以 / 结尾。
+func LoadEndpoint(name, fallback string) (string, error) {
+	endpoint, ok := endpointOverrides[name]
+	if !ok {
+		configured, err := configuredEndpoint(name)
+		if err != nil {
+			return "", err
+		}
+		endpoint = configured
+	}
+	if endpoint == "" {
+		return fallback, nil
+	}
+
+	if err := ValidateEndpoint(name, endpoint); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// configuredEndpoint 返回主配置文件和环境变量覆盖中 endpoints 里上游服务 name 的地址。
+// 项目本地配置文件不参与：API 地址决定凭证发往哪里，不能由检出的仓库指定。
+// 主配置文件不存在时视为未配置，也不会创建配置文件，
+// 因此 forex、valuation 和 Telegram 推送等不依赖配置的命令不受影响。
+func configuredEndpoint(name string) (string, error) {
+	config, err := loadTrustedConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.Endpoints == nil {
+		return "", nil
+	}
+	return config.Endpoints.Get(name), nil
+}
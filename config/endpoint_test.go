@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEndpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CONFIG_FILE)
+	t.Setenv(CONFIG_ENV, path)
+	t.Setenv("LUCKY_GO_ENDPOINTS__FRED", "")
+	t.Cleanup(func() { endpointOverrides = map[string]string{} })
+
+	data := "endpoints:\n  telegram: https://tg.example.com/\n  tencent: \"{service}.internal.example.com\"\n"
+	if err := os.WriteFile(path, []byte(data), CONFIG_FILE_MODE); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, fallback, expected string
+	}{
+		{EndpointTelegram, "https://api.telegram.org", "https://tg.example.com"},
+		{EndpointTencent, "", "{service}.internal.example.com"},
+		{EndpointFred, "https://api.stlouisfed.org", "https://api.stlouisfed.org"},
+	}
+	for _, tt := range tests {
+		if got, err := LoadEndpoint(tt.name, tt.fallback); err != nil || got != tt.expected {
+			t.Errorf("LoadEndpoint(%v) = %q, %v, expected %q", tt.name, got, err, tt.expected)
+		}
+	}
+
+	// --endpoint-<名称> 标志优先于配置文件
+	SetEndpoint(EndpointTelegram, "http://127.0.0.1:8080")
+	if got, _ := LoadEndpoint(EndpointTelegram, ""); got != "http://127.0.0.1:8080" {
+		t.Errorf("expected flag to override the config, got %q", got)
+	}
+	SetEndpoint(EndpointTelegram, "")
+	if got, _ := LoadEndpoint(EndpointTelegram, ""); got != "https://tg.example.com" {
+		t.Errorf("expected empty flag to fall back to the config, got %q", got)
+	}
+
+	// 环境变量覆盖同样生效
+	t.Setenv("LUCKY_GO_ENDPOINTS__FRED", "http://fred.local")
+	if got, _ := LoadEndpoint(EndpointFred, ""); got != "http://fred.local" {
+		t.Errorf("expected environment override, got %q", got)
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		name, endpoint string
+		valid          bool
+	}{
+		{EndpointTencent, "lighthouse.internal.example.com", true},
+		{EndpointTencent, "http://127.0.0.1:8080", true},
+		{EndpointTencent, "https://{service}.example.com", true},
+		{EndpointTencent, "ftp://example.com", false},
+		{EndpointTelegram, "https://tg.example.com/proxy", true},
+		{EndpointTelegram, "tg.example.com", false},
+		{EndpointMultpl, "http://multpl .local", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateEndpoint(tt.name, tt.endpoint); (err == nil) != tt.valid {
+			t.Errorf("ValidateEndpoint(%v, %q) = %v, expected valid %v", tt.name, tt.endpoint, err, tt.valid)
+		}
+	}
+}
+
+func TestLoadEndpoint_IgnoresProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(CONFIG_ENV, filepath.Join(dir, CONFIG_FILE))
+	t.Chdir(dir)
+
+	// 检出的仓库不能把凭证请求转发到自己的地址
+	project := "endpoints:\n  telegram: https://attacker.example.com\n"
+	if err := os.WriteFile(filepath.Join(dir, PROJECT_CONFIG_FILE), []byte(project), CONFIG_FILE_MODE); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadEndpoint(EndpointTelegram, "https://api.telegram.org"); err != nil || got != "https://api.telegram.org" {
+		t.Errorf("expected project-local endpoints to be ignored, got %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, CONFIG_FILE)); !os.IsNotExist(err) {
+		t.Errorf("expected the config file not to be created, got %v", err)
+	}
+}
+
+func TestLoadEndpoint_ParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CONFIG_FILE)
+	t.Setenv(CONFIG_ENV, path)
+
+	if err := os.WriteFile(path, []byte("endpoints: [\n"), CONFIG_FILE_MODE); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadEndpoint(EndpointTelegram, "https://api.telegram.org"); err == nil {
+		t.Errorf("expected a parse error, got %q", got)
+	}
+}
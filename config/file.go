@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CONFIG_FILE_MODE 是配置文件及其备份的权限，配置中可能包含凭证，因此只允许所有者读写
+const CONFIG_FILE_MODE = 0600
+
+// Update 在配置文件锁内读取主配置文件、调用 fn 修改配置并原子地写回。
+// 写回时只更新发生变化的节点，用户的注释和键顺序会被保留。
+func Update(fn func(config *Config) error) error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	return WithFileLock(path, func() error {
+		if err := upgradeConfigFileLocked(path); err != nil {
+			return err
+		}
+
+		doc, err := readConfigNode(path)
+		if err != nil {
+			return err
+		}
+
+		config := Config{}
+		if err := doc.Decode(&config); err != nil {
+			return err
+		}
+
+		if err := fn(&config); err != nil {
+			return err
+		}
+
+		return writeConfigNode(path, doc, &config)
+	})
+}
+
+// saveConfigFile 在配置文件锁内把完整配置写回主配置文件，保留原文件中的注释和键顺序。
+// 与 Update 相同，旧版本的文件先备份并迁移再写回。
+func saveConfigFile(path string, config *Config) error {
+	return WithFileLock(path, func() error {
+		if err := upgradeConfigFileLocked(path); err != nil {
+			return err
+		}
+
+		doc, err := readConfigNode(path)
+		if err != nil {
+			return err
+		}
+
+		return writeConfigNode(path, doc, config)
+	})
+}
+
+// readConfigNode 把配置文件解析为 YAML 节点，文件为空时返回空节点
+func readConfigNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// writeConfigNode 把配置合并到原文档节点后原子地写入文件
+func writeConfigNode(path string, doc *yaml.Node, config *Config) error {
+	config.Version = CURRENT_VERSION
+
+	updated := &yaml.Node{}
+	if err := updated.Encode(config); err != nil {
+		return err
+	}
+
+	if root := documentRoot(doc); root != nil {
+		mergeNode(root, updated)
+	} else {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{updated}}
+	}
+
+	data, err := encodeNode(doc)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(path, data)
+}
+
+// encodeNode 以两空格缩进编码 YAML 节点
+func encodeNode(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mergeNode 用 src 的内容更新 dst：已有键原位更新并保留注释，新键追加在末尾，
+// src 中不存在的键被删除。两边都是映射时逐键递归，否则整体替换值。
+func mergeNode(dst, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		replaceNode(dst, src)
+		return
+	}
+
+	srcValues := map[string]*yaml.Node{}
+	var srcKeys []*yaml.Node
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcValues[src.Content[i].Value] = src.Content[i+1]
+		srcKeys = append(srcKeys, src.Content[i])
+	}
+
+	kept := make([]*yaml.Node, 0, len(src.Content))
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		srcValue, ok := srcValues[key.Value]
+		if !ok {
+			continue
+		}
+		mergeNode(value, srcValue)
+		kept = append(kept, key, value)
+		seen[key.Value] = true
+	}
+
+	for _, key := range srcKeys {
+		if !seen[key.Value] {
+			kept = append(kept, key, srcValues[key.Value])
+		}
+	}
+
+	dst.Content = kept
+}
+
+// replaceNode 用 src 替换 dst 的内容，但保留 dst 上的注释
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+}
+
+// WriteFileAtomic 先写入同目录下的临时文件并同步到磁盘，再重命名覆盖目标文件，
+// 避免写入中途崩溃导致配置文件被截断。cloud 的状态文件也通过它写入。
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(CONFIG_FILE_MODE); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// WithFileLock 在 <path>.lock 上持有建议性排他锁期间执行 fn
+func WithFileLock(path string, fn func() error) error {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, CONFIG_FILE_MODE)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeTestConfig 在临时 HOME 中写入主配置文件并返回其路径
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	home := setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	path := filepath.Join(home, CONFIG_DIR, CONFIG_FILE)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestUpdatePreservesComments(t *testing.T) {
+	path := writeTestConfig(t, `# my servers
+version: 1
+dest:
+  web: # prod box
+    ssh: root@1.2.3.4 # main ip
+  # database
+  db:
+    ssh: root@5.6.7.8
+`)
+
+	err := Update(func(config *Config) error {
+		web := config.Dest["web"]
+		web.Ssh = "admin@1.2.3.4"
+		config.Dest["web"] = web
+		config.Dest["cache"] = DestinationInstance{Ssh: "root@9.9.9.9"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# my servers
+version: 1
+dest:
+  web: # prod box
+    ssh: admin@1.2.3.4 # main ip
+  # database
+  db:
+    ssh: root@5.6.7.8
+  cache:
+    ssh: root@9.9.9.9
+`
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestUpdateErrorLeavesFileUntouched(t *testing.T) {
+	original := "version: 1\ndest:\n  web:\n    ssh: root@1.2.3.4\n"
+	path := writeTestConfig(t, original)
+
+	err := Update(func(config *Config) error {
+		delete(config.Dest, "web")
+		return fmt.Errorf("boom")
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != original {
+		t.Errorf("expected file to stay unchanged, got:\n%s", data)
+	}
+}
+
+func TestSaveConfigFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持 Unix 文件权限")
+	}
+
+	path := writeTestConfig(t, "version: 1\n")
+
+	if err := (Config{Dest: map[string]DestinationInstance{"web": {Ssh: "root@1.2.3.4"}}}).SaveConfig(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != CONFIG_FILE_MODE {
+		t.Errorf("expected mode %o, got %o", CONFIG_FILE_MODE, info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("expected temp file to be cleaned up, found %s", entry.Name())
+		}
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	writeTestConfig(t, "version: 1\n")
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Update(func(config *Config) error {
+				if config.Dest == nil {
+					config.Dest = map[string]DestinationInstance{}
+				}
+				config.Dest[fmt.Sprintf("host-%d", i)] = DestinationInstance{Ssh: "root@1.2.3.4"}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	config, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Dest) != workers {
+		t.Errorf("expected %d destinations after concurrent updates, got %d", workers, len(config.Dest))
+	}
+}
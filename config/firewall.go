@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// FirewallRule 是一条防火墙入站规则。
+// 比较规则时只看协议、端口、来源和策略，描述不影响结果。
+type FirewallRule struct {
+	// Protocol 是协议：TCP、UDP、ICMP 或 ALL
+	Protocol string `json:"protocol" yaml:"protocol"`
+	// Port 是端口，可以是单个端口（22）、多个端口（80,443）、范围（8000-9000）或 ALL
+	Port string `json:"port,omitempty" yaml:"port,omitempty"`
+	// Cidr 是来源网段，为空时表示 0.0.0.0/0
+	Cidr string `json:"cidr,omitempty" yaml:"cidr,omitempty"`
+	// Action 是策略：ACCEPT 或 DROP，为空时表示 ACCEPT
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Description 是规则描述
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// firewallPortPattern 匹配单个端口、逗号分隔的多个端口或端口范围
+var firewallPortPattern = regexp.MustCompile(`^(\d+(-\d+)?)(,\d+(-\d+)?)*$`)
+
+// Normalize 返回填充默认值并统一大小写后的规则。
+func (rule FirewallRule) Normalize() FirewallRule {
+	rule.Protocol = strings.ToUpper(strings.TrimSpace(rule.Protocol))
+	rule.Port = strings.ToUpper(strings.ReplaceAll(rule.Port, " ", ""))
+	rule.Cidr = strings.TrimSpace(rule.Cidr)
+	rule.Action = strings.ToUpper(strings.TrimSpace(rule.Action))
+
+	if rule.Port == "" && (rule.Protocol == "ICMP" || rule.Protocol == "ALL") {
+		rule.Port = "ALL"
+	}
+	if rule.Cidr == "" {
+		rule.Cidr = "0.0.0.0/0"
+	}
+	if rule.Action == "" {
+		rule.Action = "ACCEPT"
+	}
+	return rule
+}
+
+// Key 返回用于比较规则是否相同的键，不包含描述。
+func (rule FirewallRule) Key() string {
+	rule = rule.Normalize()
+	return strings.Join([]string{rule.Protocol, rule.Port, rule.Cidr, rule.Action}, " ")
+}
+
+// String 返回规则的可读形式，如 TCP 22 from 0.0.0.0/0 ACCEPT。
+func (rule FirewallRule) String() string {
+	rule = rule.Normalize()
+	s := fmt.Sprintf("%v %v from %v %v", rule.Protocol, rule.Port, rule.Cidr, rule.Action)
+	if rule.Description != "" {
+		s += fmt.Sprintf(" (%v)", rule.Description)
+	}
+	return s
+}
+
+// Validate 校验规则的协议、端口、来源和策略。
+func (rule FirewallRule) Validate() error {
+	rule = rule.Normalize()
+
+	switch rule.Protocol {
+	case "TCP", "UDP":
+		if rule.Port != "ALL" && !firewallPortPattern.MatchString(rule.Port) {
+			return fmt.Errorf("防火墙规则端口 %q 格式不正确，应类似 22、80,443、8000-9000 或 ALL", rule.Port)
+		}
+	case "ICMP", "ALL":
+		if rule.Port != "ALL" {
+			return fmt.Errorf("协议为 %v 的防火墙规则端口只能为 ALL", rule.Protocol)
+		}
+	default:
+		return fmt.Errorf("防火墙规则协议 %q 不受支持，可用: TCP、UDP、ICMP、ALL", rule.Protocol)
+	}
+
+	if _, _, err := net.ParseCIDR(rule.Cidr); err != nil && net.ParseIP(rule.Cidr) == nil {
+		return fmt.Errorf("防火墙规则来源 %q 不是合法的 IP 或网段", rule.Cidr)
+	}
+
+	if rule.Action != "ACCEPT" && rule.Action != "DROP" {
+		return fmt.Errorf("防火墙规则策略 %q 不受支持，可用: ACCEPT、DROP", rule.Action)
+	}
+
+	return nil
+}
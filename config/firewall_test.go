@@ -0,0 +1,46 @@
+package config
+
+import "testing"
+
+func TestFirewallRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    FirewallRule
+		wantErr bool
+	}{
+		{"SinglePort", FirewallRule{Protocol: "tcp", Port: "22"}, false},
+		{"PortList", FirewallRule{Protocol: "TCP", Port: "80, 443"}, false},
+		{"PortRange", FirewallRule{Protocol: "UDP", Port: "8000-9000", Cidr: "10.0.0.0/8", Action: "drop"}, false},
+		{"AllPorts", FirewallRule{Protocol: "TCP", Port: "all"}, false},
+		{"IcmpDefaultsToAllPorts", FirewallRule{Protocol: "ICMP"}, false},
+		{"SingleIP", FirewallRule{Protocol: "TCP", Port: "22", Cidr: "1.2.3.4"}, false},
+		{"IPv6", FirewallRule{Protocol: "TCP", Port: "22", Cidr: "::/0"}, false},
+		{"MissingPort", FirewallRule{Protocol: "TCP"}, true},
+		{"BadPort", FirewallRule{Protocol: "TCP", Port: "ssh"}, true},
+		{"IcmpWithPort", FirewallRule{Protocol: "ICMP", Port: "22"}, true},
+		{"UnknownProtocol", FirewallRule{Protocol: "SCTP", Port: "22"}, true},
+		{"BadCidr", FirewallRule{Protocol: "TCP", Port: "22", Cidr: "10.0.0.0/33"}, true},
+		{"BadAction", FirewallRule{Protocol: "TCP", Port: "22", Action: "ALLOW"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFirewallRuleKey(t *testing.T) {
+	a := FirewallRule{Protocol: "tcp", Port: "80, 443", Description: "web"}
+	b := FirewallRule{Protocol: "TCP", Port: "80,443", Cidr: "0.0.0.0/0", Action: "ACCEPT"}
+	if a.Key() != b.Key() {
+		t.Errorf("expected rules differing only in defaults and description to match: %q != %q", a.Key(), b.Key())
+	}
+
+	if got := a.String(); got != "TCP 80,443 from 0.0.0.0/0 ACCEPT (web)" {
+		t.Errorf("unexpected String(): %q", got)
+	}
+}
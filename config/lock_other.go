@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package config
+
+import "os"
+
+// lockFile 在不支持文件锁的平台上不做任何事
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile 在不支持文件锁的平台上不做任何事
+func unlockFile(f *os.File) error {
+	return nil
+}
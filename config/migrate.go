@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CURRENT_VERSION 是当前程序使用的配置文件结构版本
+const CURRENT_VERSION = 1
+
+// migration 表示把配置文件从 from 版本升级到 from+1 版本的一个步骤。
+// apply 直接修改 YAML 节点，以保留用户的注释和键顺序。
+type migration struct {
+	from        int
+	description string
+	apply       func(root *yaml.Node) error
+}
+
+// migrations 是按版本排列的迁移注册表，新增结构变更时在末尾追加一项并提升 CURRENT_VERSION
+var migrations = []migration{
+	{
+		from:        0,
+		description: "引入 version 字段",
+		apply:       func(root *yaml.Node) error { return nil },
+	},
+}
+
+// warnOutput 是警告信息的输出位置，测试中可替换
+var warnOutput io.Writer = os.Stderr
+
+var (
+	// warnedUnknownKeys 记录已经提示过的未知字段，避免同一进程内重复提示
+	warnedUnknownKeys = map[string]bool{}
+	// warnedUnknownKeysMu 保护 warnedUnknownKeys，多个目标并发读取配置时也只提示一次
+	warnedUnknownKeysMu sync.Mutex
+)
+
+// migrationResult 描述一次迁移的结果
+type migrationResult struct {
+	from   int
+	to     int
+	steps  []string
+	before []byte
+	after  []byte
+}
+
+// migrateBytes 将 YAML 内容逐步迁移到 CURRENT_VERSION。内容为空时视为最新版本。
+func migrateBytes(data []byte) (*migrationResult, error) {
+	result := &migrationResult{from: CURRENT_VERSION, to: CURRENT_VERSION, before: data, after: data}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return result, nil
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+
+	root := documentRoot(doc)
+	if root == nil {
+		return result, nil
+	}
+
+	version, err := readVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	result.from = version
+
+	if version > CURRENT_VERSION {
+		return nil, fmt.Errorf("配置文件版本 %d 高于当前程序支持的版本 %d，请升级 lucky-go", version, CURRENT_VERSION)
+	}
+
+	for version < CURRENT_VERSION {
+		step, ok := findMigration(version)
+		if !ok {
+			return nil, fmt.Errorf("缺少从版本 %d 开始的配置迁移", version)
+		}
+		if err := step.apply(root); err != nil {
+			return nil, fmt.Errorf("执行迁移 %d→%d 失败: %w", version, version+1, err)
+		}
+		version++
+		setVersion(root, version)
+		result.steps = append(result.steps, fmt.Sprintf("%d→%d %s", step.from, version, step.description))
+	}
+
+	if len(result.steps) == 0 {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	result.to = version
+	result.after = buf.Bytes()
+	return result, nil
+}
+
+// upgradeConfigFileLocked 在主配置文件版本过旧时先写入带时间戳的备份，再写回迁移后的内容。
+// 只在修改配置时调用，调用方必须已持有配置文件锁；只读命令在内存中迁移，不修改文件。
+func upgradeConfigFileLocked(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	result, err := migrateBytes(data)
+	if err != nil {
+		return fmt.Errorf("迁移配置文件 %v 失败: %w", path, err)
+	}
+	if len(result.steps) == 0 {
+		return nil
+	}
+
+	backup, err := writeMigrated(path, result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(warnOutput, "配置文件已从版本 %d 升级到 %d，原文件备份为 %v\n", result.from, result.to, backup)
+	return nil
+}
+
+// writeMigrated 先把原始内容备份到 <path>.<时间戳>.bak，再写回迁移后的内容，返回备份路径
+func writeMigrated(path string, result *migrationResult) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, result.before, CONFIG_FILE_MODE); err != nil {
+		return "", fmt.Errorf("写入配置备份失败: %w", err)
+	}
+
+	if err := WriteFileAtomic(path, result.after); err != nil {
+		return "", err
+	}
+
+	return backup, nil
+}
+
+// findMigration 查找从指定版本开始的迁移
+func findMigration(from int) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// documentRoot 返回文档的顶层映射节点
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc
+}
+
+// readVersion 读取顶层 version 字段，缺失时视为版本 0
+func readVersion(root *yaml.Node) (int, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			version, err := strconv.Atoi(root.Content[i+1].Value)
+			if err != nil {
+				return 0, fmt.Errorf("配置文件 version 字段 %q 不是整数", root.Content[i+1].Value)
+			}
+			return version, nil
+		}
+	}
+	return 0, nil
+}
+
+// setVersion 设置顶层 version 字段，缺失时插入到最前面
+func setVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			root.Content[i+1].Value = value
+			root.Content[i+1].Tag = "!!int"
+			return
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	// 文件开头的注释保持在最前面
+	if len(root.Content) > 0 {
+		keyNode.HeadComment = root.Content[0].HeadComment
+		root.Content[0].HeadComment = ""
+	}
+
+	root.Content = append([]*yaml.Node{
+		keyNode,
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, root.Content...)
+}
+
+// warnUnknownKeys 提示配置中无法识别的字段，这些字段会在保存时原样保留
+func warnUnknownKeys(source string, config *Config) {
+	var keys []string
+	for key := range config.Extra {
+		keys = append(keys, key)
+	}
+	for name, dest := range config.Dest {
+		for key := range dest.Extra {
+			keys = append(keys, "dest."+name+"."+key)
+		}
+	}
+	sort.Strings(keys)
+
+	warnedUnknownKeysMu.Lock()
+	defer warnedUnknownKeysMu.Unlock()
+	for _, key := range keys {
+		id := source + ":" + key
+		if warnedUnknownKeys[id] {
+			continue
+		}
+		warnedUnknownKeys[id] = true
+		fmt.Fprintf(warnOutput, "警告: %v 中存在未知字段 %v，将原样保留\n", source, key)
+	}
+}
+
+// lineDiff 逐行比较两段文本，输出以 "-"、"+"、" " 开头的差异
+func lineDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	// lcs[i][j] 表示 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		sb.WriteString("- " + a[i] + "\n")
+	}
+	for ; j < len(b); j++ {
+		sb.WriteString("+ " + b[j] + "\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateBytes(t *testing.T) {
+	t.Run("FromVersionZero", func(t *testing.T) {
+		input := "# my servers\ndest:\n  web: # prod\n    ssh: root@1.2.3.4\n"
+
+		result, err := migrateBytes([]byte(input))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if result.from != 0 || result.to != CURRENT_VERSION {
+			t.Errorf("expected migration 0→%d, got %d→%d", CURRENT_VERSION, result.from, result.to)
+		}
+
+		output := string(result.after)
+		if !strings.HasPrefix(output, "# my servers\nversion: 1\n") {
+			t.Errorf("expected version to be inserted after the head comment, got:\n%s", output)
+		}
+		if !strings.Contains(output, "web: # prod") {
+			t.Errorf("expected comments to be kept, got:\n%s", output)
+		}
+	})
+
+	t.Run("AlreadyCurrent", func(t *testing.T) {
+		input := "version: 1\ndest: {}\n"
+
+		result, err := migrateBytes([]byte(input))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(result.steps) != 0 || string(result.after) != input {
+			t.Errorf("expected no changes, got steps %v", result.steps)
+		}
+	})
+
+	t.Run("EmptyFile", func(t *testing.T) {
+		result, err := migrateBytes(nil)
+		if err != nil || len(result.steps) != 0 {
+			t.Errorf("expected empty file to be treated as current, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("NewerVersion", func(t *testing.T) {
+		if _, err := migrateBytes([]byte("version: 99\n")); err == nil {
+			t.Error("expected error for newer version, got nil")
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		if _, err := migrateBytes([]byte("version: abc\n")); err == nil {
+			t.Error("expected error for non-integer version, got nil")
+		}
+	})
+}
+
+func TestUpgradeConfigFile(t *testing.T) {
+	home := setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	originalWarn := warnOutput
+	warnings := &bytes.Buffer{}
+	warnOutput = warnings
+	defer func() { warnOutput = originalWarn }()
+
+	path := filepath.Join(home, CONFIG_DIR, CONFIG_FILE)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := "dest:\n  web:\n    ssh: root@1.2.3.4\n    color: blue\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DryRunLeavesFileUntouched", func(t *testing.T) {
+		out, err := runConfigCommand(t, "migrate", "--dry-run")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !strings.Contains(out, "+ version: 1") {
+			t.Errorf("expected diff in output, got:\n%s", out)
+		}
+
+		data, _ := os.ReadFile(path)
+		if string(data) != original {
+			t.Error("expected dry run not to modify the file")
+		}
+	})
+
+	t.Run("LoadMigratesInMemory", func(t *testing.T) {
+		config, err := loadConfigFile()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if config.Version != CURRENT_VERSION {
+			t.Errorf("expected version %d, got %d", CURRENT_VERSION, config.Version)
+		}
+		if _, err := loadConfig(); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if string(data) != original {
+			t.Error("expected reading the config not to modify the file")
+		}
+		if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+			t.Errorf("expected no backup before the config is modified, got %v", backups)
+		}
+	})
+
+	t.Run("UnknownKeysArePreserved", func(t *testing.T) {
+		if !strings.Contains(warnings.String(), "dest.web.color") {
+			t.Errorf("expected warning about unknown key, got: %s", warnings.String())
+		}
+
+		if _, err := runConfigCommand(t, "edit", "web", "--ssh", "admin@1.2.3.4"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), "color: blue") {
+			t.Errorf("expected unknown key to survive SaveConfig, got:\n%s", data)
+		}
+		if !strings.Contains(string(data), "version: 1") {
+			t.Errorf("expected modifying the config to upgrade the file, got:\n%s", data)
+		}
+
+		backups, _ := filepath.Glob(path + ".*.bak")
+		if len(backups) != 1 {
+			t.Fatalf("expected one backup, got %v", backups)
+		}
+		data, _ = os.ReadFile(backups[0])
+		if string(data) != original {
+			t.Errorf("expected backup to hold original content, got:\n%s", data)
+		}
+	})
+}
+
+func TestLineDiff(t *testing.T) {
+	diff := lineDiff("a\nb\nc\n", "a\nx\nc\n")
+	expected := "  a\n- b\n+ x\n  c\n"
+	if diff != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, diff)
+	}
+}
@@ -0,0 +1,342 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// CONFIG_ENV 是指定配置文件路径的环境变量
+	CONFIG_ENV = "LUCKY_GO_CONFIG"
+	// PROJECT_CONFIG_FILE 是项目本地配置文件名，会叠加在主配置之上
+	PROJECT_CONFIG_FILE = ".lucky-go.yaml"
+	// envOverridePrefix 是单个配置项覆盖变量的前缀，层级之间用双下划线分隔，
+	// 如 LUCKY_GO_DEST__WEB__INSTANCE_ID 覆盖 dest.web.instance-id
+	envOverridePrefix = "LUCKY_GO_"
+	envPathSeparator  = "__"
+)
+
+// configFileFlag 保存 --config 标志指定的配置文件路径
+var configFileFlag string
+
+// SetConfigFile 设置 --config 标志指定的配置文件路径，优先级最高。
+func SetConfigFile(path string) {
+	configFileFlag = path
+}
+
+// Resolved 表示经过分层合并后的最终配置。
+type Resolved struct {
+	// Config 是合并后的配置
+	Config *Config
+	// Sources 将配置项路径（如 dest.web.ssh）映射到其值的来源
+	Sources map[string]string
+	// Layers 按合并顺序列出参与合并的来源
+	Layers []string
+}
+
+// configLayer 表示参与合并的一层配置
+type configLayer struct {
+	source string
+	data   map[string]any
+}
+
+// Resolve 按优先级从低到高合并主配置文件、项目本地配置文件和环境变量覆盖。
+func Resolve() (*Resolved, error) {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	// 旧版本的主配置文件只在内存中迁移，写回文件只发生在 config migrate 和修改配置时
+	layers := []configLayer{}
+
+	base, err := readLayer(path)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, base)
+
+	for _, projectFile := range findProjectConfigFiles() {
+		if projectFile == path {
+			continue
+		}
+		layer, err := readLayer(projectFile)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	merged := map[string]any{}
+	resolved := &Resolved{Sources: map[string]string{}}
+	for _, layer := range layers {
+		mergeLayer(merged, layer.data, "", layer.source, resolved.Sources)
+		resolved.Layers = append(resolved.Layers, layer.source)
+	}
+
+	if err := applyEnvOverrides(merged, os.Environ(), resolved.Sources); err != nil {
+		return nil, err
+	}
+
+	bytes, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+	resolved.Config = &config
+
+	return resolved, nil
+}
+
+// loadTrustedConfig 只读地合并主配置文件和环境变量覆盖，不叠加项目本地配置文件，
+// 主配置文件不存在时也不会创建。accounts、endpoints 等决定使用哪份凭证、凭证发往哪里的配置项
+// 通过它读取，避免检出的仓库通过 .lucky-go.yaml 修改。
+func loadTrustedConfig() (*Config, error) {
+	data := map[string]any{}
+	path := resolveConfigFilePath()
+	if _, err := os.Stat(path); err == nil {
+		layer, err := readLayer(path)
+		if err != nil {
+			return nil, err
+		}
+		data = layer.data
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err := applyEnvOverrides(data, os.Environ(), map[string]string{}); err != nil {
+		return nil, err
+	}
+
+	bytes, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	config := Config{}
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// resolveConfigFilePath 按 --config、LUCKY_GO_CONFIG、$XDG_CONFIG_HOME/lucky-go、~/.lucky-go 的顺序确定主配置文件路径。
+// XDG 路径仅在文件已存在时采用，否则回退到 ~/.lucky-go。
+func resolveConfigFilePath() string {
+	if configFileFlag != "" {
+		return configFileFlag
+	}
+
+	if path := os.Getenv(CONFIG_ENV); path != "" {
+		return path
+	}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		path := filepath.Join(xdg, "lucky-go", CONFIG_FILE)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, CONFIG_DIR, CONFIG_FILE)
+}
+
+// findProjectConfigFiles 从当前目录向上查找项目本地配置文件，
+// 按从外到内的顺序返回，使离当前目录越近的文件优先级越高。
+func findProjectConfigFiles() []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for {
+		path := filepath.Join(dir, PROJECT_CONFIG_FILE)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append([]string{path}, files...)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return files
+}
+
+// readLayer 读取 YAML 文件作为一层配置，旧版本文件只在内存中迁移
+func readLayer(path string) (configLayer, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return configLayer{}, err
+	}
+
+	migrated, err := migrateBytes(bytes)
+	if err != nil {
+		return configLayer{}, fmt.Errorf("迁移配置文件 %v 失败: %w", path, err)
+	}
+
+	data := map[string]any{}
+	if err := yaml.Unmarshal(migrated.after, &data); err != nil {
+		return configLayer{}, fmt.Errorf("解析配置文件 %v 失败: %w", path, err)
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(migrated.after, &config); err != nil {
+		return configLayer{}, fmt.Errorf("解析配置文件 %v 失败: %w", path, err)
+	}
+	warnUnknownKeys(path, &config)
+
+	return configLayer{source: path, data: data}, nil
+}
+
+// mergeLayer 将 src 深度合并到 dst 中，映射逐键合并，其余值整体覆盖，
+// 同时在 sources 中记录每个叶子值的来源。
+func mergeLayer(dst, src map[string]any, prefix, source string, sources map[string]string) {
+	for key, value := range src {
+		path := joinKeyPath(prefix, key)
+
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeLayer(dstMap, srcMap, path, source, sources)
+			continue
+		}
+
+		clearSources(sources, path)
+		if srcIsMap {
+			copied := map[string]any{}
+			mergeLayer(copied, srcMap, path, source, sources)
+			dst[key] = copied
+			continue
+		}
+
+		dst[key] = value
+		sources[path] = source
+	}
+}
+
+// applyEnvOverrides 将 LUCKY_GO_ 前缀且包含双下划线的环境变量应用为单个配置项覆盖。
+// 变量值按 YAML 解析，因此可以写列表或数字。
+func applyEnvOverrides(merged map[string]any, environ []string, sources map[string]string) error {
+	sort.Strings(environ)
+
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, envOverridePrefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(name, envOverridePrefix)
+		if !strings.Contains(rest, envPathSeparator) {
+			continue
+		}
+
+		segments := strings.Split(rest, envPathSeparator)
+		var parsed any
+		if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+			return fmt.Errorf("解析环境变量 %v 失败: %w", name, err)
+		}
+
+		current := merged
+		path := ""
+		for i, segment := range segments {
+			if segment == "" {
+				return fmt.Errorf("环境变量 %v 格式不正确", name)
+			}
+
+			key := matchKey(current, segment)
+			path = joinKeyPath(path, key)
+
+			if i == len(segments)-1 {
+				clearSources(sources, path)
+				current[key] = parsed
+				sources[path] = "env " + name
+				break
+			}
+
+			next, ok := current[key].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				current[key] = next
+			}
+			current = next
+		}
+	}
+
+	return nil
+}
+
+// matchKey 将环境变量中的路径片段映射为配置键：优先大小写不敏感地匹配已有键，
+// 否则转为小写并把下划线替换为连字符。
+func matchKey(current map[string]any, segment string) string {
+	normalized := strings.ReplaceAll(strings.ToLower(segment), "_", "-")
+	for key := range current {
+		if strings.EqualFold(key, segment) || strings.EqualFold(key, normalized) {
+			return key
+		}
+	}
+	return normalized
+}
+
+// clearSources 删除某路径及其所有子路径的来源记录
+func clearSources(sources map[string]string, path string) {
+	for key := range sources {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(sources, key)
+		}
+	}
+}
+
+// joinKeyPath 拼接配置项路径
+func joinKeyPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// annotateSources 在 YAML 节点的叶子值后追加来源注释
+func annotateSources(node *yaml.Node, prefix string, sources map[string]string) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			annotateSources(child, prefix, sources)
+		}
+		return
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		path := joinKeyPath(prefix, keyNode.Value)
+
+		if valueNode.Kind == yaml.MappingNode {
+			annotateSources(valueNode, path, sources)
+			continue
+		}
+
+		source, ok := sources[path]
+		if !ok {
+			continue
+		}
+		if valueNode.Kind == yaml.ScalarNode {
+			valueNode.LineComment = source
+		} else {
+			keyNode.LineComment = source
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfigFilePath(t *testing.T) {
+	home := setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	defer SetConfigFile("")
+
+	t.Run("DefaultHome", func(t *testing.T) {
+		expected := filepath.Join(home, CONFIG_DIR, CONFIG_FILE)
+		if path := resolveConfigFilePath(); path != expected {
+			t.Errorf("expected '%s', got '%s'", expected, path)
+		}
+	})
+
+	t.Run("XDGOnlyWhenExists", func(t *testing.T) {
+		xdg := filepath.Join(home, "xdg")
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+
+		expected := filepath.Join(home, CONFIG_DIR, CONFIG_FILE)
+		if path := resolveConfigFilePath(); path != expected {
+			t.Errorf("expected fallback '%s', got '%s'", expected, path)
+		}
+
+		xdgFile := filepath.Join(xdg, "lucky-go", CONFIG_FILE)
+		if err := os.MkdirAll(filepath.Dir(xdgFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(xdgFile, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if path := resolveConfigFilePath(); path != xdgFile {
+			t.Errorf("expected '%s', got '%s'", xdgFile, path)
+		}
+	})
+
+	t.Run("EnvOverridesXDG", func(t *testing.T) {
+		t.Setenv(CONFIG_ENV, "/tmp/env.yaml")
+		if path := resolveConfigFilePath(); path != "/tmp/env.yaml" {
+			t.Errorf("expected env path, got '%s'", path)
+		}
+	})
+
+	t.Run("FlagOverridesEnv", func(t *testing.T) {
+		t.Setenv(CONFIG_ENV, "/tmp/env.yaml")
+		SetConfigFile("/tmp/flag.yaml")
+		defer SetConfigFile("")
+
+		if path := resolveConfigFilePath(); path != "/tmp/flag.yaml" {
+			t.Errorf("expected flag path, got '%s'", path)
+		}
+	})
+}
+
+func TestResolve(t *testing.T) {
+	home := setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	baseFile := filepath.Join(home, CONFIG_DIR, CONFIG_FILE)
+	if err := os.MkdirAll(filepath.Dir(baseFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	base := `dest:
+  web:
+    ssh: root@1.2.3.4
+    region: ap-hongkong
+    instance-id: lhins-abc123
+  db:
+    ssh: root@5.6.7.8
+`
+	if err := os.WriteFile(baseFile, []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project := filepath.Join(home, "project")
+	sub := filepath.Join(project, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	outer := `dest:
+  web:
+    region: ap-singapore
+`
+	inner := `dest:
+  web:
+    region: ap-tokyo
+`
+	if err := os.WriteFile(filepath.Join(project, PROJECT_CONFIG_FILE), []byte(outer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, PROJECT_CONFIG_FILE), []byte(inner), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	t.Setenv("LUCKY_GO_DEST__WEB__INSTANCE_ID", "lhins-override")
+
+	resolved, err := Resolve()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	web := resolved.Config.Dest["web"]
+	if web.Ssh != "root@1.2.3.4" {
+		t.Errorf("expected ssh from base file, got '%s'", web.Ssh)
+	}
+	if web.Region != "ap-tokyo" {
+		t.Errorf("expected innermost project file to win, got '%s'", web.Region)
+	}
+	if web.InstanceId != "lhins-override" {
+		t.Errorf("expected env override, got '%s'", web.InstanceId)
+	}
+	if resolved.Config.Dest["db"].Ssh != "root@5.6.7.8" {
+		t.Errorf("expected untouched destination to be kept, got '%s'", resolved.Config.Dest["db"].Ssh)
+	}
+
+	expectedSources := map[string]string{
+		"dest.web.ssh":         baseFile,
+		"dest.web.region":      filepath.Join(sub, PROJECT_CONFIG_FILE),
+		"dest.web.instance-id": "env LUCKY_GO_DEST__WEB__INSTANCE_ID",
+	}
+	for key, expected := range expectedSources {
+		if resolved.Sources[key] != expected {
+			t.Errorf("expected source of %s to be '%s', got '%s'", key, expected, resolved.Sources[key])
+		}
+	}
+
+	if len(resolved.Layers) != 3 {
+		t.Errorf("expected 3 file layers, got %v", resolved.Layers)
+	}
+
+	t.Run("EditsOnlyTouchBaseFile", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "edit", "db", "--ssh", "admin@5.6.7.8"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		config, err := loadConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Dest["web"].Region != "ap-hongkong" || config.Dest["web"].InstanceId != "lhins-abc123" {
+			t.Errorf("expected overrides not to be persisted, got %+v", config.Dest["web"])
+		}
+	})
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	merged := map[string]any{
+		"dest": map[string]any{
+			"Web-1": map[string]any{"ssh": "root@1.2.3.4"},
+		},
+	}
+	sources := map[string]string{"dest.Web-1.ssh": "file"}
+
+	environ := []string{
+		"LUCKY_GO_CONFIG=/ignored.yaml",
+		"LUCKY_GO_DEST__WEB-1__SSH=admin@1.2.3.4",
+		"LUCKY_GO_DEST__NEW__TAGS=[a, b]",
+		"OTHER__VAR=1",
+	}
+	if err := applyEnvOverrides(merged, environ, sources); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	dest := merged["dest"].(map[string]any)
+	if dest["Web-1"].(map[string]any)["ssh"] != "admin@1.2.3.4" {
+		t.Errorf("expected existing key to be matched case-insensitively, got %v", dest)
+	}
+	tags, ok := dest["new"].(map[string]any)["tags"].([]any)
+	if !ok || len(tags) != 2 {
+		t.Errorf("expected yaml list value, got %v", dest["new"])
+	}
+	if _, ok := merged["config"]; ok {
+		t.Error("expected LUCKY_GO_CONFIG not to be treated as an override")
+	}
+
+	if err := applyEnvOverrides(merged, []string{"LUCKY_GO_DEST____SSH=x"}, sources); err == nil {
+		t.Error("expected error for empty path segment, got nil")
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 是目标的定时开关机计划，start 和 stop 为 cron 表达式，至少提供一个。
+type Schedule struct {
+	// Start 是开机的 cron 表达式，如 "0 9 * * 1-5"
+	Start string `yaml:"start,omitempty"`
+	// Stop 是关机的 cron 表达式，如 "0 21 * * *"
+	Stop string `yaml:"stop,omitempty"`
+	// Timezone 是解释 cron 表达式的 IANA 时区，如 Asia/Shanghai，为空时使用本地时区
+	Timezone string `yaml:"timezone,omitempty"`
+}
+
+// Validate 校验 cron 表达式和时区。
+func (schedule Schedule) Validate() error {
+	if schedule.Start == "" && schedule.Stop == "" {
+		return errors.New("schedule 至少需要提供 start 或 stop")
+	}
+	if _, err := schedule.Location(); err != nil {
+		return err
+	}
+	for _, expr := range []string{schedule.Start, schedule.Stop} {
+		if expr == "" {
+			continue
+		}
+		if _, err := ParseCron(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Location 返回计划使用的时区。
+func (schedule Schedule) Location() (*time.Location, error) {
+	if schedule.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("schedule.timezone %q 不正确: %w", schedule.Timezone, err)
+	}
+	return location, nil
+}
+
+// cronMacros 是 cron 表达式的简写
+var cronMacros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+// cronField 描述 cron 表达式中一个字段的取值范围和可用的名称
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+// cronFields 依次为分钟、小时、日、月和星期，星期的 7 与 0 都表示星期日
+var cronFields = []cronField{
+	{name: "分钟", min: 0, max: 59},
+	{name: "小时", min: 0, max: 23},
+	{name: "日", min: 1, max: 31},
+	{name: "月", min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "星期", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Cron 是解析后的 5 字段 cron 表达式（分钟 小时 日 月 星期）。
+type Cron struct {
+	expr string
+	// fields 是每个字段允许取值的位图
+	fields [5]uint64
+	// anyDay 和 anyWeekday 表示日和星期字段为 *。两者都不是 * 时满足任一字段即可，与 cron 的行为一致。
+	anyDay, anyWeekday bool
+}
+
+// ParseCron 解析 cron 表达式，支持 *、列表（1,3）、范围（1-5）、步长（*/15）、
+// 月份和星期的英文缩写（jan、mon）以及 @daily 等简写。
+func ParseCron(expr string) (*Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron 表达式 %q 应包含 5 个字段（分钟 小时 日 月 星期）", expr)
+	}
+
+	cron := &Cron{expr: expr, anyDay: parts[2] == "*", anyWeekday: parts[4] == "*"}
+	for i, part := range parts {
+		bits, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron 表达式 %q 的%v字段不正确: %w", expr, cronFields[i].name, err)
+		}
+		cron.fields[i] = bits
+	}
+
+	// 星期的 7 等同于 0
+	if cron.fields[4]&(1<<7) != 0 {
+		cron.fields[4] |= 1
+	}
+	return cron, nil
+}
+
+// parseCronField 把一个字段解析为允许取值的位图
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("步长 %q 不正确", item[i+1:])
+			}
+			rangePart, step = item[:i], n
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = parseCronValue(bounds[1], field); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// 如 5/15 表示从 5 开始每 15 个单位
+				high = field.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("范围 %q 的起点大于终点", rangePart)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析字段中的单个数值或英文缩写
+func parseCronValue(value string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if name != "" && strings.EqualFold(value, name) {
+			return i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q 不是有效的值", value)
+	}
+	if n < field.min || n > field.max {
+		return 0, fmt.Errorf("%d 超出范围 %d-%d", n, field.min, field.max)
+	}
+	return n, nil
+}
+
+// String 返回原始的 cron 表达式。
+func (cron *Cron) String() string {
+	return cron.expr
+}
+
+// matches 判断取值是否在字段 i 允许的范围内
+func (cron *Cron) matches(i, value int) bool {
+	return cron.fields[i]&(1<<value) != 0
+}
+
+// matchesDay 判断日期是否满足日和星期字段
+func (cron *Cron) matchesDay(t time.Time) bool {
+	day, weekday := cron.matches(2, t.Day()), cron.matches(4, int(t.Weekday()))
+	if cron.anyDay || cron.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// Next 返回 t 之后（不含 t）第一个满足表达式的时间，使用 t 的时区。
+// 5 年内都没有满足的时间（如 2 月 30 日）时返回零值。
+func (cron *Cron) Next(t time.Time) time.Time {
+	location := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case !cron.matches(3, int(month)):
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, location)
+		case !cron.matchesDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+		case !cron.matches(1, t.Hour()):
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, location)
+		case !cron.matches(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	// 2026-01-02 是星期五
+	from := time.Date(2026, 1, 2, 20, 30, 15, 0, shanghai)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 2, 20, 31, 0, 0, shanghai)},
+		{"0 21 * * *", time.Date(2026, 1, 2, 21, 0, 0, 0, shanghai)},
+		{"0 9 * * 1-5", time.Date(2026, 1, 5, 9, 0, 0, 0, shanghai)},
+		{"0 9 * * mon-fri", time.Date(2026, 1, 5, 9, 0, 0, 0, shanghai)},
+		{"*/20 * * * *", time.Date(2026, 1, 2, 20, 40, 0, 0, shanghai)},
+		{"5/20 20 * * *", time.Date(2026, 1, 2, 20, 45, 0, 0, shanghai)},
+		{"0 0 1 feb *", time.Date(2026, 2, 1, 0, 0, 0, 0, shanghai)},
+		{"0 8 * * 7", time.Date(2026, 1, 4, 8, 0, 0, 0, shanghai)},
+		// 日和星期都不是 * 时满足任一即可：15 日或星期一
+		{"0 8 15 * 1", time.Date(2026, 1, 5, 8, 0, 0, 0, shanghai)},
+		{"@daily", time.Date(2026, 1, 3, 0, 0, 0, 0, shanghai)},
+		{"30 20 2 1 *", time.Date(2027, 1, 2, 20, 30, 0, 0, shanghai)},
+	}
+
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := cron.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if cron, _ := ParseCron("0 0 30 2 *"); !cron.Next(from).IsZero() {
+		t.Error("expected impossible date to never match")
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "x * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("expected ParseCron(%q) to fail", expr)
+		}
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  string
+	}{
+		{"Valid", Schedule{Start: "0 9 * * 1-5", Stop: "0 21 * * *", Timezone: "UTC"}, ""},
+		{"StopOnly", Schedule{Stop: "0 21 * * *"}, ""},
+		{"Empty", Schedule{}, "至少需要"},
+		{"BadCron", Schedule{Start: "0 25 * * *"}, "小时"},
+		{"BadTimezone", Schedule{Stop: "0 21 * * *", Timezone: "Mars/Base"}, "timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	dest := DestinationInstance{Ssh: "root@1.2.3.4", Schedule: &Schedule{Stop: "0 21 * * *"}}
+	if err := dest.Validate(); err == nil {
+		t.Error("expected schedule without instance-id to be rejected")
+	}
+}
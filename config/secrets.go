@@ -0,0 +1,358 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// PASSPHRASE_ENV 是提供密钥库口令的环境变量
+	PASSPHRASE_ENV = "LUCKY_GO_PASSPHRASE"
+	// PASSPHRASE_FILE_ENV 是提供密钥库口令文件路径的环境变量，适用于定时任务
+	PASSPHRASE_FILE_ENV = "LUCKY_GO_PASSPHRASE_FILE"
+
+	// scrypt 参数，参考 golang.org/x/crypto/scrypt 文档推荐值
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+
+	// secretCheckPlaintext 用于在解密前校验口令是否正确
+	secretCheckPlaintext = "lucky-go"
+)
+
+// 已知凭证名称
+const (
+	SecretTencentCloudSecretId  = "tencent-cloud-secret-id"
+	SecretTencentCloudSecretKey = "tencent-cloud-secret-key"
+	SecretFredAPIKey            = "fred-api-key"
+	SecretTelegramBotToken      = "telegram-bot-token"
+	SecretTelegramChatId        = "telegram-chat-id"
+)
+
+// KnownSecrets 将已知凭证名称映射到其回退使用的环境变量
+var KnownSecrets = map[string]string{
+	SecretTencentCloudSecretId:  "TENCENT_CLOUD_SECRET_ID",
+	SecretTencentCloudSecretKey: "TENCENT_CLOUD_SECRET_KEY",
+	SecretFredAPIKey:            "FRED_API_KEY",
+	SecretTelegramBotToken:      "TELEGRAM_BOT_TOKEN",
+	SecretTelegramChatId:        "TELEGRAM_CHAT_ID",
+}
+
+// isKnownSecret 判断名称是否为已知凭证，或账号的腾讯云凭证（如 tencent-cloud-secret-id@project-a）
+func isKnownSecret(name string) bool {
+	if _, ok := KnownSecrets[name]; ok {
+		return true
+	}
+
+	secret, account, found := strings.Cut(name, "@")
+	switch secret {
+	case SecretTencentCloudSecretId, SecretTencentCloudSecretKey:
+		return found && destNamePattern.MatchString(account)
+	}
+	return false
+}
+
+// ErrWrongPassphrase 表示密钥库口令不正确
+var ErrWrongPassphrase = errors.New("密钥库口令不正确")
+
+// SecretStore 表示配置文件中加密保存的凭证。
+// 密钥由口令经 scrypt 派生，每个条目使用 AES-256-GCM 单独加密，并以条目名称作为附加数据。
+type SecretStore struct {
+	// KDF 是密钥派生算法，目前只支持 scrypt
+	KDF string `yaml:"kdf"`
+	// Salt 是 base64 编码的随机盐
+	Salt string `yaml:"salt"`
+	// N、R、P 是 scrypt 参数
+	N int `yaml:"n"`
+	R int `yaml:"r"`
+	P int `yaml:"p"`
+	// Check 是已知明文的密文，用于校验口令
+	Check string `yaml:"check"`
+	// Entries 将凭证名称映射到 base64 编码的 nonce+密文
+	Entries map[string]string `yaml:"entries,omitempty"`
+}
+
+// readPassphrase 读取口令，confirm 为 true 时要求输入两次。测试中可替换。
+var readPassphrase = defaultReadPassphrase
+
+var (
+	// derivedKeys 缓存已派生的密钥，避免同一进程内重复输入口令和重复计算 scrypt
+	derivedKeys = map[string][]byte{}
+	// derivedKeysMu 保护 derivedKeys，并在读取口令和派生密钥期间持有，
+	// 使并发读取凭证时每个盐只提示和派生一次
+	derivedKeysMu sync.Mutex
+)
+
+// LookupSecret 优先从配置文件的密钥库读取凭证，不存在时回退到环境变量 envKey。
+// 配置文件不存在时不会创建它。
+func LookupSecret(name, envKey string) (string, error) {
+	store, err := readSecretStore()
+	if err != nil {
+		return "", err
+	}
+
+	if store != nil {
+		if _, ok := store.Entries[name]; ok {
+			return store.Get(name)
+		}
+	}
+
+	return os.Getenv(envKey), nil
+}
+
+// readSecretStore 只读地加载主配置文件中的密钥库，文件或密钥库不存在时返回 nil
+func readSecretStore() (*SecretStore, error) {
+	bytes, err := os.ReadFile(resolveConfigFilePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, err
+	}
+
+	return config.Secrets, nil
+}
+
+// newSecretStore 使用新的随机盐和口令创建空密钥库
+func newSecretStore() (*SecretStore, error) {
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	store := &SecretStore{
+		KDF:     "scrypt",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Entries: map[string]string{},
+	}
+
+	passphrase, err := readPassphrase(true)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := store.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := seal(key, secretCheckPlaintext, "check")
+	if err != nil {
+		return nil, err
+	}
+	store.Check = check
+
+	derivedKeysMu.Lock()
+	derivedKeys[store.Salt] = key
+	derivedKeysMu.Unlock()
+
+	return store, nil
+}
+
+// Get 解密并返回指定凭证。
+func (store *SecretStore) Get(name string) (string, error) {
+	sealed, ok := store.Entries[name]
+	if !ok {
+		return "", fmt.Errorf("密钥库中不存在凭证 %v", name)
+	}
+
+	key, err := store.key()
+	if err != nil {
+		return "", err
+	}
+
+	return unseal(key, sealed, name)
+}
+
+// Set 加密并保存指定凭证。
+func (store *SecretStore) Set(name, value string) error {
+	key, err := store.key()
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(key, value, name)
+	if err != nil {
+		return err
+	}
+
+	if store.Entries == nil {
+		store.Entries = map[string]string{}
+	}
+	store.Entries[name] = sealed
+
+	return nil
+}
+
+// Names 返回按名称排序的凭证列表。
+func (store *SecretStore) Names() []string {
+	names := make([]string, 0, len(store.Entries))
+	for name := range store.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// key 获取口令、派生密钥并校验，结果在进程内缓存
+func (store *SecretStore) key() ([]byte, error) {
+	derivedKeysMu.Lock()
+	defer derivedKeysMu.Unlock()
+
+	if key, ok := derivedKeys[store.Salt]; ok {
+		return key, nil
+	}
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := store.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := unseal(key, store.Check, "check"); err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	derivedKeys[store.Salt] = key
+	return key, nil
+}
+
+// deriveKey 使用 scrypt 从口令派生 AES 密钥
+func (store *SecretStore) deriveKey(passphrase string) ([]byte, error) {
+	if store.KDF != "scrypt" {
+		return nil, fmt.Errorf("不支持的密钥派生算法 %v", store.KDF)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(store.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("解析密钥库盐值失败: %w", err)
+	}
+
+	return scrypt.Key([]byte(passphrase), salt, store.N, store.R, store.P, scryptKeyLen)
+}
+
+// seal 使用 AES-GCM 加密明文，返回 base64 编码的 nonce+密文
+func seal(key []byte, plaintext, aad string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unseal 解密 seal 生成的密文
+func unseal(key []byte, sealed, aad string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("解析密文失败: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("密文长度不正确")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("解密凭证 %v 失败: %w", aad, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// newGCM 创建 AES-GCM 加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// defaultReadPassphrase 依次从 LUCKY_GO_PASSPHRASE、LUCKY_GO_PASSPHRASE_FILE 和终端读取口令
+func defaultReadPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(PASSPHRASE_ENV); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if path := os.Getenv(PASSPHRASE_FILE_ENV); path != "" {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取口令文件失败: %w", err)
+		}
+		return strings.TrimRight(string(bytes), "\r\n"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("密钥库需要口令，请设置 %v 或 %v 环境变量", PASSPHRASE_ENV, PASSPHRASE_FILE_ENV)
+	}
+
+	passphrase, err := promptHidden(fd, "请输入密钥库口令: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("口令不能为空")
+	}
+
+	if confirm {
+		again, err := promptHidden(fd, "请再次输入口令: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("两次输入的口令不一致")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// promptHidden 在终端上提示输入且不回显
+func promptHidden(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	bytes, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// stubPassphrase 替换口令读取函数并清空密钥缓存
+func stubPassphrase(t *testing.T, passphrase string) {
+	t.Helper()
+
+	originalRead := readPassphrase
+	readPassphrase = func(confirm bool) (string, error) {
+		return passphrase, nil
+	}
+	derivedKeys = map[string][]byte{}
+
+	t.Cleanup(func() {
+		readPassphrase = originalRead
+		derivedKeys = map[string][]byte{}
+	})
+}
+
+func TestSecretsCommands(t *testing.T) {
+	home := setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	stubPassphrase(t, "correct horse")
+
+	t.Run("Set", func(t *testing.T) {
+		cmd := NewCommand()
+		cmd.SetIn(strings.NewReader("fred-123\n"))
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetArgs([]string{"secrets", "set", SecretFredAPIKey})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		path, _ := getConfigFilePath()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "fred-123") {
+			t.Error("expected secret to be encrypted at rest")
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		out, err := runConfigCommand(t, "secrets", "get", SecretFredAPIKey)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if strings.TrimSpace(out) != "fred-123" {
+			t.Errorf("expected 'fred-123', got '%s'", out)
+		}
+	})
+
+	t.Run("LookupPrefersStore", func(t *testing.T) {
+		t.Setenv("FRED_API_KEY", "from-env")
+
+		value, err := LookupSecret(SecretFredAPIKey, "FRED_API_KEY")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if value != "fred-123" {
+			t.Errorf("expected value from store, got '%s'", value)
+		}
+	})
+
+	t.Run("LookupFallsBackToEnv", func(t *testing.T) {
+		t.Setenv("TELEGRAM_CHAT_ID", "chat-from-env")
+
+		value, err := LookupSecret(SecretTelegramChatId, "TELEGRAM_CHAT_ID")
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if value != "chat-from-env" {
+			t.Errorf("expected value from env, got '%s'", value)
+		}
+	})
+
+	t.Run("WrongPassphrase", func(t *testing.T) {
+		stubPassphrase(t, "wrong")
+
+		_, err := LookupSecret(SecretFredAPIKey, "FRED_API_KEY")
+		if !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("expected ErrWrongPassphrase, got: %v", err)
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		if _, err := runConfigCommand(t, "secrets", "rm", SecretFredAPIKey); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		out, _ := runConfigCommand(t, "secrets", "list")
+		if strings.Contains(out, SecretFredAPIKey) {
+			t.Errorf("expected secret to be removed, got:\n%s", out)
+		}
+	})
+
+	t.Run("LookupWithoutConfigFile", func(t *testing.T) {
+		t.Setenv(CONFIG_ENV, home+"/missing.yaml")
+		t.Setenv("FRED_API_KEY", "from-env")
+
+		value, err := LookupSecret(SecretFredAPIKey, "FRED_API_KEY")
+		if err != nil || value != "from-env" {
+			t.Errorf("expected env fallback, got '%s', %v", value, err)
+		}
+		if _, err := os.Stat(home + "/missing.yaml"); !os.IsNotExist(err) {
+			t.Error("expected lookup not to create the config file")
+		}
+	})
+}
+
+func TestLookupSecretConcurrent(t *testing.T) {
+	setupTempHome(t)
+	t.Setenv(CONFIG_ENV, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	stubPassphrase(t, "correct horse")
+
+	cmd := NewCommand()
+	cmd.SetIn(strings.NewReader("fred-123\n"))
+	cmd.SetOut(&strings.Builder{})
+	cmd.SetArgs([]string{"secrets", "set", SecretFredAPIKey})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// 清空缓存，模拟新进程中多个目标同时读取凭证
+	derivedKeys = map[string][]byte{}
+	var prompts atomic.Int32
+	readPassphrase = func(confirm bool) (string, error) {
+		prompts.Add(1)
+		return "correct horse", nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := LookupSecret(SecretFredAPIKey, "FRED_API_KEY")
+			if err == nil && value != "fred-123" {
+				err = errors.New("unexpected value " + value)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	}
+	if got := prompts.Load(); got != 1 {
+		t.Errorf("expected the passphrase to be read once, got %d", got)
+	}
+}
+
+func TestSealUnseal(t *testing.T) {
+	key := make([]byte, scryptKeyLen)
+
+	sealed, err := seal(key, "secret", "name")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	plaintext, err := unseal(key, sealed, "name")
+	if err != nil || plaintext != "secret" {
+		t.Errorf("expected round trip, got '%s', %v", plaintext, err)
+	}
+
+	if _, err := unseal(key, sealed, "other-name"); err == nil {
+		t.Error("expected ciphertext to be bound to its name")
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrDestinationNotFound 表示选择器引用的目标或分组不存在，或者没有匹配任何目标，
+// 可以用 errors.Is 判断。
+var ErrDestinationNotFound = errors.New("目标不存在")
+
+// notFoundError 是带具体说明的 ErrDestinationNotFound
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string {
+	return e.message
+}
+
+// Is 使 errors.Is(err, ErrDestinationNotFound) 成立
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrDestinationNotFound
+}
+
+// notFoundf 创建 ErrDestinationNotFound 错误
+func notFoundf(format string, args ...any) error {
+	return &notFoundError{message: fmt.Sprintf(format, args...)}
+}
+
+// Destination 表示带名称的目标实例。
+type Destination struct {
+	// Name 是目标在配置中的名称
+	Name string
+	DestinationInstance
+}
+
+// LoadDestinations 加载配置并解析选择器，返回按名称排序且去重后的目标列表。
+// 选择器的写法见 Config.Select。
+func LoadDestinations(selectors ...string) ([]Destination, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Select(selectors...)
+}
+
+// LoadDestination 解析单个选择器并要求其恰好匹配一个目标，用于只能作用于单台机器的命令
+func LoadDestination(selector string) (*Destination, error) {
+	dests, err := LoadDestinations(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dests) != 1 {
+		names := make([]string, 0, len(dests))
+		for _, dest := range dests {
+			names = append(names, dest.Name)
+		}
+		return nil, fmt.Errorf("选择器 %v 匹配了 %d 个目标（%v），只能指定一个", selector, len(dests), strings.Join(names, ", "))
+	}
+
+	return &dests[0], nil
+}
+
+// Select 解析选择器并返回匹配的目标，多个选择器的结果取并集。支持的写法：
+//
+//	web-1                    目标名称
+//	web-*                    名称通配符（path.Match 语法）
+//	@web                     groups 中定义的分组，成员可以是任意选择器
+//	tag=prod,region=ap-hongkong  按条件过滤，多个条件同时满足；支持 tag、region、name、instance-id、provider
+//
+// 任何一个选择器没有匹配到目标都会返回错误，避免误操作。
+func (config *Config) Select(selectors ...string) ([]Destination, error) {
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("必须提供目标")
+	}
+
+	selected := map[string]bool{}
+	for _, selector := range selectors {
+		names, err := config.selectNames(selector, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			selected[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dests := make([]Destination, 0, len(names))
+	for _, name := range names {
+		dests = append(dests, Destination{Name: name, DestinationInstance: config.Dest[name]})
+	}
+
+	return dests, nil
+}
+
+// selectNames 解析单个选择器，visiting 用于检测分组之间的循环引用
+func (config *Config) selectNames(selector string, visiting []string) ([]string, error) {
+	switch {
+	case selector == "":
+		return nil, fmt.Errorf("必须提供目标")
+
+	case strings.HasPrefix(selector, "@"):
+		return config.selectGroup(strings.TrimPrefix(selector, "@"), visiting)
+
+	case strings.Contains(selector, "="):
+		return config.selectByConditions(selector)
+
+	case strings.ContainsAny(selector, "*?["):
+		var names []string
+		for name := range config.Dest {
+			matched, err := path.Match(selector, name)
+			if err != nil {
+				return nil, fmt.Errorf("通配符 %v 格式不正确: %w", selector, err)
+			}
+			if matched {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, notFoundf("选择器 %v 没有匹配任何目标", selector)
+		}
+		return names, nil
+
+	default:
+		if _, ok := config.Dest[selector]; !ok {
+			return nil, notFoundf("配置中不存在目标 %v", selector)
+		}
+		return []string{selector}, nil
+	}
+}
+
+// selectGroup 展开分组成员
+func (config *Config) selectGroup(group string, visiting []string) ([]string, error) {
+	for _, v := range visiting {
+		if v == group {
+			return nil, fmt.Errorf("分组 %v 存在循环引用: %v", group, strings.Join(append(visiting, group), " → "))
+		}
+	}
+
+	members, ok := config.Groups[group]
+	if !ok {
+		return nil, notFoundf("配置中不存在分组 %v", group)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("分组 %v 没有任何成员", group)
+	}
+
+	var names []string
+	for _, member := range members {
+		matched, err := config.selectNames(member, append(visiting, group))
+		if err != nil {
+			return nil, fmt.Errorf("解析分组 %v 失败: %w", group, err)
+		}
+		names = append(names, matched...)
+	}
+
+	return names, nil
+}
+
+// selectByConditions 按逗号分隔的 key=value 条件过滤目标，值支持通配符
+func (config *Config) selectByConditions(selector string) ([]string, error) {
+	type condition struct{ key, pattern string }
+
+	var conditions []condition
+	for _, part := range strings.Split(selector, ",") {
+		key, pattern, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || key == "" || pattern == "" {
+			return nil, fmt.Errorf("选择器条件 %q 格式不正确，应为 key=value", part)
+		}
+		switch key {
+		case "tag", "region", "name", "instance-id", "provider":
+		default:
+			return nil, fmt.Errorf("选择器不支持条件 %v，可用条件: tag、region、name、instance-id、provider", key)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("通配符 %v 格式不正确: %w", pattern, err)
+		}
+		conditions = append(conditions, condition{key: key, pattern: pattern})
+	}
+
+	var names []string
+	for name, dest := range config.Dest {
+		matchedAll := true
+		for _, c := range conditions {
+			if !dest.matches(name, c.key, c.pattern) {
+				matchedAll = false
+				break
+			}
+		}
+		if matchedAll {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, notFoundf("选择器 %v 没有匹配任何目标", selector)
+	}
+
+	return names, nil
+}
+
+// matches 判断目标是否满足单个条件
+func (dest DestinationInstance) matches(name, key, pattern string) bool {
+	match := func(value string) bool {
+		ok, _ := path.Match(pattern, value)
+		return ok
+	}
+
+	switch key {
+	case "tag":
+		for _, tag := range dest.Tags {
+			if match(tag) {
+				return true
+			}
+		}
+		return false
+	case "region":
+		return match(dest.Region)
+	case "name":
+		return match(name)
+	case "instance-id":
+		return match(dest.InstanceId)
+	case "provider":
+		if dest.Provider == "" {
+			return match(ProviderLighthouse)
+		}
+		return match(dest.Provider)
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	config := &Config{
+		Dest: map[string]DestinationInstance{
+			"web-1": {Ssh: "root@1.1.1.1", Region: "ap-hongkong", InstanceId: "lhins-web1", Tags: []string{"prod", "web"}},
+			"web-2": {Ssh: "root@1.1.1.2", Region: "ap-tokyo", InstanceId: "lhins-web2", Tags: []string{"prod", "web"}},
+			"db":    {Ssh: "root@2.2.2.2", Region: "ap-hongkong", InstanceId: "ins-db", Tags: []string{"prod"}},
+			"dev":   {Ssh: "root@3.3.3.3", Tags: []string{"staging"}},
+		},
+		Groups: map[string][]string{
+			"web":   {"web-*"},
+			"hk":    {"region=ap-hongkong"},
+			"all":   {"@web", "db", "dev"},
+			"loopa": {"@loopb"},
+			"loopb": {"@loopa"},
+			"empty": {},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		expected  []string
+		err       string
+		// notFound 表示错误应当是 ErrDestinationNotFound
+		notFound bool
+	}{
+		{name: "ExactName", selectors: []string{"db"}, expected: []string{"db"}},
+		{name: "Glob", selectors: []string{"web-*"}, expected: []string{"web-1", "web-2"}},
+		{name: "Group", selectors: []string{"@web"}, expected: []string{"web-1", "web-2"}},
+		{name: "NestedGroup", selectors: []string{"@all"}, expected: []string{"db", "dev", "web-1", "web-2"}},
+		{name: "Conditions", selectors: []string{"tag=prod,region=ap-hongkong"}, expected: []string{"db", "web-1"}},
+		{name: "ConditionGlob", selectors: []string{"instance-id=lhins-*"}, expected: []string{"web-1", "web-2"}},
+		{name: "UnionIsDeduplicated", selectors: []string{"@hk", "web-*"}, expected: []string{"db", "web-1", "web-2"}},
+		{name: "UnknownName", selectors: []string{"nope"}, err: "配置中不存在目标 nope", notFound: true},
+		{name: "GlobNoMatch", selectors: []string{"cache-*"}, err: "没有匹配任何目标", notFound: true},
+		{name: "UnknownGroup", selectors: []string{"@nope"}, err: "配置中不存在分组 nope", notFound: true},
+		{name: "EmptyGroup", selectors: []string{"@empty"}, err: "没有任何成员"},
+		{name: "GroupCycle", selectors: []string{"@loopa"}, err: "循环引用"},
+		{name: "UnknownCondition", selectors: []string{"color=blue"}, err: "不支持条件 color"},
+		{name: "MalformedCondition", selectors: []string{"tag="}, err: "格式不正确"},
+		{name: "NoSelectors", selectors: nil, err: "必须提供目标"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dests, err := config.Select(tt.selectors...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got: %v", tt.err, err)
+				}
+				if errors.Is(err, ErrDestinationNotFound) != tt.notFound {
+					t.Errorf("expected errors.Is(err, ErrDestinationNotFound) to be %v, got: %v", tt.notFound, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			var names []string
+			for _, dest := range dests {
+				names = append(names, dest.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestLoadDestination(t *testing.T) {
+	writeTestConfig(t, `version: 1
+dest:
+  web-1:
+    ssh: root@1.1.1.1
+  web-2:
+    ssh: root@1.1.1.2
+  db:
+    ssh: root@2.2.2.2
+`)
+
+	dest, err := LoadDestination("d*")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if dest.Name != "db" || dest.Ssh != "root@2.2.2.2" {
+		t.Errorf("unexpected destination: %+v", dest)
+	}
+
+	if _, err := LoadDestination("web-*"); err == nil || !strings.Contains(err.Error(), "只能指定一个") {
+		t.Errorf("expected error for multiple matches, got: %v", err)
+	}
+}
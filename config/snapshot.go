@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SnapshotPolicy 是自动快照的保留策略。
+// 只有 lucky-go 自动创建的快照（名称以 lucky-go- 开头）会被清理，手动创建的快照不受影响。
+type SnapshotPolicy struct {
+	// Keep 是最多保留的自动快照数量，0 表示不限制
+	Keep int `yaml:"keep,omitempty"`
+	// MaxAge 是自动快照的最长保留时间，如 30d、12h，为空表示不限制
+	MaxAge string `yaml:"max-age,omitempty"`
+}
+
+// Validate 校验保留策略。
+func (policy SnapshotPolicy) Validate() error {
+	if policy.Keep < 0 {
+		return fmt.Errorf("snapshots.keep 不能为负数")
+	}
+	if _, err := policy.Age(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Age 返回解析后的 MaxAge，为空时返回 0。
+func (policy SnapshotPolicy) Age() (time.Duration, error) {
+	if policy.MaxAge == "" {
+		return 0, nil
+	}
+
+	age, err := ParseAge(policy.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("snapshots.max-age %q 格式不正确，应类似 30d 或 12h", policy.MaxAge)
+	}
+	return age, nil
+}
+
+// ParseAge 解析时长，在 time.ParseDuration 的基础上支持以 d 表示天，如 30d。
+func ParseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("无法解析时长 %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("无法解析时长 %q", s)
+	}
+	return age, nil
+}
+
+// LoadSnapshotPolicy 返回目标生效的快照保留策略：目标自身的策略优先，否则使用全局策略。
+// 都未配置时返回零值，表示不自动清理。
+func LoadSnapshotPolicy(name string) (SnapshotPolicy, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return SnapshotPolicy{}, err
+	}
+
+	policy := SnapshotPolicy{}
+	if dest, ok := config.Dest[name]; ok && dest.Snapshots != nil {
+		policy = *dest.Snapshots
+	} else if config.Snapshots != nil {
+		policy = *config.Snapshots
+	}
+
+	return policy, policy.Validate()
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"0d", 0, false},
+		{"d", 0, true},
+		{"-1d", 0, true},
+		{"-1h", 0, true},
+		{"month", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			age, err := ParseAge(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if age != tt.expected {
+				t.Errorf("ParseAge(%q): expected %v, got %v", tt.input, tt.expected, age)
+			}
+		})
+	}
+}
+
+func TestLoadSnapshotPolicy(t *testing.T) {
+	writeTestConfig(t, `version: 1
+snapshots:
+  keep: 5
+dest:
+  web:
+    ssh: root@1.2.3.4
+    snapshots:
+      keep: 2
+      max-age: 7d
+  db:
+    ssh: root@5.6.7.8
+`)
+
+	web, err := LoadSnapshotPolicy("web")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if web != (SnapshotPolicy{Keep: 2, MaxAge: "7d"}) {
+		t.Errorf("expected destination policy to override the global one, got %+v", web)
+	}
+
+	db, err := LoadSnapshotPolicy("db")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if db != (SnapshotPolicy{Keep: 5}) {
+		t.Errorf("expected global policy, got %+v", db)
+	}
+}
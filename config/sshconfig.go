@@ -0,0 +1,338 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxIncludeDepth 限制 Include 的嵌套层数，避免循环包含
+const maxIncludeDepth = 16
+
+// sshConfigReader 读取 ssh_config 文件，测试中可替换为内存实现
+type sshConfigReader struct {
+	// readFile 读取文件内容
+	readFile func(path string) ([]byte, error)
+	// glob 展开 Include 中的通配符
+	glob func(pattern string) ([]string, error)
+	// sshDir 是相对路径 Include 的基准目录，通常为 ~/.ssh
+	sshDir string
+	// home 用于展开 ~
+	home string
+}
+
+// newSSHConfigReader 返回读取本地文件系统的 sshConfigReader
+func newSSHConfigReader() (*sshConfigReader, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshConfigReader{
+		readFile: os.ReadFile,
+		glob:     filepath.Glob,
+		sshDir:   filepath.Join(home, ".ssh"),
+		home:     home,
+	}, nil
+}
+
+// sshHostBlock 是解析中的一个 Host 块
+type sshHostBlock struct {
+	aliases []string
+	options map[string]string
+}
+
+// sshConfigParse 保存一次解析的状态
+type sshConfigParse struct {
+	reader   *sshConfigReader
+	blocks   []*sshHostBlock
+	warnings []string
+}
+
+// ParseSSHConfig 解析 ssh_config 文件中的 Host 块，返回按名称排序的目标及无法导入内容的警告。
+// 只识别 HostName、User、Port、IdentityFile 和 ProxyJump；通配符 Host、Match 块和全局选项会被跳过，
+// Include 的文件按出现位置展开。同一个 Host 中重复的选项以第一个为准，与 ssh 的行为一致。
+func ParseSSHConfig(path string) ([]Destination, []string, error) {
+	reader, err := newSSHConfigReader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reader.parse(path)
+}
+
+// parse 解析 path 指向的 ssh_config 文件
+func (reader *sshConfigReader) parse(path string) ([]Destination, []string, error) {
+	p := &sshConfigParse{reader: reader}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, p.warnings, err
+	}
+
+	dests := p.destinations()
+	return dests, p.warnings, nil
+}
+
+// parseFile 逐行解析单个文件，depth 为当前 Include 嵌套深度
+func (p *sshConfigParse) parseFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("Include 嵌套超过 %d 层: %v", maxIncludeDepth, path)
+	}
+
+	data, err := p.reader.readFile(path)
+	if err != nil {
+		return err
+	}
+
+	var current *sshHostBlock
+	skipping := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		keyword, args, err := splitSSHConfigLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%v:%d: %w", path, lineNo, err)
+		}
+		if keyword == "" {
+			continue
+		}
+
+		switch keyword {
+		case "host":
+			current, skipping = nil, false
+
+			var aliases []string
+			for _, pattern := range args {
+				if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, "*?") {
+					p.warnings = append(p.warnings, fmt.Sprintf("%v:%d: 跳过通配符 Host %v", path, lineNo, pattern))
+					continue
+				}
+				aliases = append(aliases, pattern)
+			}
+
+			if len(aliases) == 0 {
+				skipping = true
+				continue
+			}
+			current = &sshHostBlock{aliases: aliases, options: map[string]string{}}
+			p.blocks = append(p.blocks, current)
+
+		case "match":
+			current, skipping = nil, true
+			p.warnings = append(p.warnings, fmt.Sprintf("%v:%d: 不支持 Match 块，已跳过", path, lineNo))
+
+		case "include":
+			for _, pattern := range args {
+				if err := p.include(pattern, depth); err != nil {
+					return fmt.Errorf("%v:%d: %w", path, lineNo, err)
+				}
+			}
+
+		default:
+			if skipping {
+				continue
+			}
+			if current == nil {
+				p.warnings = append(p.warnings, fmt.Sprintf("%v:%d: 跳过全局选项 %v", path, lineNo, keyword))
+				continue
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("%v:%d: %v 缺少值", path, lineNo, keyword)
+			}
+
+			switch keyword {
+			case "hostname", "user", "port", "identityfile", "proxyjump":
+				if _, ok := current.options[keyword]; !ok {
+					current.options[keyword] = args[0]
+				}
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// include 展开 Include 指令，相对路径以 ~/.ssh 为基准
+func (p *sshConfigParse) include(pattern string, depth int) error {
+	pattern = p.reader.expandHome(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(p.reader.sshDir, pattern)
+	}
+
+	matches, err := p.reader.glob(pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+
+	for _, match := range matches {
+		if err := p.parseFile(match, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// destinations 把 Host 块转换为目标，多个别名的 Host 块为每个别名生成一个目标。
+// 同名 Host 出现多次时以第一个为准。
+func (p *sshConfigParse) destinations() []Destination {
+	seen := map[string]bool{}
+	var dests []Destination
+
+	for _, block := range p.blocks {
+		for _, alias := range block.aliases {
+			if seen[alias] {
+				continue
+			}
+			seen[alias] = true
+
+			if err := ValidateDestinationName(alias); err != nil {
+				p.warnings = append(p.warnings, fmt.Sprintf("跳过 Host %v: %v", alias, err))
+				continue
+			}
+
+			dest, err := block.destination(alias)
+			if err != nil {
+				p.warnings = append(p.warnings, fmt.Sprintf("跳过 Host %v: %v", alias, err))
+				continue
+			}
+			dests = append(dests, Destination{Name: alias, DestinationInstance: dest})
+		}
+	}
+
+	sort.Slice(dests, func(i, j int) bool { return dests[i].Name < dests[j].Name })
+	return dests
+}
+
+// destination 把 Host 块的选项转换为目标实例
+func (block *sshHostBlock) destination(alias string) (DestinationInstance, error) {
+	host := block.options["hostname"]
+	if host == "" {
+		host = alias
+	}
+
+	dest := DestinationInstance{
+		Ssh:          host,
+		IdentityFile: block.options["identityfile"],
+		ProxyJump:    block.options["proxyjump"],
+	}
+	if user := block.options["user"]; user != "" {
+		dest.Ssh = user + "@" + host
+	}
+	if dest.ProxyJump == "none" {
+		dest.ProxyJump = ""
+	}
+
+	if port := block.options["port"]; port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return dest, fmt.Errorf("Port %q 不是数字", port)
+		}
+		dest.Port = n
+	}
+
+	return dest, dest.Validate()
+}
+
+// expandHome 把开头的 ~ 展开为用户主目录
+func (reader *sshConfigReader) expandHome(path string) string {
+	if path == "~" {
+		return reader.home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(reader.home, path[2:])
+	}
+	return path
+}
+
+// splitSSHConfigLine 把一行拆分为小写的关键字和参数，支持 key=value 写法、双引号和 # 注释
+func splitSSHConfigLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil, nil
+	}
+
+	keyword := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+
+	var args []string
+	var current strings.Builder
+	inQuote, hasArg := false, false
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote, hasArg = !inQuote, true
+		case !inQuote && (r == ' ' || r == '\t'):
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		case !inQuote && r == '#' && !hasArg:
+			return keyword, args, nil
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if inQuote {
+		return "", nil, fmt.Errorf("引号未闭合")
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+
+	return keyword, args, nil
+}
+
+// RenderSSHConfig 把目标渲染为 ssh_config 格式，可作为 Include 文件使用。
+// 输出经 ParseSSHConfig 解析后得到相同的 SSH 字段。
+func RenderSSHConfig(dests []Destination) string {
+	var b strings.Builder
+	b.WriteString("# 由 lucky-go config export ssh-config 生成，请勿手动修改\n")
+
+	for _, dest := range dests {
+		user, host, found := strings.Cut(dest.Ssh, "@")
+		if !found {
+			user, host = "", dest.Ssh
+		}
+
+		fmt.Fprintf(&b, "\nHost %v\n", dest.Name)
+		fmt.Fprintf(&b, "  HostName %v\n", host)
+		if user != "" {
+			fmt.Fprintf(&b, "  User %v\n", user)
+		}
+		if dest.Port != 0 {
+			fmt.Fprintf(&b, "  Port %d\n", dest.Port)
+		}
+		if dest.IdentityFile != "" {
+			fmt.Fprintf(&b, "  IdentityFile %v\n", quoteSSHConfigValue(dest.IdentityFile))
+		}
+		if dest.ProxyJump != "" {
+			fmt.Fprintf(&b, "  ProxyJump %v\n", dest.ProxyJump)
+		}
+	}
+
+	return b.String()
+}
+
+// quoteSSHConfigValue 在值包含空白时加上双引号
+func quoteSSHConfigValue(value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memorySSHConfigReader 返回从内存读取文件的 sshConfigReader
+func memorySSHConfigReader(files map[string]string) *sshConfigReader {
+	return &sshConfigReader{
+		readFile: func(name string) ([]byte, error) {
+			content, ok := files[name]
+			if !ok {
+				return nil, fmt.Errorf("open %v: %w", name, os.ErrNotExist)
+			}
+			return []byte(content), nil
+		},
+		glob: func(pattern string) ([]string, error) {
+			var matches []string
+			for name := range files {
+				if ok, _ := path.Match(pattern, name); ok {
+					matches = append(matches, name)
+				}
+			}
+			sort.Strings(matches)
+			return matches, nil
+		},
+		sshDir: "/home/u/.ssh",
+		home:   "/home/u",
+	}
+}
+
+func TestParseSSHConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []Destination
+		warnings []string
+		err      string
+	}{
+		{
+			name: "AllFields",
+			files: map[string]string{"/home/u/.ssh/config": `
+Host web
+  HostName 1.2.3.4
+  User root
+  Port 2222
+  IdentityFile ~/.ssh/id_web
+  ProxyJump bastion
+`},
+			expected: []Destination{
+				{Name: "web", DestinationInstance: DestinationInstance{Ssh: "root@1.2.3.4", Port: 2222, IdentityFile: "~/.ssh/id_web", ProxyJump: "bastion"}},
+			},
+		},
+		{
+			name: "KeywordsAreCaseInsensitiveAndFirstValueWins",
+			files: map[string]string{"/home/u/.ssh/config": `
+HOST db
+  hostname=5.6.7.8
+  HostName 9.9.9.9
+  user "deploy"
+`},
+			expected: []Destination{
+				{Name: "db", DestinationInstance: DestinationInstance{Ssh: "deploy@5.6.7.8"}},
+			},
+		},
+		{
+			name:  "HostNameDefaultsToAlias",
+			files: map[string]string{"/home/u/.ssh/config": "Host example.com\n  User git\n"},
+			expected: []Destination{
+				{Name: "example.com", DestinationInstance: DestinationInstance{Ssh: "git@example.com"}},
+			},
+		},
+		{
+			name: "MultipleAliasesAndWildcards",
+			files: map[string]string{"/home/u/.ssh/config": `
+Host a b *.internal !c
+  HostName 10.0.0.1
+
+Host *
+  User nobody
+  ServerAliveInterval 30
+`},
+			expected: []Destination{
+				{Name: "a", DestinationInstance: DestinationInstance{Ssh: "10.0.0.1"}},
+				{Name: "b", DestinationInstance: DestinationInstance{Ssh: "10.0.0.1"}},
+			},
+			warnings: []string{"跳过通配符 Host *.internal", "跳过通配符 Host !c", "跳过通配符 Host *"},
+		},
+		{
+			name: "MatchBlockIsSkipped",
+			files: map[string]string{"/home/u/.ssh/config": `
+Match host web exec "true"
+  User ignored
+Host web
+  HostName 1.2.3.4
+`},
+			expected: []Destination{
+				{Name: "web", DestinationInstance: DestinationInstance{Ssh: "1.2.3.4"}},
+			},
+			warnings: []string{"不支持 Match 块"},
+		},
+		{
+			name: "GlobalOptionsAndComments",
+			files: map[string]string{"/home/u/.ssh/config": `
+# global
+AddKeysToAgent yes
+Host web # prod
+  HostName 1.2.3.4 # main
+`},
+			expected: []Destination{
+				{Name: "web", DestinationInstance: DestinationInstance{Ssh: "1.2.3.4"}},
+			},
+			warnings: []string{"跳过全局选项 addkeystoagent"},
+		},
+		{
+			name: "IncludeRelativeAndGlob",
+			files: map[string]string{
+				"/home/u/.ssh/config":        "Include conf.d/*\nHost web\n  HostName 1.2.3.4\n",
+				"/home/u/.ssh/conf.d/a.conf": "Host db\n  HostName 5.6.7.8\n",
+				"/home/u/.ssh/conf.d/b.conf": "Host web\n  HostName 9.9.9.9\n",
+			},
+			expected: []Destination{
+				{Name: "db", DestinationInstance: DestinationInstance{Ssh: "5.6.7.8"}},
+				{Name: "web", DestinationInstance: DestinationInstance{Ssh: "9.9.9.9"}},
+			},
+		},
+		{
+			name: "IncludeHomeAndMissingIsIgnored",
+			files: map[string]string{
+				"/home/u/.ssh/config": "Include ~/extra.conf /etc/ssh/none.conf\n",
+				"/home/u/extra.conf":  "Host x\n  HostName 1.1.1.1\n",
+			},
+			expected: []Destination{
+				{Name: "x", DestinationInstance: DestinationInstance{Ssh: "1.1.1.1"}},
+			},
+		},
+		{
+			name:  "IncludeCycle",
+			files: map[string]string{"/home/u/.ssh/config": "Include config\n"},
+			err:   "Include 嵌套超过",
+		},
+		{
+			name:     "InvalidPortIsSkipped",
+			files:    map[string]string{"/home/u/.ssh/config": "Host web\n  Port ssh\n"},
+			warnings: []string{"跳过 Host web"},
+		},
+		{
+			name:  "UnterminatedQuote",
+			files: map[string]string{"/home/u/.ssh/config": "Host web\n  User \"root\n"},
+			err:   "引号未闭合",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dests, warnings, err := memorySSHConfigReader(tt.files).parse("/home/u/.ssh/config")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if !reflect.DeepEqual(dests, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, dests)
+			}
+
+			joined := strings.Join(warnings, "\n")
+			for _, warning := range tt.warnings {
+				if !strings.Contains(joined, warning) {
+					t.Errorf("expected warning %q, got:\n%s", warning, joined)
+				}
+			}
+		})
+	}
+}
+
+func TestSSHConfigRoundTrip(t *testing.T) {
+	dests := []Destination{
+		{Name: "db", DestinationInstance: DestinationInstance{Ssh: "5.6.7.8"}},
+		{Name: "web", DestinationInstance: DestinationInstance{Ssh: "root@1.2.3.4", Port: 2222, IdentityFile: "~/My Keys/id", ProxyJump: "admin@bastion:2200"}},
+	}
+
+	rendered := RenderSSHConfig(dests)
+	parsed, warnings, err := memorySSHConfigReader(map[string]string{"/out.conf": rendered}).parse("/out.conf")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	if !reflect.DeepEqual(parsed, dests) {
+		t.Errorf("expected round trip to keep destinations\nexpected: %+v\ngot:      %+v\nrendered:\n%s", dests, parsed, rendered)
+	}
+}
+
+func TestImportSSHConfigCommand(t *testing.T) {
+	configPath := writeTestConfig(t, `version: 1
+dest:
+  web:
+    ssh: root@1.2.3.4
+    region: ap-hongkong
+    instance-id: lhins-web
+  db:
+    ssh: root@5.6.7.8
+  cache:
+    ssh: root@7.7.7.7
+`)
+	sshConfig := filepath.Join(filepath.Dir(configPath), "ssh_config")
+	if err := os.WriteFile(sshConfig, []byte(`
+Host web
+  HostName 1.2.3.4
+  User admin
+  Port 2222
+Host db
+  HostName 9.9.9.9
+  User root
+Host cache
+  HostName 7.7.7.7
+  User root
+Host new
+  HostName 3.3.3.3
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	// 冲突按名称顺序询问：db 先输入无效选项再重命名（web 已被占用）为 db-2，web 覆盖
+	cmd.SetIn(strings.NewReader("x\nr\nweb\ndb-2\no\n"))
+	cmd.SetArgs([]string{"import", "ssh-config", sshConfig})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("expected no error, got: %v\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), `ssh: "root@1.2.3.4" → "admin@1.2.3.4"`) {
+		t.Errorf("expected conflict diff in output, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "新增 2 个，更新 1 个，跳过 0 个，未变化 1 个") {
+		t.Errorf("expected summary in output, got:\n%s", out.String())
+	}
+
+	config, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := config.Dest["web"]
+	if web.Ssh != "admin@1.2.3.4" || web.Port != 2222 || web.InstanceId != "lhins-web" {
+		t.Errorf("expected web to be overwritten but keep instance-id, got %+v", web)
+	}
+	if config.Dest["db"].Ssh != "root@5.6.7.8" || config.Dest["db-2"].Ssh != "root@9.9.9.9" {
+		t.Errorf("expected db to be kept and imported as db-2, got %+v", config.Dest)
+	}
+	if config.Dest["new"].Ssh != "3.3.3.3" {
+		t.Errorf("expected new destination to be added, got %+v", config.Dest["new"])
+	}
+}
+
+func TestExportSSHConfigCommand(t *testing.T) {
+	path := writeTestConfig(t, `version: 1
+dest:
+  web:
+    ssh: root@1.2.3.4
+    port: 2222
+    tags: [prod]
+  dev:
+    ssh: 5.6.7.8
+`)
+	output := filepath.Join(filepath.Dir(path), "lucky-go.conf")
+
+	if _, err := runConfigCommand(t, "export", "ssh-config", "tag=prod", "-o", output); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Host web\n  HostName 1.2.3.4\n  User root\n  Port 2222\n") {
+		t.Errorf("unexpected export:\n%s", data)
+	}
+	if strings.Contains(string(data), "Host dev") {
+		t.Errorf("expected selector to filter destinations, got:\n%s", data)
+	}
+}
+
+func TestSshArgs(t *testing.T) {
+	dest := DestinationInstance{Ssh: "root@1.2.3.4", Port: 2222, IdentityFile: "~/.ssh/id", ProxyJump: "bastion"}
+	expected := []string{"-p", "2222", "-i", "~/.ssh/id", "-J", "bastion", "--", "root@1.2.3.4"}
+	if args := dest.SshArgs(); !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+
+	if args := (DestinationInstance{Ssh: "web"}).SshArgs(); !reflect.DeepEqual(args, []string{"--", "web"}) {
+		t.Errorf("expected only the destination, got %v", args)
+	}
+}
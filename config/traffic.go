@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultTrafficAlerts 是未配置 alerts 时的流量告警阈值（百分比）
+var DefaultTrafficAlerts = []int{80, 90, 100}
+
+// TrafficPolicy 是流量包的告警和超额关机策略。
+type TrafficPolicy struct {
+	// Alerts 是告警阈值，为流量包使用率的百分比，为空时使用 DefaultTrafficAlerts
+	Alerts []int `yaml:"alerts,omitempty"`
+	// StopAt 是自动关机的使用率百分比，0 表示不自动关机
+	StopAt int `yaml:"stop-at,omitempty"`
+}
+
+// Validate 校验告警阈值和关机阈值。
+func (policy TrafficPolicy) Validate() error {
+	for _, alert := range policy.Alerts {
+		if alert <= 0 {
+			return fmt.Errorf("traffic.alerts 中的阈值 %d 必须大于 0", alert)
+		}
+	}
+	if policy.StopAt < 0 {
+		return fmt.Errorf("traffic.stop-at 不能为负数")
+	}
+	return nil
+}
+
+// Thresholds 返回从小到大排序并去重的告警阈值。
+func (policy TrafficPolicy) Thresholds() []int {
+	alerts := policy.Alerts
+	if len(alerts) == 0 {
+		alerts = DefaultTrafficAlerts
+	}
+
+	seen := map[int]bool{}
+	var thresholds []int
+	for _, alert := range alerts {
+		if !seen[alert] {
+			seen[alert] = true
+			thresholds = append(thresholds, alert)
+		}
+	}
+	sort.Ints(thresholds)
+	return thresholds
+}
+
+// LoadTrafficPolicy 返回目标生效的流量策略：目标自身的策略优先，否则使用全局策略。
+func LoadTrafficPolicy(name string) (TrafficPolicy, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return TrafficPolicy{}, err
+	}
+
+	policy := TrafficPolicy{}
+	if dest, ok := config.Dest[name]; ok && dest.Traffic != nil {
+		policy = *dest.Traffic
+	} else if config.Traffic != nil {
+		policy = *config.Traffic
+	}
+
+	return policy, policy.Validate()
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrafficPolicyThresholds(t *testing.T) {
+	if got := (TrafficPolicy{}).Thresholds(); !reflect.DeepEqual(got, DefaultTrafficAlerts) {
+		t.Errorf("expected default thresholds, got %v", got)
+	}
+	if got := (TrafficPolicy{Alerts: []int{90, 50, 90}}).Thresholds(); !reflect.DeepEqual(got, []int{50, 90}) {
+		t.Errorf("expected sorted unique thresholds, got %v", got)
+	}
+}
+
+func TestLoadTrafficPolicy(t *testing.T) {
+	writeTestConfig(t, `version: 1
+traffic:
+  stop-at: 150
+dest:
+  web:
+    ssh: root@1.2.3.4
+    traffic:
+      alerts: [50]
+  db:
+    ssh: root@5.6.7.8
+`)
+
+	web, err := LoadTrafficPolicy("web")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(web, TrafficPolicy{Alerts: []int{50}}) {
+		t.Errorf("expected destination policy to override the global one, got %+v", web)
+	}
+
+	db, err := LoadTrafficPolicy("db")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if db.StopAt != 150 {
+		t.Errorf("expected global policy, got %+v", db)
+	}
+}
@@ -7,14 +7,12 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
-	"github.com/olekukonko/tablewriter/renderer"
-	"github.com/olekukonko/tablewriter/tw"
 	"github.com/spf13/cobra"
 
 	"lucky-go/finance"
 	"lucky-go/forex"
 	"lucky-go/notify"
+	"lucky-go/output"
 	"lucky-go/valuation"
 )
 
@@ -234,21 +232,9 @@ func renderDailyReport(r *DailyReport) {
 	cyanBold := color.New(color.FgCyan, color.Bold).SprintFunc()
 	redBold := color.New(color.FgRed, color.Bold).SprintFunc()
 
-	cfg := renderer.ColorizedConfig{
-		Borders: tw.Border{Left: tw.On, Right: tw.On, Top: tw.On, Bottom: tw.On},
-		Settings: tw.Settings{
-			Separators: tw.Separators{BetweenColumns: tw.On, ShowHeader: tw.On},
-			Lines:      tw.Lines{ShowTop: tw.On, ShowBottom: tw.On, ShowHeaderLine: tw.On},
-		},
-		Symbols: tw.NewSymbols(tw.StyleLight),
-	}
-
 	// PE 表格
 	fmt.Println(cyanBold("\n📊 PE 估值"))
-	peTable := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	peTable := output.NewTable(os.Stdout)
 	peTable.Header([]string{"", "国债", "AAA", "BAA"})
 	_ = peTable.Append([]string{"收益率", fmt.Sprintf("%.2f%%", r.Treasury), fmt.Sprintf("%.2f%%", r.AAA), fmt.Sprintf("%.2f%%", r.BAA)})
 	_ = peTable.Append([]string{"100% PE", greenBold(fmt.Sprintf("%.2f", 100/r.Treasury)), greenBold(fmt.Sprintf("%.2f", 100/r.AAA)), greenBold(fmt.Sprintf("%.2f", 100/r.BAA))})
@@ -265,10 +251,7 @@ func renderDailyReport(r *DailyReport) {
 		premiumColor = greenBold
 	}
 
-	capeTable := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	capeTable := output.NewTable(os.Stdout)
 	capeTable.Header([]string{"指标", "数值"})
 	_ = capeTable.Append([]string{"席勒 CAPE", cyanBold(fmt.Sprintf("%.2f", r.CAPE))})
 	_ = capeTable.Append([]string{"合理 PE", greenBold(fmt.Sprintf("%.2f", r.FairPE))})
@@ -277,10 +260,7 @@ func renderDailyReport(r *DailyReport) {
 
 	// Forex 表格
 	fmt.Println(cyanBold("\n💱 汇率信息"))
-	forexTable := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	forexTable := output.NewTable(os.Stdout)
 	forexTable.Header([]string{"", "汇率查询"})
 	_ = forexTable.Append([]string{"货币对", fmt.Sprintf("%s → %s", r.ForexResult.From, r.ForexResult.To)})
 	_ = forexTable.Append([]string{"汇率", greenBold(fmt.Sprintf("%.4f", r.ForexResult.Rate))})
@@ -10,12 +10,11 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
-	"github.com/olekukonko/tablewriter/renderer"
-	"github.com/olekukonko/tablewriter/tw"
 	"github.com/spf13/cobra"
 
+	"lucky-go/config"
 	"lucky-go/notify"
+	"lucky-go/output"
 )
 
 var push bool
@@ -25,8 +24,10 @@ func init() {
 }
 
 const (
-	// FRED API 基础 URL
-	fredAPIBaseURL = "https://api.stlouisfed.org/fred/series/observations"
+	// FRED API 基础 URL，可通过 endpoints.fred 替换
+	fredAPIBaseURL = "https://api.stlouisfed.org"
+	// 查询序列观测值的路径
+	fredObservationsPath = "/fred/series/observations"
 	// FRED Series IDs
 	seriesDGS10 = "DGS10" // 10年期国债收益率
 	seriesAAA   = "AAA"   // AAA 公司债收益率
@@ -164,15 +165,23 @@ func GetBAAYield() (float64, error) {
 
 // GetFredYield 从 FRED API 获取指定 series 的最新收益率数据
 func GetFredYield(seriesID string) (float64, error) {
-	// 获取 API Key
-	apiKey := os.Getenv("FRED_API_KEY")
+	// 获取 API Key，优先使用配置文件中加密保存的凭证
+	apiKey, err := config.LookupSecret(config.SecretFredAPIKey, "FRED_API_KEY")
+	if err != nil {
+		return 0, err
+	}
 	if apiKey == "" {
-		return 0, fmt.Errorf("FRED_API_KEY 环境变量未设置，请访问 https://fred.stlouisfed.org/docs/api/api_key.html 申请")
+		return 0, fmt.Errorf("没有找到 FRED API Key，请使用 lucky-go config secrets set fred-api-key 保存或设置 FRED_API_KEY 环境变量，API Key 可在 https://fred.stlouisfed.org/docs/api/api_key.html 申请")
+	}
+
+	baseURL, err := config.LoadEndpoint(config.EndpointFred, fredAPIBaseURL)
+	if err != nil {
+		return 0, err
 	}
 
 	// 构建 API URL
-	url := fmt.Sprintf("%s?series_id=%s&api_key=%s&file_type=json&sort_order=desc&limit=1",
-		fredAPIBaseURL, seriesID, apiKey)
+	url := fmt.Sprintf("%s%s?series_id=%s&api_key=%s&file_type=json&sort_order=desc&limit=1",
+		baseURL, fredObservationsPath, seriesID, apiKey)
 
 	// 构造请求
 	req, err := http.NewRequest("GET", url, nil)
@@ -227,21 +236,8 @@ func renderThreeColumnPETable(title1 string, yield1 float64, pe1 [5]float64,
 
 	colorFuncs := []func(a ...interface{}) string{greenBold, yellowBold, blueBold, redBold, redBold}
 
-	// 配置 Colorized 渲染器
-	cfg := renderer.ColorizedConfig{
-		Borders: tw.Border{Left: tw.On, Right: tw.On, Top: tw.On, Bottom: tw.On},
-		Settings: tw.Settings{
-			Separators: tw.Separators{BetweenColumns: tw.On, ShowHeader: tw.On},
-			Lines:      tw.Lines{ShowTop: tw.On, ShowBottom: tw.On, ShowHeaderLine: tw.On},
-		},
-		Symbols: tw.NewSymbols(tw.StyleLight),
-	}
-
 	// 创建表格
-	table := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	table := output.NewTable(os.Stdout)
 
 	// 设置表头
 	table.Header([]string{"", fmt.Sprintf("📊 %s", title1), fmt.Sprintf("📊 %s", title2), fmt.Sprintf("📊 %s", title3)})
@@ -6,8 +6,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"lucky-go/config"
 )
 
 // 创建一个模拟的HTTP客户端
@@ -19,6 +22,19 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return m.DoFunc(req)
 }
 
+// TestMain 让测试使用一个不存在的配置文件，不受开发者配置中 FRED 的 endpoints 和密钥库影响
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lucky-go-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(config.CONFIG_ENV, filepath.Join(dir, config.CONFIG_FILE))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestGet10YearTreasuryYield(t *testing.T) {
 	t.Run("ValidResponse", func(t *testing.T) {
 		// 保存原始客户端和环境变量
@@ -6,12 +6,10 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
-	"github.com/olekukonko/tablewriter/renderer"
-	"github.com/olekukonko/tablewriter/tw"
 	"github.com/spf13/cobra"
 
 	"lucky-go/notify"
+	"lucky-go/output"
 )
 
 var (
@@ -72,21 +70,8 @@ func renderForexTable(result *ExchangeResult) {
 	greenBold := color.New(color.FgGreen, color.Bold).SprintFunc()
 	cyanBold := color.New(color.FgCyan, color.Bold).SprintFunc()
 
-	// 配置 Colorized 渲染器
-	cfg := renderer.ColorizedConfig{
-		Borders: tw.Border{Left: tw.On, Right: tw.On, Top: tw.On, Bottom: tw.On},
-		Settings: tw.Settings{
-			Separators: tw.Separators{BetweenColumns: tw.On, ShowHeader: tw.On},
-			Lines:      tw.Lines{ShowTop: tw.On, ShowBottom: tw.On, ShowHeaderLine: tw.On},
-		},
-		Symbols: tw.NewSymbols(tw.StyleLight),
-	}
-
 	// 创建表格
-	table := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	table := output.NewTable(os.Stdout)
 
 	// 设置表头
 	table.Header([]string{"", "💱 汇率查询结果"})
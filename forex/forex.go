@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"lucky-go/config"
 )
 
 const (
-	// Frankfurter API 基础 URL（免费、无需 API Key）
-	frankfurterAPIBaseURL = "https://api.frankfurter.app/latest"
+	// Frankfurter API 基础 URL（免费、无需 API Key），可通过 endpoints.frankfurter 替换
+	frankfurterAPIBaseURL = "https://api.frankfurter.app"
+	// 最新汇率的路径
+	frankfurterLatestPath = "/latest"
 )
 
 // FrankfurterResponse 表示 Frankfurter API 的响应结构
@@ -43,9 +47,14 @@ var defaultHTTPClient HTTPClient = &http.Client{}
 // to: 目标货币代码 (如 CNY)
 // amount: 兑换金额
 func GetExchangeRate(from, to string, amount float64) (*ExchangeResult, error) {
+	baseURL, err := config.LoadEndpoint(config.EndpointFrankfurter, frankfurterAPIBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
 	// 构建 API URL
-	url := fmt.Sprintf("%s?from=%s&to=%s&amount=%.2f",
-		frankfurterAPIBaseURL, from, to, amount)
+	url := fmt.Sprintf("%s%s?from=%s&to=%s&amount=%.2f",
+		baseURL, frankfurterLatestPath, from, to, amount)
 
 	// 构造请求
 	req, err := http.NewRequest("GET", url, nil)
@@ -3,8 +3,12 @@ package forex
 import (
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"lucky-go/config"
 )
 
 // MockHTTPClient 模拟 HTTP 客户端
@@ -16,6 +20,19 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return m.DoFunc(req)
 }
 
+// TestMain 让测试使用一个不存在的配置文件，不受开发者配置中 Frankfurter 的 endpoints 影响
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lucky-go-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(config.CONFIG_ENV, filepath.Join(dir, config.CONFIG_FILE))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestGetExchangeRate(t *testing.T) {
 	t.Run("ValidResponse", func(t *testing.T) {
 		// 保存原始客户端
@@ -239,3 +256,30 @@ func TestForexCommand(t *testing.T) {
 		}
 	})
 }
+
+func TestGetExchangeRateEndpoint(t *testing.T) {
+	t.Setenv("LUCKY_GO_CONFIG", t.TempDir()+"/config.yaml")
+	config.SetEndpoint(config.EndpointFrankfurter, "http://127.0.0.1:8080/frankfurter/")
+	t.Cleanup(func() { config.SetEndpoint(config.EndpointFrankfurter, "") })
+
+	originalClient := defaultHTTPClient
+	defer func() { defaultHTTPClient = originalClient }()
+
+	var requested string
+	defaultHTTPClient = &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requested = req.URL.String()
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(`{"amount":1,"base":"USD","date":"2025-12-03","rates":{"CNY":7.2456}}`)),
+			}, nil
+		},
+	}
+
+	if _, err := GetExchangeRate("USD", "CNY", 1); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(requested, "http://127.0.0.1:8080/frankfurter/latest?from=USD") {
+		t.Errorf("expected request to the configured endpoint, got %q", requested)
+	}
+}
@@ -8,6 +8,9 @@ require (
 	github.com/spf13/cobra v1.10.1
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.3.6
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/lighthouse v1.2.2
+	golang.org/x/crypto v0.43.0
+	golang.org/x/sys v0.38.0
+	golang.org/x/term v0.36.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -23,5 +26,4 @@ require (
 	github.com/olekukonko/errors v1.1.0 // indirect
 	github.com/olekukonko/ll v0.1.3 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
-	golang.org/x/sys v0.38.0 // indirect
 )
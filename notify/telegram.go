@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
+
+	"lucky-go/config"
 )
 
-var telegramAPIBaseURL = "https://api.telegram.org/bot"
+// telegramAPIBaseURL 是 Telegram Bot API 的默认地址，可通过 endpoints.telegram 替换为镜像
+var telegramAPIBaseURL = "https://api.telegram.org"
 
 // setTelegramAPIBaseURL 用于测试时替换 API URL
 func setTelegramAPIBaseURL(url string) {
@@ -30,14 +32,21 @@ type TelegramResponse struct {
 
 // SendTelegramMessage 发送消息到 Telegram
 func SendTelegramMessage(message string) error {
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+	// 优先使用配置文件中加密保存的凭证
+	botToken, err := config.LookupSecret(config.SecretTelegramBotToken, "TELEGRAM_BOT_TOKEN")
+	if err != nil {
+		return err
+	}
 	if botToken == "" {
-		return fmt.Errorf("TELEGRAM_BOT_TOKEN 环境变量未设置")
+		return fmt.Errorf("没有找到 Telegram 机器人 token，请使用 lucky-go config secrets set telegram-bot-token 保存或设置 TELEGRAM_BOT_TOKEN 环境变量")
 	}
 
-	chatID := os.Getenv("TELEGRAM_CHAT_ID")
+	chatID, err := config.LookupSecret(config.SecretTelegramChatId, "TELEGRAM_CHAT_ID")
+	if err != nil {
+		return err
+	}
 	if chatID == "" {
-		return fmt.Errorf("TELEGRAM_CHAT_ID 环境变量未设置")
+		return fmt.Errorf("没有找到 Telegram chat ID，请使用 lucky-go config secrets set telegram-chat-id 保存或设置 TELEGRAM_CHAT_ID 环境变量")
 	}
 
 	// 构建请求体
@@ -52,8 +61,13 @@ func SendTelegramMessage(message string) error {
 		return fmt.Errorf("序列化消息失败: %w", err)
 	}
 
+	baseURL, err := config.LoadEndpoint(config.EndpointTelegram, telegramAPIBaseURL)
+	if err != nil {
+		return err
+	}
+
 	// 发送请求
-	url := fmt.Sprintf("%s%s/sendMessage", telegramAPIBaseURL, botToken)
+	url := fmt.Sprintf("%s/bot%s/sendMessage", baseURL, botToken)
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("发送请求失败: %w", err)
@@ -5,9 +5,25 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"lucky-go/config"
 )
 
+// TestMain 让测试使用一个不存在的配置文件，不受开发者配置中 Telegram 的 endpoints 和密钥库影响
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lucky-go-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(config.CONFIG_ENV, filepath.Join(dir, config.CONFIG_FILE))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestSendTelegramMessage(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -25,7 +41,7 @@ func TestSendTelegramMessage(t *testing.T) {
 			chatID:      "123456",
 			message:     "test message",
 			wantErr:     true,
-			errContains: "TELEGRAM_BOT_TOKEN",
+			errContains: "config secrets set telegram-bot-token",
 		},
 		{
 			name:        "MissingChatID",
@@ -33,7 +49,7 @@ func TestSendTelegramMessage(t *testing.T) {
 			chatID:      "",
 			message:     "test message",
 			wantErr:     true,
-			errContains: "TELEGRAM_CHAT_ID",
+			errContains: "config secrets set telegram-chat-id",
 		},
 		{
 			name:       "SuccessfulSend",
@@ -45,13 +61,13 @@ func TestSendTelegramMessage(t *testing.T) {
 			wantErr:    false,
 		},
 		{
-			name:       "APIError",
-			botToken:   "test-token",
-			chatID:     "123456",
-			message:    "test message",
-			serverResp: TelegramResponse{OK: false, Description: "Bad Request: chat not found"},
-			serverCode: http.StatusOK,
-			wantErr:    true,
+			name:        "APIError",
+			botToken:    "test-token",
+			chatID:      "123456",
+			message:     "test message",
+			serverResp:  TelegramResponse{OK: false, Description: "Bad Request: chat not found"},
+			serverCode:  http.StatusOK,
+			wantErr:     true,
 			errContains: "chat not found",
 		},
 	}
@@ -112,7 +128,7 @@ func TestSendTelegramMessage(t *testing.T) {
 
 				// 替换 API URL
 				origBaseURL := telegramAPIBaseURL
-				setTelegramAPIBaseURL(server.URL + "/bot")
+				setTelegramAPIBaseURL(server.URL)
 				defer setTelegramAPIBaseURL(origBaseURL)
 			}
 
@@ -147,7 +163,7 @@ func TestSendTelegramMessage_NetworkError(t *testing.T) {
 
 	// 使用无效的 URL 模拟网络错误
 	origBaseURL := telegramAPIBaseURL
-	setTelegramAPIBaseURL("http://invalid.invalid.invalid/bot")
+	setTelegramAPIBaseURL("http://invalid.invalid.invalid")
 	defer setTelegramAPIBaseURL(origBaseURL)
 
 	err := SendTelegramMessage("test message")
@@ -175,7 +191,7 @@ func TestSendTelegramMessage_InvalidJSONResponse(t *testing.T) {
 	defer server.Close()
 
 	origBaseURL := telegramAPIBaseURL
-	setTelegramAPIBaseURL(server.URL + "/bot")
+	setTelegramAPIBaseURL(server.URL)
 	defer setTelegramAPIBaseURL(origBaseURL)
 
 	err := SendTelegramMessage("test message")
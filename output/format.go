@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 输出格式，由各命令的 -o/--output 标志指定
+const (
+	Table = "table"
+	JSON  = "json"
+	YAML  = "yaml"
+)
+
+// ValidateFormat 检查 format 是否为 table、json 或 yaml
+func ValidateFormat(format string) error {
+	switch format {
+	case Table, JSON, YAML:
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式 %v，可用: table、json、yaml", format)
+	}
+}
+
+// Write 以 JSON 或 YAML 格式把 value 写入 w
+func Write(w io.Writer, format string, value any) error {
+	var data []byte
+	var err error
+	switch format {
+	case JSON:
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	case YAML:
+		data, err = yaml.Marshal(value)
+	default:
+		return fmt.Errorf("不支持的输出格式 %v", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,38 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	value := map[string]int{"exit-code": 0}
+
+	tests := []struct {
+		format, expected string
+	}{
+		{JSON, "{\n  \"exit-code\": 0\n}\n"},
+		{YAML, "exit-code: 0\n"},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		if err := Write(out, tt.format, value); err != nil || out.String() != tt.expected {
+			t.Errorf("Write(%v) = %q, %v, expected %q", tt.format, out.String(), err, tt.expected)
+		}
+	}
+
+	if err := Write(&bytes.Buffer{}, Table, value); err == nil {
+		t.Error("expected table format to be rejected")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{Table, JSON, YAML} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("expected %v to be valid, got: %v", format, err)
+		}
+	}
+	if err := ValidateFormat("xml"); err == nil {
+		t.Error("expected xml to be rejected")
+	}
+}
@@ -0,0 +1,27 @@
+// Package output 提供各命令共用的终端输出格式。
+package output
+
+import (
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/renderer"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// NewTable 创建 lucky-go 统一风格的彩色表格：细线边框、表头居中
+func NewTable(w io.Writer) *tablewriter.Table {
+	cfg := renderer.ColorizedConfig{
+		Borders: tw.Border{Left: tw.On, Right: tw.On, Top: tw.On, Bottom: tw.On},
+		Settings: tw.Settings{
+			Separators: tw.Separators{BetweenColumns: tw.On, ShowHeader: tw.On},
+			Lines:      tw.Lines{ShowTop: tw.On, ShowBottom: tw.On, ShowHeaderLine: tw.On},
+		},
+		Symbols: tw.NewSymbols(tw.StyleLight),
+	}
+
+	return tablewriter.NewTable(w,
+		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
+		tablewriter.WithHeaderAlignment(tw.AlignCenter),
+	)
+}
@@ -0,0 +1,23 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewTable(t *testing.T) {
+	out := &bytes.Buffer{}
+	table := NewTable(out)
+	table.Header([]string{"名称", "状态"})
+	_ = table.Append([]string{"web-1", "RUNNING"})
+	if err := table.Render(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	for _, expected := range []string{"名称", "web-1", "RUNNING", "┌", "┘"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected table to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
 	"lucky-go/cloud"
+	"lucky-go/config"
 	"lucky-go/daily"
 	"lucky-go/finance"
 	"lucky-go/forex"
 	"lucky-go/game"
 	"lucky-go/server/ssh"
 	"lucky-go/valuation"
-	"os"
-
-	"github.com/spf13/cobra"
 )
 
+// cfgFile 保存 --config 标志指定的配置文件路径
+var cfgFile string
+
+// endpointFlags 保存 --endpoint-<名称> 标志指定的上游 API 地址
+var endpointFlags = map[string]*string{}
+
 // rootCmd 表示在不带任何子命令的情况下调用时的基础命令
 var rootCmd = &cobra.Command{
 	Use:   "lucky-go",
@@ -24,11 +34,15 @@ var rootCmd = &cobra.Command{
 	// Run: func(cmd *cobra.Command, args []string) { },
 }
 
-// Execute 执行根命令并通过退出状态1处理任何错误。
+// Execute 执行根命令并处理任何错误：错误带有 ExitCode 方法时使用其退出码，否则退出状态为1。
 // 此函数由 main.main() 调用，且只需执行一次。
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		var coded interface{ ExitCode() int }
+		if errors.As(err, &coded) {
+			os.Exit(coded.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
@@ -38,16 +52,29 @@ func init() {
 	// Cobra 支持持久标志，如果在此处定义，
 	// 将对应用程序全局有效。
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.lucky-go.yaml)")
+	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "配置文件路径（默认依次查找 $LUCKY_GO_CONFIG、$XDG_CONFIG_HOME/lucky-go/config.yaml、~/.lucky-go/config.yaml）")
+	for _, name := range config.EndpointNames {
+		endpointFlags[name] = rootCmd.PersistentFlags().String("endpoint-"+name, "", fmt.Sprintf("%v API 地址，覆盖配置中的 endpoints.%v", name, name))
+	}
 
 	// Cobra 还支持本地标志，仅在直接调用此操作时运行。
 	rootCmd.Flags().BoolP("toggle", "t", false, "切换选项的帮助消息")
 
 	rootCmd.AddCommand(ssh.NewCommand())
 	rootCmd.AddCommand(cloud.NewCommand())
+	rootCmd.AddCommand(config.NewCommand())
 	rootCmd.AddCommand(game.NewCommand())
 	rootCmd.AddCommand(finance.NewCommand())
 	rootCmd.AddCommand(forex.NewCommand())
 	rootCmd.AddCommand(valuation.NewCommand())
 	rootCmd.AddCommand(daily.NewCommand())
 }
+
+// initConfig 在执行任何命令之前将 --config 和 --endpoint-<名称> 标志传递给配置模块。
+func initConfig() {
+	config.SetConfigFile(cfgFile)
+	for name, value := range endpointFlags {
+		config.SetEndpoint(name, *value)
+	}
+}
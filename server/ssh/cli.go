@@ -4,11 +4,12 @@ package ssh
 import (
 	"errors"
 	"fmt"
-	"lucky-go/config"
 	"os"
 	"os/exec"
 
 	"github.com/spf13/cobra"
+
+	"lucky-go/config"
 )
 
 // 为测试目的定义一个可替换的执行命令函数
@@ -18,8 +19,11 @@ var execCommand = exec.Command
 var sshCmd = &cobra.Command{
 	Use:   "ssh [destination]",
 	Short: "与目标建立 SSH 连接",
-	Long:  `使用配置中指定的目标名称通过 SSH 连接到远程服务器。`,
-	Args:  cobra.ExactArgs(1),
+	Long: `使用配置中指定的目标名称通过 SSH 连接到远程服务器。
+
+也可以传入选择器（如 web-*、@group、tag=prod），但必须恰好匹配一个目标。
+在多个目标上执行命令请使用 ssh exec。`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return errors.New("必须提供目标")
@@ -31,7 +35,7 @@ var sshCmd = &cobra.Command{
 			return errors.New("必须提供目标")
 		}
 
-		destinationInstance, err := config.LoadDestinationInstance(destination)
+		destinationInstance, err := config.LoadDestination(destination)
 		if err != nil {
 			return err
 		}
@@ -39,7 +43,7 @@ var sshCmd = &cobra.Command{
 		ssh := destinationInstance.Ssh
 
 		fmt.Printf("get dest ssh %v\n", ssh)
-		sshProcess := execCommand("ssh", ssh)
+		sshProcess := execCommand("ssh", destinationInstance.SshArgs()...)
 		sshProcess.Stdin = os.Stdin
 		sshProcess.Stdout = os.Stdout
 		sshProcess.Stderr = os.Stderr
@@ -73,6 +77,7 @@ func newCommand() *cobra.Command {
 // NewCommand 为服务器模块创建并返回带有子命令的 SSH 命令。
 func NewCommand() *cobra.Command {
 	sshCmd.AddCommand(newCommand())
+	sshCmd.AddCommand(newExecCommand())
 
 	return sshCmd
 }
@@ -3,6 +3,7 @@ package ssh
 import (
 	"os"
 	"os/exec"
+	"strconv"
 	"testing"
 
 	"lucky-go/config"
@@ -152,6 +153,12 @@ func TestHelperProcess(t *testing.T) {
 		if output != "" {
 			os.Stdout.Write([]byte(output))
 		}
+		if stderr := os.Getenv("SSH_STDERR"); stderr != "" {
+			os.Stderr.Write([]byte(stderr))
+		}
+		if code, err := strconv.Atoi(os.Getenv("SSH_EXIT_CODE")); err == nil {
+			os.Exit(code)
+		}
 	}
 
 	os.Exit(0)
@@ -0,0 +1,270 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"lucky-go/config"
+	"lucky-go/output"
+)
+
+// hostColors 是为每个目标的输出前缀轮流使用的颜色
+var hostColors = []color.Attribute{color.FgCyan, color.FgMagenta, color.FgYellow, color.FgBlue, color.FgGreen, color.FgHiCyan, color.FgHiMagenta, color.FgHiYellow}
+
+// ExecResult 是在单个目标上执行命令的结果
+type ExecResult struct {
+	Destination string `json:"destination" yaml:"destination"`
+	Ssh         string `json:"ssh" yaml:"ssh"`
+	// ExitCode 是命令的退出码，ssh 无法启动时为 -1，连接失败时 ssh 返回 255
+	ExitCode int    `json:"exit-code" yaml:"exit-code"`
+	Stdout   string `json:"stdout" yaml:"stdout"`
+	Stderr   string `json:"stderr" yaml:"stderr"`
+	// DurationMs 是执行耗时，单位为毫秒
+	DurationMs int64  `json:"duration-ms" yaml:"duration-ms"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// execFailure 是 ssh exec 的失败，带有 lucky-go 的退出码
+type execFailure struct {
+	message string
+	code    int
+}
+
+// Error 实现 error 接口
+func (e *execFailure) Error() string {
+	return e.message
+}
+
+// ExitCode 返回 lucky-go 的退出码
+func (e *execFailure) ExitCode() int {
+	return e.code
+}
+
+// newExecCommand 创建 ssh exec 子命令，在多个目标上并行执行命令
+func newExecCommand() *cobra.Command {
+	var parallel int
+	var connectTimeout time.Duration
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "exec [selector...] -- [command...]",
+		Short: "在多个目标上并行执行命令",
+		Long: `通过 ssh 在选择器匹配的所有目标上执行命令，最多同时连接 --parallel 个目标。
+
+表格输出时每行输出以目标名称为前缀并按目标着色，标准错误的输出写入标准错误，
+全部结束后显示每个目标的退出码和耗时。-o json 或 -o yaml 时只输出每个目标的 stdout、stderr 和退出码。
+ssh 以 BatchMode 运行，不会提示输入密码。只有一个目标时 lucky-go 以命令的退出码退出，
+多个目标中有失败时退出码为 1。`,
+		Example: `  lucky-go ssh exec @web -- df -h /
+  lucky-go ssh exec 'tag=prod' --parallel 8 -- 'uptime; free -m'
+  lucky-go ssh exec web-* -o json -- cat /etc/os-release`,
+		// 命令失败时只输出错误，不输出用法
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := output.ValidateFormat(format); err != nil {
+				return err
+			}
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 {
+				return errors.New("请使用 -- 分隔选择器和要执行的命令")
+			}
+			selectors, command := args[:dash], strings.Join(args[dash:], " ")
+			if len(selectors) == 0 {
+				return errors.New("必须提供目标或选择器")
+			}
+			if strings.TrimSpace(command) == "" {
+				return errors.New("必须提供要执行的命令")
+			}
+			if parallel < 1 {
+				return errors.New("--parallel 必须大于 0")
+			}
+			// ssh 的 ConnectTimeout 以秒为单位，0 表示不限时
+			if connectTimeout < time.Second {
+				return errors.New("--connect-timeout 不能小于 1s")
+			}
+
+			dests, err := config.LoadDestinations(selectors...)
+			if err != nil {
+				return err
+			}
+
+			var stdout, stderr io.Writer
+			if format == output.Table {
+				stdout, stderr = cmd.OutOrStdout(), cmd.ErrOrStderr()
+			}
+			results := runExec(dests, command, parallel, connectTimeout, stdout, stderr)
+
+			if format != output.Table {
+				if err := output.Write(cmd.OutOrStdout(), format, results); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout())
+				renderExecTable(cmd.OutOrStdout(), results)
+			}
+			return execError(results)
+		},
+	}
+
+	cmd.Flags().IntVar(&parallel, "parallel", 4, "最多同时执行的目标数")
+	cmd.Flags().DurationVar(&connectTimeout, "connect-timeout", 10*time.Second, "ssh 连接超时时间，不小于 1s，按整秒向上取整")
+	cmd.Flags().StringVarP(&format, "output", "o", output.Table, "输出格式：table、json 或 yaml")
+
+	return cmd
+}
+
+// runExec 以最多 parallel 个并发在 dests 上执行 command，结果顺序与 dests 一致。
+// stdout 和 stderr 不为 nil 时把每个目标的输出逐行加上前缀后写入。
+func runExec(dests []config.Destination, command string, parallel int, connectTimeout time.Duration, stdout, stderr io.Writer) []ExecResult {
+	width := 0
+	for _, dest := range dests {
+		width = max(width, len(dest.Name))
+	}
+
+	results := make([]ExecResult, len(dests))
+	var mu sync.Mutex
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i := range dests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			dest := dests[i]
+			var out, errOut bytes.Buffer
+			var writers []*prefixWriter
+			cmdOut, cmdErr := io.Writer(&out), io.Writer(&errOut)
+			if stdout != nil && stderr != nil {
+				prefix := color.New(hostColors[i%len(hostColors)], color.Bold).Sprintf("%-*s |", width, dest.Name) + " "
+				outPrefix := &prefixWriter{mu: &mu, w: stdout, prefix: prefix}
+				errPrefix := &prefixWriter{mu: &mu, w: stderr, prefix: prefix}
+				writers = append(writers, outPrefix, errPrefix)
+				cmdOut, cmdErr = io.MultiWriter(&out, outPrefix), io.MultiWriter(&errOut, errPrefix)
+			}
+
+			sshArgs := []string{"-o", "BatchMode=yes", "-o", fmt.Sprintf("ConnectTimeout=%d", int(math.Ceil(connectTimeout.Seconds())))}
+			sshArgs = append(append(sshArgs, dest.SshArgs()...), command)
+			process := execCommand("ssh", sshArgs...)
+			process.Stdout = cmdOut
+			process.Stderr = cmdErr
+
+			start := time.Now()
+			err := process.Run()
+			for _, writer := range writers {
+				writer.Flush()
+			}
+
+			result := ExecResult{
+				Destination: dest.Name,
+				Ssh:         dest.Ssh,
+				Stdout:      out.String(),
+				Stderr:      errOut.String(),
+				DurationMs:  time.Since(start).Milliseconds(),
+			}
+			var exitErr interface{ ExitCode() int }
+			switch {
+			case err == nil:
+			case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+				result.ExitCode = exitErr.ExitCode()
+			default:
+				result.ExitCode = -1
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// prefixWriter 把写入的内容按行加上前缀后写入 w，多个 prefixWriter 共用 mu 保证行不交错
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+// Write 实现 io.Writer 接口，不完整的行保留到下一次写入或 Flush
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(data), nil
+}
+
+// Flush 输出最后一行没有换行符的内容
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+// writeLine 加锁后输出带前缀的一行
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%v%s", p.prefix, line)
+}
+
+// execError 汇总执行结果：只有一个目标时使用命令的退出码，多个目标中有失败时退出码为 1
+func execError(results []ExecResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.ExitCode != 0 {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+
+	if len(results) == 1 {
+		result := results[0]
+		if result.Error != "" {
+			return fmt.Errorf("在 %v 上执行命令失败: %v", result.Destination, result.Error)
+		}
+		return &execFailure{message: fmt.Sprintf("命令在 %v 上以退出码 %d 结束", result.Destination, result.ExitCode), code: result.ExitCode}
+	}
+	return &execFailure{message: fmt.Sprintf("%d/%d 个目标执行失败", failed, len(results)), code: 1}
+}
+
+// renderExecTable 渲染每个目标的退出码和耗时
+func renderExecTable(w io.Writer, results []ExecResult) {
+	green := color.New(color.FgGreen, color.Bold).SprintFunc()
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+
+	table := output.NewTable(w)
+
+	table.Header([]string{"目标", "退出码", "耗时", "错误"})
+	for _, result := range results {
+		code := green("0")
+		if result.ExitCode != 0 {
+			code = red(fmt.Sprint(result.ExitCode))
+		}
+		duration := (time.Duration(result.DurationMs) * time.Millisecond).String()
+		_ = table.Append([]string{result.Destination, code, duration, result.Error})
+	}
+
+	_ = table.Render()
+}
@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"lucky-go/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setupExecConfig 写入测试配置，并把 ssh 替换为按目标返回 env 中输出和退出码的辅助进程
+func setupExecConfig(t *testing.T, env map[string][]string) *[][]string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), config.CONFIG_FILE)
+	t.Setenv(config.CONFIG_ENV, path)
+	data, err := yaml.Marshal(config.Config{
+		Version: 1,
+		Dest: map[string]config.DestinationInstance{
+			"web-1": {Ssh: "root@10.0.0.1", Tags: []string{"web"}},
+			"web-2": {Ssh: "root@10.0.0.2", Port: 2222, Tags: []string{"web"}},
+			"db":    {Ssh: "root@10.0.0.3"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]string
+	var mu sync.Mutex
+	originalExecCommand := execCommand
+	t.Cleanup(func() { execCommand = originalExecCommand })
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		mu.Lock()
+		calls = append(calls, arg)
+		mu.Unlock()
+		cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)...)
+		cmd.Env = []string{"GO_HELPER_PROCESS=1"}
+		for _, a := range arg {
+			cmd.Env = append(cmd.Env, env[a]...)
+		}
+		return cmd
+	}
+	return &calls
+}
+
+// runExecCommand 执行 ssh exec 并分别返回标准输出和标准错误
+func runExecCommand(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+
+	cmd := newExecCommand()
+	stdout, stderr := &strings.Builder{}, &strings.Builder{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), stderr.String(), err
+}
+
+func TestExecCommand(t *testing.T) {
+	calls := setupExecConfig(t, map[string][]string{
+		"root@10.0.0.1": {"SSH_OUTPUT=/dev/vda1 40%\npartial"},
+		"root@10.0.0.2": {"SSH_OUTPUT=/dev/vda1 95%\n", "SSH_STDERR=warning: disk almost full\n", "SSH_EXIT_CODE=2"},
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		stdout, stderr, err := runExecCommand(t, "tag=web", "--parallel", "2", "--", "df", "-h", "/")
+		if err == nil || err.Error() != "1/2 个目标执行失败" {
+			t.Errorf("expected one failure, got: %v", err)
+		}
+		for _, line := range []string{"web-1 | /dev/vda1 40%\n", "web-1 | partial\n", "web-2 | /dev/vda1 95%\n"} {
+			if !strings.Contains(stdout, line) {
+				t.Errorf("expected prefixed line %q, got:\n%s", line, stdout)
+			}
+		}
+		if !strings.Contains(stderr, "web-2 | warning: disk almost full") {
+			t.Errorf("expected prefixed stderr, got:\n%s", stderr)
+		}
+		if !strings.Contains(stdout, "退出码") || strings.Contains(stdout, "db") {
+			t.Errorf("expected a summary of the selected destinations, got:\n%s", stdout)
+		}
+
+		for _, call := range *calls {
+			if call[len(call)-1] != "df -h /" || call[1] != "BatchMode=yes" {
+				t.Errorf("unexpected ssh arguments: %v", call)
+			}
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		stdout, stderr, _ := runExecCommand(t, "web-*", "-o", "json", "--", "df")
+		var results []ExecResult
+		if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stdout, err)
+		}
+		if len(results) != 2 || results[0].Stdout != "/dev/vda1 40%\npartial" || results[1].ExitCode != 2 ||
+			results[1].Stderr != "warning: disk almost full\n" {
+			t.Errorf("unexpected results: %+v", results)
+		}
+		if strings.Contains(stderr, "warning") {
+			t.Errorf("expected no streamed output, got %q", stderr)
+		}
+	})
+
+	t.Run("SingleExitCode", func(t *testing.T) {
+		_, _, err := runExecCommand(t, "web-2", "--", "false")
+		var coded interface{ ExitCode() int }
+		if !errors.As(err, &coded) || coded.ExitCode() != 2 {
+			t.Errorf("expected the command's exit code, got: %v", err)
+		}
+		if _, _, err := runExecCommand(t, "db", "--", "true"); err != nil {
+			t.Errorf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, _, err := runExecCommand(t, "tag=web", "df"); err == nil {
+			t.Error("expected missing -- to be rejected")
+		}
+		if _, _, err := runExecCommand(t, "tag=web", "--"); err == nil {
+			t.Error("expected missing command to be rejected")
+		}
+		if _, _, err := runExecCommand(t, "missing", "--", "df"); err == nil {
+			t.Error("expected unknown destination to be rejected")
+		}
+		// ConnectTimeout=0 表示不限时，小于 1s 的值不能被截断为 0
+		if _, _, err := runExecCommand(t, "db", "--connect-timeout", "500ms", "--", "true"); err == nil {
+			t.Error("expected a connect timeout below 1s to be rejected")
+		}
+	})
+
+	t.Run("ConnectTimeout", func(t *testing.T) {
+		*calls = nil
+		if _, _, err := runExecCommand(t, "db", "--connect-timeout", "1500ms", "--", "true"); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if len(*calls) != 1 || (*calls)[0][3] != "ConnectTimeout=2" {
+			t.Errorf("expected the connect timeout to be rounded up to whole seconds, got: %v", *calls)
+		}
+	})
+}
@@ -5,13 +5,11 @@ import (
 	"os"
 
 	"github.com/fatih/color"
-	"github.com/olekukonko/tablewriter"
-	"github.com/olekukonko/tablewriter/renderer"
-	"github.com/olekukonko/tablewriter/tw"
 	"github.com/spf13/cobra"
 
 	"lucky-go/finance"
 	"lucky-go/notify"
+	"lucky-go/output"
 )
 
 var push bool
@@ -103,19 +101,7 @@ func renderCAPETable(cape, fairPE, treasury, premium float64) {
 		premiumColor = greenBold
 	}
 
-	cfg := renderer.ColorizedConfig{
-		Borders: tw.Border{Left: tw.On, Right: tw.On, Top: tw.On, Bottom: tw.On},
-		Settings: tw.Settings{
-			Separators: tw.Separators{BetweenColumns: tw.On, ShowHeader: tw.On},
-			Lines:      tw.Lines{ShowTop: tw.On, ShowBottom: tw.On, ShowHeaderLine: tw.On},
-		},
-		Symbols: tw.NewSymbols(tw.StyleLight),
-	}
-
-	table := tablewriter.NewTable(os.Stdout,
-		tablewriter.WithRenderer(renderer.NewColorized(cfg)),
-		tablewriter.WithHeaderAlignment(tw.AlignCenter),
-	)
+	table := output.NewTable(os.Stdout)
 
 	table.Header([]string{"指标", "📊 市场估值对比"})
 
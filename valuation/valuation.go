@@ -7,11 +7,15 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+
+	"lucky-go/config"
 )
 
 const (
-	// Multpl.com Shiller PE 页面 URL
-	multplShillerPEURL = "https://www.multpl.com/shiller-pe"
+	// Multpl.com 基础 URL，可通过 endpoints.multpl 替换
+	multplBaseURL = "https://www.multpl.com"
+	// Shiller PE 页面的路径
+	multplShillerPEPath = "/shiller-pe"
 )
 
 // HTTPClient 定义 HTTP 客户端接口
@@ -32,7 +36,12 @@ type CAPEResult struct {
 
 // GetShillerCAPE 从 Multpl.com 获取当前席勒 CAPE 值
 func GetShillerCAPE() (float64, error) {
-	req, err := http.NewRequest("GET", multplShillerPEURL, nil)
+	baseURL, err := config.LoadEndpoint(config.EndpointMultpl, multplBaseURL)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("GET", baseURL+multplShillerPEPath, nil)
 	if err != nil {
 		return 0, fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"lucky-go/config"
 )
 
 // MockHTTPClient 模拟 HTTP 客户端
@@ -17,6 +21,19 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return m.DoFunc(req)
 }
 
+// TestMain 让测试使用一个不存在的配置文件，不受开发者配置中 multpl 的 endpoints 影响
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lucky-go-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(config.CONFIG_ENV, filepath.Join(dir, config.CONFIG_FILE))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestGetShillerCAPE(t *testing.T) {
 	t.Run("ValidResponse", func(t *testing.T) {
 		// 保存原始客户端